@@ -7,6 +7,7 @@ import (
 )
 
 //Чистка событий: отдельная горутина, каждые X минут должна переносить в архив старые события
+//и удалять просроченные ответы на запросы с Idempotency-Key
 
 type CleaningWorker struct {
 	period uint32
@@ -15,6 +16,7 @@ type CleaningWorker struct {
 
 type RepoProvider interface {
 	ArchiveOldEvents(ctx context.Context) error
+	DeleteExpiredIdempotencyRecords(ctx context.Context) error
 }
 
 func NewCleaningWorker(period uint32, repo RepoProvider) *CleaningWorker {
@@ -44,10 +46,12 @@ func (c *CleaningWorker) Start(ctx context.Context) {
 
 func (c *CleaningWorker) runCleanup(ctx context.Context) {
 	cleanupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	err := c.repo.ArchiveOldEvents(cleanupCtx)
-	cancel()
+	defer cancel()
 
-	if err != nil {
+	if err := c.repo.ArchiveOldEvents(cleanupCtx); err != nil {
 		log.Printf("Error archiving old events: %v", err)
 	}
+	if err := c.repo.DeleteExpiredIdempotencyRecords(cleanupCtx); err != nil {
+		log.Printf("Error deleting expired idempotency records: %v", err)
+	}
 }
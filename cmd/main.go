@@ -4,13 +4,15 @@ import (
 	"github.com/dontpanicw/calendar/config"
 	"github.com/dontpanicw/calendar/internal/app"
 	"log"
+	// Встроенная база часовых поясов: пояса пользователей не зависят от tzdata в образе
+	_ "time/tzdata"
 )
 
 func main() {
 
 	cfg, err := config.NewConfig()
 	if err != nil {
-		log.Fatal("error creating config")
+		log.Fatalf("error creating config: %v", err)
 	}
 
 	if err := app.Start(cfg); err != nil {
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +14,22 @@ const DefaultHTTPPort = ":8080"
 type Config struct {
 	HTTPPort        string
 	PostgresConnStr string
+
+	// Доставка напоминаний
+	NotifyFile   string // файл для канала log; пусто — stdout
+	SMTPAddr     string // host:port; пусто — канал email отключён
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Аутентификация
+	AuthDisabled     bool   // только для локальной разработки: пользователь берётся из user_id запроса
+	JWTSecret        string // секрет HS256; пусто — HS256 не принимается
+	JWTPublicKeyFile string // PEM-файл публичного ключа RS256; пусто — RS256 не принимается
+	JWTIssuer        string // ожидаемый iss; пусто — не проверяется
+	JWTAudience      string // ожидаемый aud; пусто — не проверяется
+
+	IdempotencyTTL time.Duration // сколько хранятся ответы на запросы с Idempotency-Key; 0 — значение по умолчанию
 }
 
 func NewConfig() (*Config, error) {
@@ -38,5 +56,25 @@ func NewConfig() (*Config, error) {
 		cfg.PostgresConnStr = postgresConnStr
 	}
 
+	cfg.NotifyFile = os.Getenv("NOTIFY_FILE")
+	cfg.SMTPAddr = os.Getenv("SMTP_ADDR")
+	cfg.SMTPFrom = os.Getenv("SMTP_FROM")
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	cfg.AuthDisabled = os.Getenv("AUTH_DISABLED") == "true"
+	cfg.JWTSecret = os.Getenv("JWT_HS256_SECRET")
+	cfg.JWTPublicKeyFile = os.Getenv("JWT_RS256_PUBLIC_KEY_FILE")
+	cfg.JWTIssuer = os.Getenv("JWT_ISSUER")
+	cfg.JWTAudience = os.Getenv("JWT_AUDIENCE")
+
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL %q, use a positive duration like 24h", ttl)
+		}
+		cfg.IdempotencyTTL = d
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,209 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// Record событие из строки файла. Row — номер строки файла с 1 (в CSV строка 1 — заголовок).
+// Если Err не nil, строку разобрать не удалось, остальные строки читаются дальше.
+type Record struct {
+	Row   int
+	Event domain.Event
+	Err   error
+}
+
+// Decoder читает события по одному
+type Decoder struct {
+	format Format
+	csv    *csv.Reader
+	// columns номер колонки CSV по названию
+	columns map[string]int
+	r       *bufio.Reader
+	line    int
+	// next номер строки, с которой начнётся следующая запись CSV
+	next int
+}
+
+// NewDecoder для CSV сразу читает заголовок: колонки — из Columns в любом порядке,
+// date и description обязательны
+func NewDecoder(r io.Reader, format Format) (*Decoder, error) {
+	d := &Decoder{format: format}
+	if format != FormatCSV {
+		d.r = bufio.NewReader(r)
+		return d, nil
+	}
+	d.csv = csv.NewReader(r)
+	d.csv.FieldsPerRecord = -1
+	header, err := d.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid csv: header is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	if d.columns, err = parseHeader(header); err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+	d.advance(header)
+	d.csv.ReuseRecord = true
+	return d, nil
+}
+
+func parseHeader(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(Columns))
+	for _, c := range Columns {
+		known[c] = true
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Excel сохраняет CSV в UTF-8 с BOM
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"date", "description"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	return columns, nil
+}
+
+// Decode следующее событие; io.EOF — строк больше нет. Другая ошибка — файл дальше не прочитать,
+// тогда Row записи — строка, на которой чтение прервалось.
+func (d *Decoder) Decode() (Record, error) {
+	if d.format == FormatCSV {
+		return d.decodeCSV()
+	}
+	return d.decodeNDJSON()
+}
+
+func (d *Decoder) decodeCSV() (Record, error) {
+	record, err := d.csv.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		d.next = parseErr.Line + 1
+		return Record{Row: parseErr.StartLine, Err: parseErr.Err}, nil
+	}
+	if err != nil {
+		return Record{Row: d.next}, err
+	}
+	row, _ := d.csv.FieldPos(0)
+	d.advance(record)
+	if len(record) != len(d.columns) {
+		return Record{Row: row, Err: fmt.Errorf("expected %d fields, got %d", len(d.columns), len(record))}, nil
+	}
+	event, err := d.event(record)
+	return Record{Row: row, Event: event, Err: err}, nil
+}
+
+func (d *Decoder) decodeNDJSON() (Record, error) {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Record{Row: d.line + 1}, fmt.Errorf("failed to read events: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return Record{}, io.EOF
+			}
+			d.line++
+			continue
+		}
+		d.line++
+		record := Record{Row: d.line}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		// Опечатка в названии поля не должна молча терять значение
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&record.Event); err != nil {
+			record.Err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		return record, nil
+	}
+}
+
+// advance переводит d.next на строку после прочитанной записи CSV: поле в кавычках может занимать несколько строк
+func (d *Decoder) advance(record []string) {
+	last := len(record) - 1
+	line, _ := d.csv.FieldPos(last)
+	d.next = line + strings.Count(record[last], "\n") + 1
+}
+
+// event событие из строки CSV; колонки, которых нет в заголовке, остаются пустыми
+func (d *Decoder) event(record []string) (domain.Event, error) {
+	value := func(name string) string {
+		i, ok := d.columns[name]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	var event domain.Event
+	var err error
+	invalid := func(column string, err error) (domain.Event, error) {
+		return domain.Event{}, fmt.Errorf("%s: %w", column, err)
+	}
+	if event.EventId, err = parseID(value("event_id")); err != nil {
+		return invalid("event_id", err)
+	}
+	if event.UserId, err = parseID(value("user_id")); err != nil {
+		return invalid("user_id", err)
+	}
+	if event.Version, err = parseID(value("version")); err != nil {
+		return invalid("version", err)
+	}
+	if event.Date, err = parseTime(value("date")); err != nil {
+		return invalid("date", err)
+	}
+	if event.End, err = parseTime(value("end")); err != nil {
+		return invalid("end", err)
+	}
+	if event.AllDay, err = parseBool(value("all_day")); err != nil {
+		return invalid("all_day", err)
+	}
+	if event.IsArchived, err = parseBool(value("is_archived")); err != nil {
+		return invalid("is_archived", err)
+	}
+	if s := value("rrule"); s != "" {
+		if event.Recurrence, err = domain.ParseRecurrenceRule(s); err != nil {
+			return invalid("rrule", err)
+		}
+	}
+	if event.ExDates, err = parseTimes(value("exdates")); err != nil {
+		return invalid("exdates", err)
+	}
+	if s := value("overrides"); s != "" {
+		if err = json.Unmarshal([]byte(s), &event.Overrides); err != nil {
+			return invalid("overrides", err)
+		}
+	}
+	if event.ReminderOffsets, err = parseOffsets(value("reminder_offsets")); err != nil {
+		return invalid("reminder_offsets", err)
+	}
+	if event.ReminderTimes, err = parseTimes(value("reminder_times")); err != nil {
+		return invalid("reminder_times", err)
+	}
+	event.Description = value("description")
+	event.TimeZone = value("time_zone")
+	event.NotifyChannel = value("notify_channel")
+	event.NotifyTarget = value("notify_target")
+	event.ExternalUID = value("external_uid")
+	return event, nil
+}
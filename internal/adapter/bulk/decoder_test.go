@@ -0,0 +1,129 @@
+package bulk
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func decodeAll(t *testing.T, input string, format Format) []Record {
+	t.Helper()
+	d, err := NewDecoder(strings.NewReader(input), format)
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	var records []Record
+	for {
+		record, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestDecoder_CSVRows(t *testing.T) {
+	// Колонки в любом порядке и не все; BOM от Excel; ошибка в строке не мешает остальным
+	input := "\ufeffDescription,date,all_day,reminder_offsets\n" +
+		"Standup,2026-03-16T09:00:00+03:00,,15m\n" +
+		"\n" +
+		"Bad date,tomorrow,,\n" +
+		"\"Multi\nline\",2026-03-20,true,\n" +
+		"Too few fields\n" +
+		"Bad offset,2026-03-16T09:00:00Z,,soon\n"
+	records := decodeAll(t, input, FormatCSV)
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records, got %d: %+v", len(records), records)
+	}
+	wantRows := []int{2, 4, 5, 7, 8}
+	for i, record := range records {
+		if record.Row != wantRows[i] {
+			t.Errorf("record %d: expected row %d, got %d", i, wantRows[i], record.Row)
+		}
+	}
+	if r := records[0]; r.Err != nil || r.Event.Description != "Standup" || r.Event.Date.UTC().Hour() != 6 || len(r.Event.ReminderOffsets) != 1 {
+		t.Errorf("unexpected first record %+v", r)
+	}
+	if r := records[2]; r.Err != nil || !r.Event.AllDay || r.Event.Description != "Multi\nline" {
+		t.Errorf("unexpected all-day record %+v", r)
+	}
+	for _, i := range []int{1, 3, 4} {
+		if records[i].Err == nil {
+			t.Errorf("record %d: expected error", i)
+		}
+	}
+	if err := records[1].Err; err == nil || !strings.HasPrefix(err.Error(), "date:") {
+		t.Errorf("error must name the column, got %v", err)
+	}
+}
+
+func TestDecoder_CSVHeader(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"description,date,color\n",
+		"description,date,date\n",
+		"description,end\n",
+	} {
+		if _, err := NewDecoder(strings.NewReader(input), FormatCSV); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestDecoder_NDJSON(t *testing.T) {
+	input := `{"description":"Standup","date":"2026-03-16T09:00:00Z","rrule":"FREQ=DAILY"}` + "\n" +
+		"\n" +
+		`{"description":"Typo","dtae":"2026-03-16T09:00:00Z"}` + "\n" +
+		`not json` + "\n" +
+		`{"description":"No newline","date":"2026-03-17T09:00:00Z","reminder_offsets":["1h"]}`
+	records := decodeAll(t, input, FormatNDJSON)
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	if r := records[0]; r.Err != nil || r.Row != 1 || r.Event.Recurrence == nil {
+		t.Errorf("unexpected first record %+v", r)
+	}
+	if r := records[1]; r.Err == nil || r.Row != 3 {
+		t.Errorf("unknown field must fail on row 3, got %+v", r)
+	}
+	if r := records[2]; r.Err == nil || r.Row != 4 {
+		t.Errorf("invalid JSON must fail on row 4, got %+v", r)
+	}
+	if r := records[3]; r.Err != nil || r.Row != 5 || len(r.Event.ReminderOffsets) != 1 {
+		t.Errorf("unexpected last record %+v", r)
+	}
+}
+
+// TestDecoder_ReadError оборванный файл: Row указывает строку, на которой чтение прервалось
+func TestDecoder_ReadError(t *testing.T) {
+	errReset := errors.New("connection reset")
+	for _, tc := range []struct {
+		format Format
+		input  string
+		rows   int
+		want   int
+	}{
+		// Поле в кавычках на две строки: следующая запись начинается со строки 5
+		{FormatCSV, "description,date\nStandup,2026-03-16T09:00:00Z\n\"Multi\nline\",2026-03-20\n", 2, 5},
+		{FormatNDJSON, `{"description":"Standup","date":"2026-03-16T09:00:00Z"}` + "\n\n", 1, 3},
+	} {
+		d, err := NewDecoder(io.MultiReader(strings.NewReader(tc.input), iotest.ErrReader(errReset)), tc.format)
+		if err != nil {
+			t.Fatalf("%s: NewDecoder: %v", tc.format, err)
+		}
+		for i := range tc.rows {
+			if record, err := d.Decode(); err != nil || record.Err != nil {
+				t.Fatalf("%s: record %d: %v, %v", tc.format, i, err, record.Err)
+			}
+		}
+		record, err := d.Decode()
+		if !errors.Is(err, errReset) || record.Row != tc.want {
+			t.Errorf("%s: expected read error on row %d, got row %d, %v", tc.format, tc.want, record.Row, err)
+		}
+	}
+}
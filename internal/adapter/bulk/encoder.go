@@ -0,0 +1,140 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// Encoder пишет события по одному. Вывод буферизуется: после последнего события нужен Flush.
+type Encoder struct {
+	format Format
+	w      *bufio.Writer
+	csv    *csv.Writer
+	json   *json.Encoder
+	header bool
+	record []string
+}
+
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	e := &Encoder{format: format}
+	if format == FormatCSV {
+		e.csv = csv.NewWriter(w)
+		e.record = make([]string, len(Columns))
+		return e
+	}
+	e.w = bufio.NewWriter(w)
+	e.json = json.NewEncoder(e.w)
+	return e
+}
+
+// ndjsonEvent событие в NDJSON; reminder_offsets пишется и пустым, если напоминаний нет
+type ndjsonEvent struct {
+	domain.Event
+	ReminderOffsets *[]domain.Offset `json:"reminder_offsets,omitempty"`
+}
+
+// withoutReminders у сохранённого события без напоминаний списки могут быть nil, а при загрузке
+// nil означает напоминание по умолчанию, поэтому пустой список задаётся явно
+func withoutReminders(event domain.Event) domain.Event {
+	if len(event.ReminderOffsets) == 0 && len(event.ReminderTimes) == 0 {
+		event.ReminderOffsets = []domain.Offset{}
+	}
+	return event
+}
+
+// Encode пишет событие; серия пишется целиком, с правилом, исключениями и переносами
+func (e *Encoder) Encode(event domain.Event) error {
+	event = withoutReminders(event)
+	if e.format != FormatCSV {
+		record := ndjsonEvent{Event: event}
+		if event.ReminderOffsets != nil {
+			record.ReminderOffsets = &event.ReminderOffsets
+		}
+		if err := e.json.Encode(record); err != nil {
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+		return nil
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	if err := eventRecord(event, e.record); err != nil {
+		return err
+	}
+	if err := e.csv.Write(e.record); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// Flush дописывает буфер. В CSV без событий пишется только заголовок.
+func (e *Encoder) Flush() error {
+	if e.format != FormatCSV {
+		if err := e.w.Flush(); err != nil {
+			return fmt.Errorf("failed to write events: %w", err)
+		}
+		return nil
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	if err := e.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+	return nil
+}
+
+func (e *Encoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	if err := e.csv.Write(Columns); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	return nil
+}
+
+// eventRecord заполняет строку CSV в порядке Columns
+func eventRecord(event domain.Event, record []string) error {
+	var rule, overrides string
+	if event.Recurrence != nil {
+		rule = event.Recurrence.String()
+	}
+	if len(event.Overrides) > 0 {
+		data, err := json.Marshal(event.Overrides)
+		if err != nil {
+			return fmt.Errorf("failed to marshal overrides: %w", err)
+		}
+		overrides = string(data)
+	}
+	values := map[string]string{
+		"event_id":         formatID(event.EventId),
+		"user_id":          formatID(event.UserId),
+		"version":          formatID(event.Version),
+		"date":             formatTime(event.Date, event.AllDay),
+		"end":              formatTime(event.End, event.AllDay),
+		"all_day":          fmt.Sprint(event.AllDay),
+		"description":      event.Description,
+		"time_zone":        event.TimeZone,
+		"rrule":            rule,
+		"exdates":          formatTimes(event.ExDates, event.AllDay),
+		"reminder_offsets": formatOffsets(event.ReminderOffsets),
+		"reminder_times":   formatTimes(event.ReminderTimes, false),
+		"notify_channel":   event.NotifyChannel,
+		"notify_target":    event.NotifyTarget,
+		"is_archived":      fmt.Sprint(event.IsArchived),
+		"external_uid":     event.ExternalUID,
+		"overrides":        overrides,
+	}
+	for i, column := range Columns {
+		record[i] = values[column]
+	}
+	return nil
+}
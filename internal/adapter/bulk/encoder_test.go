@@ -0,0 +1,142 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func sampleEvents() []domain.Event {
+	rule, _ := domain.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10")
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	return []domain.Event{
+		{
+			EventId: 1, UserId: 7, Version: 3, Date: start, End: start.Add(30 * time.Minute),
+			Description: "Планёрка, «важно»", Recurrence: rule, TimeZone: "Europe/Moscow",
+			ExDates:         []time.Time{start.AddDate(0, 0, 2)},
+			Overrides:       []domain.OccurrenceOverride{{OriginalStart: start.AddDate(0, 0, 7), Date: start.AddDate(0, 0, 7).Add(time.Hour), End: start.AddDate(0, 0, 7).Add(90 * time.Minute)}},
+			ReminderOffsets: []domain.Offset{domain.Offset(10 * time.Minute), domain.Offset(24 * time.Hour)},
+			NotifyChannel:   "webhook", NotifyTarget: "https://example.com/hook",
+		},
+		{
+			EventId: 2, UserId: 7, Version: 1, Date: time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
+			End: time.Date(2026, 3, 22, 0, 0, 0, 0, time.UTC), AllDay: true, IsArchived: true,
+			Description: "Отпуск\nна море", ReminderTimes: []time.Time{time.Date(2026, 3, 19, 18, 0, 0, 0, time.UTC)},
+			ExternalUID: "trip@example.com",
+		},
+	}
+}
+
+func TestEncoder_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf, FormatCSV)
+	for _, event := range sampleEvents() {
+		if err := e.Encode(event); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	out := buf.String()
+	lines := strings.SplitN(out, "\n", 2)
+	if lines[0] != strings.Join(Columns, ",") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	for _, want := range []string{
+		`1,7,3,2026-03-16T09:00:00Z,2026-03-16T09:30:00Z,false,"Планёрка, «важно»",Europe/Moscow,"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10",2026-03-18T09:00:00Z,10m 1d,,webhook,https://example.com/hook,false,,"[{""original_start""`,
+		`2,7,1,2026-03-20,2026-03-22,true,"Отпуск` + "\n" + `на море",,,,,2026-03-19T18:00:00Z,,,true,trip@example.com,`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestEncoder_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, FormatCSV).Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if buf.String() != strings.Join(Columns, ",")+"\n" {
+		t.Errorf("empty export must contain only header, got %q", buf.String())
+	}
+	buf.Reset()
+	if err := NewEncoder(&buf, FormatNDJSON).Flush(); err != nil || buf.Len() != 0 {
+		t.Errorf("empty NDJSON export must be empty, got %q, %v", buf.String(), err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, format)
+		for _, event := range sampleEvents() {
+			_ = e.Encode(event)
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatalf("%s: Flush: %v", format, err)
+		}
+
+		d, err := NewDecoder(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: NewDecoder: %v", format, err)
+		}
+		for i, want := range sampleEvents() {
+			record, err := d.Decode()
+			if err != nil || record.Err != nil {
+				t.Fatalf("%s: event %d: %v, %v", format, i, err, record.Err)
+			}
+			got := record.Event
+			if got.EventId != want.EventId || got.UserId != want.UserId || got.Version != want.Version ||
+				!got.Date.Equal(want.Date) || !got.End.Equal(want.End) || got.AllDay != want.AllDay ||
+				got.Description != want.Description || got.TimeZone != want.TimeZone || got.IsArchived != want.IsArchived ||
+				got.ExternalUID != want.ExternalUID || got.NotifyChannel != want.NotifyChannel || got.NotifyTarget != want.NotifyTarget ||
+				len(got.ExDates) != len(want.ExDates) || len(got.Overrides) != len(want.Overrides) ||
+				len(got.ReminderOffsets) != len(want.ReminderOffsets) || len(got.ReminderTimes) != len(want.ReminderTimes) ||
+				(got.Recurrence == nil) != (want.Recurrence == nil) {
+				t.Errorf("%s: event %d differs:\ngot  %+v\nwant %+v", format, i, got, want)
+			}
+		}
+		if _, err := d.Decode(); err == nil {
+			t.Errorf("%s: expected EOF", format)
+		}
+	}
+}
+
+// TestRoundTrip_NoReminders событие без напоминаний не должно получить напоминание по умолчанию при загрузке
+func TestRoundTrip_NoReminders(t *testing.T) {
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)
+	for _, format := range []Format{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		e := NewEncoder(&buf, format)
+		// Из хранилища событие без напоминаний может прийти и с nil, и с пустым списком
+		_ = e.Encode(domain.Event{Date: start, Description: "Quiet"})
+		_ = e.Encode(domain.Event{Date: start, Description: "Quiet too", ReminderOffsets: []domain.Offset{}})
+		_ = e.Encode(domain.Event{Date: start, Description: "At time", ReminderTimes: []time.Time{start.Add(-time.Hour)}})
+		if err := e.Flush(); err != nil {
+			t.Fatalf("%s: Flush: %v", format, err)
+		}
+
+		d, err := NewDecoder(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: NewDecoder: %v", format, err)
+		}
+		for i := range 2 {
+			record, err := d.Decode()
+			if err != nil || record.Err != nil {
+				t.Fatalf("%s: event %d: %v, %v", format, i, err, record.Err)
+			}
+			if offsets := record.Event.ReminderOffsets; offsets == nil || len(offsets) != 0 {
+				t.Errorf("%s: event %d: expected empty reminder offsets, got %#v", format, i, offsets)
+			}
+		}
+		record, _ := d.Decode()
+		if record.Event.ReminderOffsets != nil || len(record.Event.ReminderTimes) != 1 {
+			t.Errorf("%s: expected only reminder times, got %+v", format, record.Event)
+		}
+	}
+}
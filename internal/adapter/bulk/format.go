@@ -0,0 +1,180 @@
+// Package bulk выгружает и загружает события таблицей: CSV для электронных таблиц и NDJSON
+// (по объекту JSON на строку) для скриптов. События пишутся и читаются по одному, без загрузки всего файла в память.
+package bulk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// Format формат файла
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat разбирает format запроса
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatNDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("format must be %s or %s", FormatCSV, FormatNDJSON)
+}
+
+// FormatByContentType формат по MIME-типу; false — тип не CSV и не NDJSON
+func FormatByContentType(contentType string) (Format, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv":
+		return FormatCSV, true
+	case "application/x-ndjson", "application/jsonl":
+		return FormatNDJSON, true
+	}
+	return "", false
+}
+
+// ContentType MIME-тип файла
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Columns колонки CSV по порядку; названия совпадают с полями события в JSON и NDJSON.
+// Списки (exdates, reminder_offsets, reminder_times) — значения через пробел, overrides — массив JSON.
+// Пустые reminder_offsets и reminder_times при создании дают напоминание по умолчанию, поэтому событие
+// без напоминаний пишется с reminder_offsets = none (в NDJSON — "reminder_offsets": []).
+var Columns = []string{
+	"event_id",
+	"user_id",
+	"version",
+	"date",
+	"end",
+	"all_day",
+	"description",
+	"time_zone",
+	"rrule",
+	"exdates",
+	"reminder_offsets",
+	"reminder_times",
+	"notify_channel",
+	"notify_target",
+	"is_archived",
+	"external_uid",
+	"overrides",
+}
+
+// noReminders значение reminder_offsets события без напоминаний
+const noReminders = "none"
+
+// dateLayout даты событий на весь день пишутся без времени
+const dateLayout = time.DateOnly
+
+// formatTime время в RFC 3339, дата — для события на весь день; нулевое — пустая строка
+func formatTime(t time.Time, allDay bool) string {
+	switch {
+	case t.IsZero():
+		return ""
+	case allDay:
+		return t.UTC().Format(dateLayout)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseTime RFC 3339 или дата YYYY-MM-DD (полночь UTC); пустая строка — нулевое время
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q must be RFC 3339 time or YYYY-MM-DD date", s)
+}
+
+func parseBool(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%q must be true or false", s)
+	}
+	return b, nil
+}
+
+func parseID(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%q must be non-negative integer", s)
+	}
+	return id, nil
+}
+
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+func formatTimes(times []time.Time, allDay bool) string {
+	parts := make([]string, len(times))
+	for i, t := range times {
+		parts[i] = formatTime(t, allDay)
+	}
+	return strings.Join(parts, " ")
+}
+
+func parseTimes(s string) ([]time.Time, error) {
+	var times []time.Time
+	for _, part := range strings.Fields(s) {
+		t, err := parseTime(part)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+func formatOffsets(offsets []domain.Offset) string {
+	if offsets != nil && len(offsets) == 0 {
+		return noReminders
+	}
+	parts := make([]string, len(offsets))
+	for i, o := range offsets {
+		parts[i] = o.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseOffsets пустая строка — nil, none — пустой список
+func parseOffsets(s string) ([]domain.Offset, error) {
+	if strings.EqualFold(s, noReminders) {
+		return []domain.Offset{}, nil
+	}
+	var offsets []domain.Offset
+	for _, part := range strings.Fields(s) {
+		o, err := domain.ParseOffset(part)
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, o)
+	}
+	return offsets, nil
+}
@@ -0,0 +1,338 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// untitled описание события без SUMMARY и DESCRIPTION: у события в календаре описание обязательно
+const untitled = "Без названия"
+
+// Item событие из файла. Если Err не nil, событие разобрать не удалось; Cancelled — событие отменено
+// в исходном календаре (STATUS:CANCELLED) и сохранять его не нужно.
+type Item struct {
+	UID       string
+	Event     domain.Event
+	Cancelled bool
+	Err       error
+}
+
+// Decoder читает события из календаря iCalendar (RFC 5545)
+type Decoder struct {
+	r io.Reader
+	// Location пояс IANA для «плавающего» времени без TZID и Z, по умолчанию UTC
+	Location *time.Location
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode разбирает все VEVENT файла. Ошибка возвращается, только если файл не календарь;
+// ошибки отдельных событий — в Item.Err, остальные события разбираются дальше.
+// Перенесённые повторения (RECURRENCE-ID) становятся переносами или исключениями серии с тем же UID.
+func (d *Decoder) Decode() ([]Item, error) {
+	lines, err := readLines(d.r)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := parseComponents(lines)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar: %w", err)
+	}
+
+	var calendars []*component
+	for _, c := range roots {
+		if c.name == "VCALENDAR" {
+			calendars = append(calendars, c)
+		}
+	}
+	if len(calendars) == 0 {
+		return nil, errors.New("invalid calendar: no VCALENDAR")
+	}
+
+	var items []Item
+	for _, cal := range calendars {
+		items = append(items, d.decodeCalendar(cal)...)
+	}
+	return items, nil
+}
+
+func (d *Decoder) decodeCalendar(cal *component) []Item {
+	p := &eventParser{
+		floating:   d.Location,
+		vtimezones: make(map[string]*component),
+		zones:      make(map[string]zoneInfo),
+	}
+	if p.floating == nil {
+		p.floating = time.UTC
+	}
+
+	// Сначала серии и одиночные события, затем их перенесённые повторения
+	var masters, instances []*component
+	for _, c := range cal.children {
+		switch c.name {
+		case "VTIMEZONE":
+			p.vtimezones[c.value("TZID")] = c
+		case "VEVENT":
+			if _, ok := c.prop("RECURRENCE-ID"); ok {
+				instances = append(instances, c)
+			} else {
+				masters = append(masters, c)
+			}
+		}
+	}
+
+	var items []Item
+	index := make(map[string]int)
+	for _, c := range masters {
+		item := p.parseEvent(c)
+		if item.UID != "" && item.Err == nil {
+			if i, ok := index[item.UID]; ok {
+				items[i].Err = fmt.Errorf("duplicate UID %q", item.UID)
+				continue
+			}
+			index[item.UID] = len(items)
+		}
+		items = append(items, item)
+	}
+	for _, c := range instances {
+		uid := unescapeText(c.value("UID"))
+		i, ok := index[uid]
+		if !ok {
+			items = append(items, Item{UID: uid, Err: errors.New("recurrence instance without its series")})
+			continue
+		}
+		if items[i].Err != nil || items[i].Cancelled {
+			continue
+		}
+		if err := p.applyInstance(&items[i].Event, c); err != nil {
+			items[i].Err = err
+		}
+	}
+	return items
+}
+
+type zoneInfo struct {
+	loc  *time.Location
+	iana bool
+}
+
+// eventParser переводит VEVENT одного календаря в domain.Event
+type eventParser struct {
+	floating   *time.Location
+	vtimezones map[string]*component
+	zones      map[string]zoneInfo
+}
+
+func (p *eventParser) parseEvent(c *component) Item {
+	item := Item{UID: unescapeText(c.value("UID"))}
+	if item.UID == "" {
+		item.Err = errors.New("UID is required")
+		return item
+	}
+	item.Cancelled = strings.EqualFold(c.value("STATUS"), "CANCELLED")
+	if item.Cancelled {
+		return item
+	}
+	event, err := p.event(c)
+	if err != nil {
+		item.Err = err
+		return item
+	}
+	event.ExternalUID = item.UID
+	item.Event = event
+	return item
+}
+
+func (p *eventParser) event(c *component) (domain.Event, error) {
+	var event domain.Event
+	start, ok := c.prop("DTSTART")
+	if !ok {
+		return event, errors.New("DTSTART is required")
+	}
+	var zone zoneInfo
+	var err error
+	event.Date, event.AllDay, zone, err = p.time(start)
+	if err != nil {
+		return event, fmt.Errorf("DTSTART: %w", err)
+	}
+	if event.End, err = p.end(c, event.Date, event.AllDay); err != nil {
+		return event, err
+	}
+
+	event.Description = summary(c)
+	if event.Description == "" {
+		event.Description = untitled
+	}
+
+	if rule, ok := c.prop("RRULE"); ok {
+		if event.Recurrence, err = domain.ParseRecurrenceRule(rule.value); err != nil {
+			return event, fmt.Errorf("RRULE: %w", err)
+		}
+		// Серия в поясе IANA сохраняет местное время повторений при переходах на летнее время
+		if zone.iana && zone.loc != time.UTC {
+			event.TimeZone = zone.loc.String()
+		}
+	}
+	if _, ok := c.prop("RDATE"); ok {
+		return event, errors.New("RDATE is not supported")
+	}
+	for _, ex := range c.all("EXDATE") {
+		for _, v := range strings.Split(ex.value, ",") {
+			ex.value = v
+			t, _, _, err := p.time(ex)
+			if err != nil {
+				return event, fmt.Errorf("EXDATE: %w", err)
+			}
+			event.ExDates = append(event.ExDates, t)
+		}
+	}
+
+	for _, alarm := range c.children {
+		if alarm.name != "VALARM" {
+			continue
+		}
+		if err := p.alarm(&event, alarm); err != nil {
+			return event, err
+		}
+	}
+	return event, nil
+}
+
+// end конец события: DTEND, иначе DTSTART + DURATION; для события на весь день без них — следующий день.
+// Нулевой конец — длительность по умолчанию.
+func (p *eventParser) end(c *component, start time.Time, allDay bool) (time.Time, error) {
+	if prop, ok := c.prop("DTEND"); ok {
+		end, _, _, err := p.time(prop)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("DTEND: %w", err)
+		}
+		return end, nil
+	}
+	if s := c.value("DURATION"); s != "" {
+		d, err := parseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("DURATION: %w", err)
+		}
+		if allDay && d%(24*time.Hour) == 0 {
+			return start.AddDate(0, 0, int(d/(24*time.Hour))), nil
+		}
+		return start.Add(d), nil
+	}
+	if allDay {
+		return start.AddDate(0, 0, 1), nil
+	}
+	return time.Time{}, nil
+}
+
+// applyInstance переносит повторение серии или отменяет его
+func (p *eventParser) applyInstance(series *domain.Event, c *component) error {
+	id, _ := c.prop("RECURRENCE-ID")
+	original, _, _, err := p.time(id)
+	if err != nil {
+		return fmt.Errorf("RECURRENCE-ID: %w", err)
+	}
+	if strings.EqualFold(c.value("STATUS"), "CANCELLED") {
+		series.Exclude(original)
+		return nil
+	}
+
+	override := domain.OccurrenceOverride{OriginalStart: original, Date: original}
+	if start, ok := c.prop("DTSTART"); ok {
+		if override.Date, _, _, err = p.time(start); err != nil {
+			return fmt.Errorf("DTSTART: %w", err)
+		}
+	}
+	if override.End, err = p.end(c, override.Date, series.AllDay); err != nil {
+		return err
+	}
+	if override.End.IsZero() {
+		duration := domain.DefaultEventDuration
+		if !series.End.IsZero() {
+			duration = series.End.Sub(series.Date)
+		}
+		override.End = override.Date.Add(duration)
+	}
+	if s := summary(c); s != series.Description {
+		override.Description = s
+	}
+	series.Override(override)
+	return nil
+}
+
+// alarm напоминание VALARM: смещение до начала события или абсолютное время.
+// Напоминания после начала события не поддерживаются и пропускаются.
+func (p *eventParser) alarm(event *domain.Event, c *component) error {
+	trigger, ok := c.prop("TRIGGER")
+	if !ok {
+		return nil
+	}
+	if strings.EqualFold(trigger.param("VALUE"), "DATE-TIME") {
+		at, _, _, err := p.time(trigger)
+		if err != nil {
+			return fmt.Errorf("TRIGGER: %w", err)
+		}
+		event.ReminderTimes = append(event.ReminderTimes, at)
+		return nil
+	}
+	d, err := parseDuration(trigger.value)
+	if err != nil {
+		return fmt.Errorf("TRIGGER: %w", err)
+	}
+	if strings.EqualFold(trigger.param("RELATED"), "END") && !event.End.IsZero() {
+		d += event.End.Sub(event.Date)
+	}
+	if d <= 0 {
+		event.ReminderOffsets = append(event.ReminderOffsets, domain.Offset(-d))
+	}
+	return nil
+}
+
+// time разбирает DATE или DATE-TIME: дата — полночь UTC события на весь день,
+// время с Z — UTC, с TZID — в этом поясе, без них — в поясе floating
+func (p *eventParser) time(prop property) (t time.Time, allDay bool, zone zoneInfo, err error) {
+	value := strings.TrimSpace(prop.value)
+	if strings.EqualFold(prop.param("VALUE"), "DATE") || len(value) == len(dateLayout) {
+		t, err = time.Parse(dateLayout, value)
+		return t, true, zoneInfo{}, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(utcLayout, value)
+		return t, false, zoneInfo{loc: time.UTC, iana: true}, err
+	}
+	zone = zoneInfo{loc: p.floating, iana: true}
+	if tzid := prop.param("TZID"); tzid != "" {
+		if zone, err = p.zone(tzid); err != nil {
+			return time.Time{}, false, zoneInfo{}, err
+		}
+	}
+	t, err = time.ParseInLocation(localLayout, value, zone.loc)
+	return t, false, zone, err
+}
+
+func (p *eventParser) zone(tzid string) (zoneInfo, error) {
+	if z, ok := p.zones[tzid]; ok {
+		return z, nil
+	}
+	loc, iana, err := resolveZone(tzid, p.vtimezones[tzid])
+	if err != nil {
+		return zoneInfo{}, err
+	}
+	z := zoneInfo{loc: loc, iana: iana}
+	p.zones[tzid] = z
+	return z, nil
+}
+
+// summary название события: SUMMARY, иначе DESCRIPTION
+func summary(c *component) string {
+	if s := unescapeText(c.value("SUMMARY")); s != "" {
+		return s
+	}
+	return unescapeText(c.value("DESCRIPTION"))
+}
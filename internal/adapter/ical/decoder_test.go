@@ -0,0 +1,333 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// sample календарь в стиле Thunderbird: переносы строк LF, пояс Mozilla, серия с переносом и отменой
+const sample = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Mozilla.org/NONSGML Mozilla Calendar V1.1//EN
+BEGIN:VTIMEZONE
+TZID:/mozilla.org/20050126_1/Europe/Berlin
+X-LIC-LOCATION:Europe/Berlin
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:Custom Zone
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0400
+TZOFFSETTO:+0330
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:standup@example.com
+DTSTART;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260323T090000
+DURATION:PT15M
+SUMMARY:Standup\, daily
+RRULE:FREQ=DAILY;COUNT=10
+EXDATE;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260324T090000,20260325T090000
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT10M
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;RELATED=END:-PT30M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:standup@example.com
+RECURRENCE-ID;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260326T090000
+DTSTART;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260326T110000
+DTEND;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260326T111500
+SUMMARY:Standup moved
+END:VEVENT
+BEGIN:VEVENT
+UID:standup@example.com
+RECURRENCE-ID;TZID="/mozilla.org/20050126_1/Europe/Berlin":20260327T090000
+STATUS:CANCELLED
+END:VEVENT
+BEGIN:VEVENT
+UID:vacation@example.com
+DTSTART;VALUE=DATE:20260401
+DTEND;VALUE=DATE:20260405
+SUMMARY:Отпуск
+DESCRIPTION:Длинное описание, которое
+  перенесено на следующую строку
+END:VEVENT
+BEGIN:VEVENT
+UID:tehran@example.com
+DTSTART;TZID=Custom Zone:20260310T100000
+DTEND;TZID=Custom Zone:20260310T110000
+SUMMARY:Fixed offset
+END:VEVENT
+BEGIN:VEVENT
+UID:floating@example.com
+DTSTART:20260311T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled@example.com
+DTSTART:20260312T100000Z
+STATUS:CANCELLED
+SUMMARY:Cancelled
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20260312T100000Z
+SUMMARY:No UID
+END:VEVENT
+BEGIN:VEVENT
+UID:bad-rule@example.com
+DTSTART:20260312T100000Z
+RRULE:FREQ=HOURLY
+SUMMARY:Unsupported
+END:VEVENT
+BEGIN:VEVENT
+UID:orphan@example.com
+RECURRENCE-ID:20260312T100000Z
+DTSTART:20260312T120000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func decodeSample(t *testing.T) map[string]Item {
+	t.Helper()
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	dec := NewDecoder(strings.NewReader(sample))
+	dec.Location = moscow
+	items, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(items) != 8 {
+		t.Fatalf("expected 8 items, got %d", len(items))
+	}
+	byUID := make(map[string]Item)
+	for _, item := range items {
+		byUID[item.UID] = item
+	}
+	return byUID
+}
+
+func TestDecoder_Series(t *testing.T) {
+	item := decodeSample(t)["standup@example.com"]
+	if item.Err != nil {
+		t.Fatalf("unexpected error: %v", item.Err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	e := item.Event
+	start := time.Date(2026, 3, 23, 9, 0, 0, 0, berlin)
+	if !e.Date.Equal(start) || !e.End.Equal(start.Add(15*time.Minute)) {
+		t.Errorf("unexpected times %v - %v", e.Date, e.End)
+	}
+	if e.Description != "Standup, daily" || e.ExternalUID != "standup@example.com" || e.TimeZone != "Europe/Berlin" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if e.Recurrence == nil || e.Recurrence.String() != "FREQ=DAILY;COUNT=10" {
+		t.Errorf("unexpected rule %v", e.Recurrence)
+	}
+	// Две даты EXDATE и отменённое повторение
+	if len(e.ExDates) != 3 || !e.ExDates[2].Equal(time.Date(2026, 3, 27, 9, 0, 0, 0, berlin)) {
+		t.Errorf("unexpected exdates %v", e.ExDates)
+	}
+	if len(e.Overrides) != 1 || !e.Overrides[0].Date.Equal(time.Date(2026, 3, 26, 11, 0, 0, 0, berlin)) || e.Overrides[0].Description != "Standup moved" {
+		t.Errorf("unexpected overrides %+v", e.Overrides)
+	}
+	// RELATED=END: за 30 минут до конца 15-минутного события — за 15 минут до начала
+	if len(e.ReminderOffsets) != 2 || e.ReminderOffsets[0] != domain.Offset(10*time.Minute) || e.ReminderOffsets[1] != domain.Offset(15*time.Minute) {
+		t.Errorf("unexpected reminders %v", e.ReminderOffsets)
+	}
+}
+
+func TestDecoder_Items(t *testing.T) {
+	items := decodeSample(t)
+
+	vacation := items["vacation@example.com"].Event
+	if !vacation.AllDay || !vacation.Date.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) || !vacation.End.Equal(time.Date(2026, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected all-day event %+v", vacation)
+	}
+	if vacation.Description != "Отпуск" {
+		t.Errorf("expected SUMMARY as description, got %q", vacation.Description)
+	}
+
+	fixed := items["tehran@example.com"].Event
+	if _, offset := fixed.Date.Zone(); offset != 3*3600+1800 {
+		t.Errorf("expected +0330 from VTIMEZONE, got %d", offset)
+	}
+
+	floating := items["floating@example.com"]
+	if floating.Event.Date.Location().String() != "Europe/Moscow" || floating.Event.Description != untitled {
+		t.Errorf("unexpected floating event %+v", floating.Event)
+	}
+
+	if !items["cancelled@example.com"].Cancelled {
+		t.Error("expected cancelled event")
+	}
+	for _, uid := range []string{"", "bad-rule@example.com", "orphan@example.com"} {
+		if items[uid].Err == nil {
+			t.Errorf("%q: expected error", uid)
+		}
+	}
+}
+
+// customDST пояс без имени IANA с переходами на летнее время по правилам, как пишет Outlook:
+// с 2007 года — второе воскресенье марта и первое воскресенье ноября, до того — последнее воскресенье октября
+const customDST = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Company Time
+BEGIN:STANDARD
+DTSTART:16010101T020000
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+TZNAME:CST
+RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU;UNTIL=20061029T060000Z
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+TZNAME:CDT
+RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU;UNTIL=20060402T070000Z
+END:DAYLIGHT
+BEGIN:STANDARD
+DTSTART:20071104T020000
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+TZNAME:CST
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:20070311T020000
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+TZNAME:CDT
+RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:winter@example.com
+DTSTART;TZID=Company Time:20260115T090000
+SUMMARY:Winter
+END:VEVENT
+BEGIN:VEVENT
+UID:summer@example.com
+DTSTART;TZID=Company Time:20260715T090000
+SUMMARY:Summer
+END:VEVENT
+BEGIN:VEVENT
+UID:after-switch@example.com
+DTSTART;TZID=Company Time:20260308T030000
+SUMMARY:After switch
+END:VEVENT
+BEGIN:VEVENT
+UID:old-rules@example.com
+DTSTART;TZID=Company Time:20051030T090000
+SUMMARY:Old rules
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestDecoder_CustomTimeZoneDST(t *testing.T) {
+	decoded, err := NewDecoder(strings.NewReader(customDST)).Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	items := make(map[string]Item)
+	for _, item := range decoded {
+		if item.Err != nil {
+			t.Fatalf("%s: %v", item.UID, item.Err)
+		}
+		items[item.UID] = item
+	}
+	for uid, want := range map[string]time.Time{
+		"winter@example.com":       time.Date(2026, 1, 15, 14, 0, 0, 0, time.UTC),
+		"summer@example.com":       time.Date(2026, 7, 15, 13, 0, 0, 0, time.UTC),
+		"after-switch@example.com": time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC),
+		"old-rules@example.com":    time.Date(2005, 10, 30, 14, 0, 0, 0, time.UTC),
+	} {
+		if got := items[uid].Event.Date; !got.Equal(want) {
+			t.Errorf("%s: expected %v, got %v", uid, want, got.UTC())
+		}
+	}
+	if name, _ := items["summer@example.com"].Event.Date.Zone(); name != "CDT" {
+		t.Errorf("expected CDT in summer, got %q", name)
+	}
+}
+
+func TestDecoder_UnsupportedCustomTimeZone(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Odd Zone
+BEGIN:DAYLIGHT
+DTSTART:20200101T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+RRULE:FREQ=MONTHLY;BYDAY=1SU
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:odd@example.com
+DTSTART;TZID=Odd Zone:20260115T090000
+END:VEVENT
+END:VCALENDAR
+`
+	items, err := NewDecoder(strings.NewReader(input)).Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(items) != 1 || items[0].Err == nil || !strings.Contains(items[0].Err.Error(), "unsupported custom time zone") {
+		t.Fatalf("expected unsupported time zone error, got %+v", items)
+	}
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	for _, s := range []string{"", "hello", "BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n", "BEGIN:VEVENT\nEND:VEVENT\n"} {
+		if _, err := NewDecoder(strings.NewReader(s)).Decode(); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestDecoder_RoundTrip(t *testing.T) {
+	rule, _ := domain.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO,WE")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2026, 3, 16, 9, 0, 0, 0, berlin)
+	events := []domain.Event{
+		{
+			EventId: 1, Date: start, End: start.Add(time.Hour), Description: "Планёрка; важно",
+			Recurrence: rule, TimeZone: "Europe/Berlin",
+			ExDates:         []time.Time{start.AddDate(0, 0, 2)},
+			Overrides:       []domain.OccurrenceOverride{{OriginalStart: start.AddDate(0, 0, 7), Date: start.AddDate(0, 0, 7).Add(time.Hour), End: start.AddDate(0, 0, 7).Add(2 * time.Hour)}},
+			ReminderOffsets: []domain.Offset{domain.Offset(15 * time.Minute)},
+		},
+		{EventId: 2, Date: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC), AllDay: true, Description: "Конференция", ExternalUID: "conf@example.com"},
+	}
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(events); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	items, err := NewDecoder(&buf).Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	for i, item := range items {
+		if item.Err != nil {
+			t.Fatalf("item %d: %v", i, item.Err)
+		}
+		want, got := events[i], item.Event
+		if item.UID != UID(want) || !got.Date.Equal(want.Date) || !got.End.Equal(want.End) || got.Description != want.Description ||
+			got.AllDay != want.AllDay || got.TimeZone != want.TimeZone || len(got.ExDates) != len(want.ExDates) ||
+			len(got.Overrides) != len(want.Overrides) || len(got.ReminderOffsets) != len(want.ReminderOffsets) {
+			t.Errorf("item %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
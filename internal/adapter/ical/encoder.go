@@ -0,0 +1,209 @@
+// Package ical выгружает и загружает события в формате iCalendar (RFC 5545): Thunderbird, Apple Calendar, Outlook и т.п.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// ProdID идентификатор программы, создавшей календарь
+const ProdID = "-//dontpanicw//Calendar//RU"
+
+// ContentType MIME-тип календаря
+const ContentType = "text/calendar; charset=utf-8"
+
+// UID постоянный идентификатор события в календаре; по нему клиенты узнают событие при повторной выгрузке.
+// Импортированное событие сохраняет UID исходного календаря.
+func UID(event domain.Event) string {
+	if event.ExternalUID != "" {
+		return event.ExternalUID
+	}
+	return fmt.Sprintf("event-%d@calendar", event.EventId)
+}
+
+// EventID id события по UID, который выдаёт UID; false — UID не из этого сервиса
+func EventID(uid string) (int64, bool) {
+	s, ok := strings.CutPrefix(uid, "event-")
+	if !ok {
+		return 0, false
+	}
+	if s, ok = strings.CutSuffix(s, "@calendar"); !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// Encoder пишет события одним VCALENDAR
+type Encoder struct {
+	w io.Writer
+	// Name название календаря (X-WR-CALNAME), пустое — не пишется
+	Name string
+	// Stamp время выгрузки для DTSTAMP; нулевое — текущее
+	Stamp time.Time
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode пишет календарь с событиями. Серия пишется одним VEVENT с RRULE и EXDATE,
+// перенесённые повторения — отдельными VEVENT с RECURRENCE-ID и тем же UID.
+// Серии с часовым поясом пишутся в местном времени с TZID, остальные события — в UTC.
+func (e *Encoder) Encode(events []domain.Event) error {
+	stamp := e.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	l := newLineWriter(e.w)
+	l.line("BEGIN", "VCALENDAR")
+	l.line("VERSION", "2.0")
+	l.text("PRODID", ProdID)
+	l.line("CALSCALE", "GREGORIAN")
+	if e.Name != "" {
+		l.text("X-WR-CALNAME", e.Name)
+	}
+	for _, z := range zones(events, stamp) {
+		writeTimeZone(l, z.loc, z.fromYear, z.toYear)
+	}
+	for _, event := range events {
+		writeEvent(l, event, stamp)
+	}
+	l.line("END", "VCALENDAR")
+	if err := l.flush(); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
+
+func writeEvent(l *lineWriter, event domain.Event, stamp time.Time) {
+	loc := eventLocation(event)
+	uid := UID(event)
+
+	l.line("BEGIN", "VEVENT")
+	l.text("UID", uid)
+	l.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+	if event.Version > 1 {
+		l.line("SEQUENCE", fmt.Sprint(event.Version-1))
+	}
+	writeTimes(l, event.Date, event.End, event.AllDay, loc)
+	l.text("SUMMARY", event.Description)
+	if event.Recurrence != nil {
+		l.line("RRULE", formatRule(*event.Recurrence, event.AllDay))
+		for _, ex := range event.ExDates {
+			writeTime(l, "EXDATE", ex, event.AllDay, loc)
+		}
+	}
+	for _, o := range event.ReminderOffsets {
+		writeAlarm(l, "TRIGGER", formatDuration(-o.Duration()), event.Description)
+	}
+	for _, at := range event.ReminderTimes {
+		writeAlarm(l, "TRIGGER;VALUE=DATE-TIME", at.UTC().Format(utcLayout), event.Description)
+	}
+	l.line("END", "VEVENT")
+
+	for _, o := range event.Overrides {
+		l.line("BEGIN", "VEVENT")
+		l.text("UID", uid)
+		l.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+		writeTime(l, "RECURRENCE-ID", o.OriginalStart, event.AllDay, loc)
+		writeTimes(l, o.Date, o.End, event.AllDay, loc)
+		description := o.Description
+		if description == "" {
+			description = event.Description
+		}
+		l.text("SUMMARY", description)
+		l.line("END", "VEVENT")
+	}
+}
+
+// writeTimes пишет DTSTART и DTEND; событие без конца — мгновенное, DTEND не пишется
+func writeTimes(l *lineWriter, start, end time.Time, allDay bool, loc *time.Location) {
+	writeTime(l, "DTSTART", start, allDay, loc)
+	if end.After(start) {
+		writeTime(l, "DTEND", end, allDay, loc)
+	}
+}
+
+// writeTime пишет дату-время: дату для события на весь день, местное время с TZID или UTC
+func writeTime(l *lineWriter, name string, t time.Time, allDay bool, loc *time.Location) {
+	switch {
+	case allDay:
+		// Даты событий на весь день — полночь UTC
+		l.line(name+";VALUE=DATE", t.UTC().Format(dateLayout))
+	case loc != nil:
+		l.line(name+";TZID="+loc.String(), t.In(loc).Format(localLayout))
+	default:
+		l.line(name, t.UTC().Format(utcLayout))
+	}
+}
+
+func writeAlarm(l *lineWriter, trigger, value, description string) {
+	l.line("BEGIN", "VALARM")
+	l.line("ACTION", "DISPLAY")
+	l.line(trigger, value)
+	l.text("DESCRIPTION", description)
+	l.line("END", "VALARM")
+}
+
+// formatRule RRULE; у серии на весь день UNTIL — дата, как и DTSTART
+func formatRule(rule domain.RecurrenceRule, allDay bool) string {
+	if !allDay || rule.Until.IsZero() {
+		return rule.String()
+	}
+	until := rule.Until
+	rule.Until = time.Time{}
+	return rule.String() + ";UNTIL=" + until.UTC().Format(dateLayout)
+}
+
+// eventLocation пояс, в котором пишутся времена события: только у серий, чтобы повторения
+// сохраняли местное время при переходах на летнее время
+func eventLocation(event domain.Event) *time.Location {
+	if event.Recurrence == nil || event.AllDay || event.TimeZone == "" {
+		return nil
+	}
+	loc, err := domain.LoadLocation(event.TimeZone)
+	if err != nil || loc == time.UTC {
+		return nil
+	}
+	return loc
+}
+
+type zone struct {
+	loc              *time.Location
+	fromYear, toYear int
+}
+
+// zones пояса событий для VTIMEZONE: от года первого события до следующего за выгрузкой года
+func zones(events []domain.Event, stamp time.Time) []zone {
+	var result []zone
+	index := make(map[string]int)
+	for _, event := range events {
+		loc := eventLocation(event)
+		if loc == nil {
+			continue
+		}
+		from, to := event.Date.In(loc).Year(), max(event.Date.In(loc).Year(), stamp.In(loc).Year())+1
+		for _, o := range event.Overrides {
+			to = max(to, o.Date.In(loc).Year())
+		}
+		i, ok := index[loc.String()]
+		if !ok {
+			index[loc.String()] = len(result)
+			result = append(result, zone{loc: loc, fromYear: from, toYear: to})
+			continue
+		}
+		result[i].fromYear = min(result[i].fromYear, from)
+		result[i].toYear = max(result[i].toYear, to)
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+var stamp = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func encode(t *testing.T, events ...domain.Event) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Stamp = stamp
+	if err := enc.Encode(events); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.String()
+}
+
+// unfold склеивает перенесённые строки
+func unfold(s string) []string {
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n ", ""), "\r\n"), "\r\n")
+}
+
+func contains(t *testing.T, lines []string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		found := false
+		for _, line := range lines {
+			if line == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing line %q in\n%s", w, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestEncoder_Event(t *testing.T) {
+	start := time.Date(2026, 3, 15, 11, 0, 0, 0, time.UTC)
+	out := encode(t, domain.Event{
+		EventId:         7,
+		Version:         3,
+		Date:            start,
+		End:             start.Add(90 * time.Minute),
+		Description:     "Встреча; обсудить план, бюджет\nи сроки",
+		ReminderOffsets: []domain.Offset{domain.Offset(15 * time.Minute), domain.Offset(24 * time.Hour)},
+		ReminderTimes:   []time.Time{time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)},
+	})
+
+	if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("unexpected calendar envelope:\n%s", out)
+	}
+	contains(t, unfold(out),
+		"UID:event-7@calendar",
+		"DTSTAMP:20260301T120000Z",
+		"SEQUENCE:2",
+		"DTSTART:20260315T110000Z",
+		"DTEND:20260315T123000Z",
+		`SUMMARY:Встреча\; обсудить план\, бюджет\nи сроки`,
+		"TRIGGER:-PT15M",
+		"TRIGGER:-P1D",
+		"TRIGGER;VALUE=DATE-TIME:20260314T090000Z",
+	)
+	if strings.Contains(out, "VTIMEZONE") {
+		t.Error("single event must be written in UTC without VTIMEZONE")
+	}
+}
+
+func TestEncoder_AllDaySeries(t *testing.T) {
+	rule, _ := domain.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO;UNTIL=20260601T000000Z")
+	start := time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)
+	out := encode(t, domain.Event{
+		EventId:     1,
+		Date:        start,
+		End:         start.AddDate(0, 0, 1),
+		AllDay:      true,
+		Description: "Отпуск",
+		Recurrence:  rule,
+		ExDates:     []time.Time{start.AddDate(0, 0, 7)},
+	})
+	contains(t, unfold(out),
+		"DTSTART;VALUE=DATE:20260316",
+		"DTEND;VALUE=DATE:20260317",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO;UNTIL=20260601",
+		"EXDATE;VALUE=DATE:20260323",
+	)
+}
+
+func TestEncoder_SeriesTimeZone(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	start := time.Date(2026, 3, 27, 9, 0, 0, 0, loc)
+	moved := time.Date(2026, 3, 30, 9, 0, 0, 0, loc)
+	out := encode(t, domain.Event{
+		EventId:     2,
+		Date:        start,
+		End:         start.Add(time.Hour),
+		Description: "Standup",
+		Recurrence:  rule,
+		TimeZone:    "Europe/Berlin",
+		Overrides: []domain.OccurrenceOverride{{
+			OriginalStart: moved,
+			Date:          moved.Add(2 * time.Hour),
+			End:           moved.Add(3 * time.Hour),
+		}},
+	})
+	lines := unfold(out)
+	contains(t, lines,
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Berlin",
+		"DTSTART;TZID=Europe/Berlin:20260327T090000",
+		"RRULE:FREQ=DAILY",
+		"RECURRENCE-ID;TZID=Europe/Berlin:20260330T090000",
+		"DTSTART;TZID=Europe/Berlin:20260330T110000",
+		// Переход на летнее время 29 марта 2026 в 02:00 по местному времени
+		"DTSTART:20260329T020000",
+		"TZOFFSETFROM:+0100",
+		"TZOFFSETTO:+0200",
+	)
+	if n := strings.Count(out, "UID:event-2@calendar"); n != 2 {
+		t.Errorf("expected series and override with the same UID, got %d", n)
+	}
+}
+
+func TestFold(t *testing.T) {
+	long := strings.Repeat("я", 100)
+	folded := fold("SUMMARY:" + long)
+	for _, line := range strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is %d octets: %q", len(line), line)
+		}
+	}
+	if got := unfold(folded); len(got) != 1 || got[0] != "SUMMARY:"+long {
+		t.Errorf("unfolded line differs: %q", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                "PT0S",
+		-15 * time.Minute:                "-PT15M",
+		-(24*time.Hour + 90*time.Minute): "-P1DT1H30M",
+		7 * 24 * time.Hour:               "P7D",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestEventID(t *testing.T) {
+	if id, ok := EventID(UID(domain.Event{EventId: 42})); !ok || id != 42 {
+		t.Errorf("EventID of own UID = %d, %v", id, ok)
+	}
+	for _, uid := range []string{"a@example.com", "event-0@calendar", "event-x@calendar", "event-1@calendar.example"} {
+		if _, ok := EventID(uid); ok {
+			t.Errorf("EventID(%q) must be false", uid)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+	// maxLineOctets длина строки без CRLF, после которой строка переносится (RFC 5545, 3.1)
+	maxLineOctets = 75
+)
+
+// lineWriter пишет строки содержимого iCalendar с CRLF и переносом длинных строк.
+// Первая ошибка записи запоминается, последующие записи пропускаются.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{w: bufio.NewWriter(w)}
+}
+
+// line пишет строку name:value; value уже должен быть экранирован
+func (l *lineWriter) line(name, value string) {
+	l.write(fold(name + ":" + value))
+}
+
+// text пишет текстовое свойство с экранированием
+func (l *lineWriter) text(name, value string) {
+	l.line(name, escapeText(value))
+}
+
+func (l *lineWriter) write(s string) {
+	if l.err != nil {
+		return
+	}
+	_, l.err = l.w.WriteString(s)
+}
+
+func (l *lineWriter) flush() error {
+	if l.err != nil {
+		return l.err
+	}
+	return l.w.Flush()
+}
+
+// fold разбивает строку на части не длиннее 75 октетов, не разрывая символы UTF-8.
+// Продолжение начинается с пробела, который тоже занимает октет.
+func fold(s string) string {
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			// \r\n пишется как один перевод строки
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// formatDuration длительность в формате RFC 5545, например -PT15M или P1D
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+	days := d / (24 * time.Hour)
+	if days > 0 {
+		b.WriteString(strconv.FormatInt(int64(days), 10) + "D")
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 || days == 0 {
+		b.WriteByte('T')
+		h, m, s := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+		if h > 0 {
+			b.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+		}
+		if m > 0 {
+			b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+		}
+		if s > 0 || (h == 0 && m == 0) {
+			b.WriteString(strconv.FormatInt(int64(s), 10) + "S")
+		}
+	}
+	return b.String()
+}
+
+// formatUTCOffset смещение пояса, например +0300 или -0330
+func formatUTCOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	s := sign + pad2(seconds/3600) + pad2(seconds%3600/60)
+	if sec := seconds % 60; sec != 0 {
+		s += pad2(sec)
+	}
+	return s
+}
+
+func pad2(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
+
+// parseDuration разбирает длительность RFC 5545: [+-]P[nW][nD][T[nH][nM][nS]]
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+	var d time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime, s = true, s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		var unit time.Duration
+		switch u := s[i]; {
+		case u == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case u == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case u == 'H' && inTime:
+			unit = time.Hour
+		case u == 'M' && inTime:
+			unit = time.Minute
+		case u == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	return sign * d, nil
+}
+
+// parseUTCOffset разбирает смещение пояса: +0300, -0330, +053000
+func parseUTCOffset(s string) (int, error) {
+	if (len(s) != 5 && len(s) != 7) || (s[0] != '+' && s[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", s)
+	}
+	seconds := 0
+	for i, mul := range []int{3600, 60, 1} {
+		if 1+2*i >= len(s) {
+			break
+		}
+		n, err := strconv.Atoi(s[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid UTC offset %q", s)
+		}
+		seconds += n * mul
+	}
+	if s[0] == '-' {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
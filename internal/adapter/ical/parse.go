@@ -0,0 +1,179 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// property строка содержимого: NAME;PARAM=value:value. Имена приводятся к верхнему регистру.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func (p property) param(name string) string {
+	return p.params[name]
+}
+
+// component компонент BEGIN:NAME ... END:NAME со свойствами и вложенными компонентами
+type component struct {
+	name     string
+	props    []property
+	children []*component
+}
+
+// prop первое свойство с именем name
+func (c *component) prop(name string) (property, bool) {
+	for _, p := range c.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+// value значение первого свойства name, пустое, если свойства нет
+func (c *component) value(name string) string {
+	p, _ := c.prop(name)
+	return p.value
+}
+
+// all все свойства с именем name
+func (c *component) all(name string) []property {
+	var result []property
+	for _, p := range c.props {
+		if p.name == name {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// readLines читает строки содержимого, склеивая перенесённые (RFC 5545, 3.1).
+// Принимает и CRLF, и LF: многие программы пишут .ics с переводами строк Unix.
+func readLines(r io.Reader) ([]string, error) {
+	br := bufio.NewReader(r)
+	var lines []string
+	for {
+		s, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read calendar: %w", err)
+		}
+		s = strings.TrimRight(s, "\r\n")
+		switch {
+		case s == "":
+		case (s[0] == ' ' || s[0] == '\t') && len(lines) > 0:
+			lines[len(lines)-1] += s[1:]
+		default:
+			lines = append(lines, s)
+		}
+		if err != nil {
+			return lines, nil
+		}
+	}
+}
+
+// parseProperty разбирает строку содержимого. Значения параметров могут быть в кавычках
+// и содержать ; , и :
+func parseProperty(line string) (property, error) {
+	p := property{params: make(map[string]string)}
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.name = strings.ToUpper(line[:i])
+	rest := line[i:]
+	for rest != "" && rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("invalid parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		var value string
+		if rest != "" && rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return p, fmt.Errorf("unterminated quoted parameter in %q", line)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ";:")
+			if end < 0 {
+				return p, fmt.Errorf("invalid content line %q", line)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		p.params[name] = value
+	}
+	if rest == "" || rest[0] != ':' {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.value = rest[1:]
+	return p, nil
+}
+
+// parseComponents собирает дерево компонентов верхнего уровня
+func parseComponents(lines []string) ([]*component, error) {
+	var roots []*component
+	var stack []*component
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(p.value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, c)
+			} else {
+				roots = append(roots, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("unexpected END:%s", p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("property %s outside of component", p.name)
+			}
+			c := stack[len(stack)-1]
+			c.props = append(c.props, p)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("component %s is not closed", stack[len(stack)-1].name)
+	}
+	return roots, nil
+}
+
+// unescapeText обратное к escapeText
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
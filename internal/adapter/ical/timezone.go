@@ -0,0 +1,370 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// transition смена смещения пояса: at — момент смены, offset и name действуют после него
+type transition struct {
+	at         time.Time
+	fromOffset int
+	offset     int
+	name       string
+	dst        bool
+}
+
+// transitions смены смещения пояса loc за годы [fromYear, toYear].
+// Первым идёт смещение на начало fromYear, чтобы VTIMEZONE описывал весь период.
+func transitions(loc *time.Location, fromYear, toYear int) []transition {
+	start := time.Date(fromYear, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(toYear+1, time.January, 1, 0, 0, 0, 0, loc)
+
+	name, offset := start.Zone()
+	result := []transition{{at: start, fromOffset: offset, offset: offset, name: name, dst: start.IsDST()}}
+	// Смены смещения не бывают чаще раза в сутки: идём по суткам и уточняем момент двоичным поиском
+	for t := start; t.Before(end); t = t.Add(24 * time.Hour) {
+		next := t.Add(24 * time.Hour)
+		if _, nextOffset := next.Zone(); nextOffset == offset {
+			continue
+		}
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, o := mid.Zone(); o == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		at := hi.Truncate(time.Second)
+		nextName, nextOffset := at.Zone()
+		result = append(result, transition{at: at, fromOffset: offset, offset: nextOffset, name: nextName, dst: at.IsDST()})
+		offset = nextOffset
+	}
+	return result
+}
+
+// writeTimeZone пишет VTIMEZONE для пояса loc за годы [fromYear, toYear].
+// Каждая смена смещения — отдельное правило без RRULE; после toYear действует последнее смещение,
+// поэтому период выбирается с запасом.
+func writeTimeZone(l *lineWriter, loc *time.Location, fromYear, toYear int) {
+	l.line("BEGIN", "VTIMEZONE")
+	l.text("TZID", loc.String())
+	for _, t := range transitions(loc, fromYear, toYear) {
+		component := "STANDARD"
+		if t.dst {
+			component = "DAYLIGHT"
+		}
+		l.line("BEGIN", component)
+		// DTSTART правила — местное время до смены, то есть со смещением TZOFFSETFROM
+		l.line("DTSTART", t.at.UTC().Add(time.Duration(t.fromOffset)*time.Second).Format(localLayout))
+		l.line("TZOFFSETFROM", formatUTCOffset(t.fromOffset))
+		l.line("TZOFFSETTO", formatUTCOffset(t.offset))
+		if t.name != "" {
+			l.text("TZNAME", t.name)
+		}
+		l.line("END", component)
+	}
+	l.line("END", "VTIMEZONE")
+}
+
+// windowsZones пояса Outlook и Exchange, которые чаще всего встречаются в выгрузках, и их имена IANA
+var windowsZones = map[string]string{
+	"UTC":                           "UTC",
+	"GMT Standard Time":             "Europe/London",
+	"W. Europe Standard Time":       "Europe/Berlin",
+	"Central Europe Standard Time":  "Europe/Budapest",
+	"Romance Standard Time":         "Europe/Paris",
+	"FLE Standard Time":             "Europe/Kiev",
+	"E. Europe Standard Time":       "Europe/Chisinau",
+	"Russian Standard Time":         "Europe/Moscow",
+	"Ekaterinburg Standard Time":    "Asia/Yekaterinburg",
+	"N. Central Asia Standard Time": "Asia/Novosibirsk",
+	"Eastern Standard Time":         "America/New_York",
+	"Central Standard Time":         "America/Chicago",
+	"Mountain Standard Time":        "America/Denver",
+	"Pacific Standard Time":         "America/Los_Angeles",
+	"India Standard Time":           "Asia/Kolkata",
+	"China Standard Time":           "Asia/Shanghai",
+	"Tokyo Standard Time":           "Asia/Tokyo",
+}
+
+// resolveZone пояс для TZID из файла. Сначала ищется пояс IANA: по самому TZID, по его концу
+// (Mozilla пишет /mozilla.org/20050126_1/America/New_York), по X-LIC-LOCATION и по имени Windows.
+// Если пояс неизвестен, он строится по правилам STANDARD и DAYLIGHT из VTIMEZONE. iana — найден ли пояс IANA.
+func resolveZone(tzid string, vtz *component) (loc *time.Location, iana bool, err error) {
+	candidates := []string{tzid}
+	for rest := tzid; strings.Contains(rest, "/"); {
+		_, rest, _ = strings.Cut(rest, "/")
+		candidates = append(candidates, rest)
+	}
+	if vtz != nil {
+		if name := vtz.value("X-LIC-LOCATION"); name != "" {
+			candidates = append(candidates, name)
+		}
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		candidates = append(candidates, name)
+	}
+	for _, name := range candidates {
+		// "Local" и пустое имя time.LoadLocation понимает как пояс сервера
+		if name == "" || name == "Local" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, true, nil
+		}
+	}
+
+	if vtz == nil {
+		return nil, false, fmt.Errorf("unknown time zone %q", tzid)
+	}
+	loc, err = customZone(tzid, vtz)
+	return loc, false, err
+}
+
+const (
+	// customZoneUntil до какого года считаются смены смещения пояса из VTIMEZONE: TZif версии 1
+	// хранит моменты в int32, после него действует последнее смещение
+	customZoneUntil = 2037
+	// minZoneChange раньше этого момента смена смещения в int32 не умещается
+	minZoneChange = -1 << 31
+)
+
+// zoneOnset момент, с которого действует правило пояса; from — смещение до него
+type zoneOnset struct {
+	at   time.Time
+	from int
+	typ  zoneType
+}
+
+// customZone пояс по правилам STANDARD и DAYLIGHT из VTIMEZONE. Правило действует с DTSTART
+// (местное время со смещением TZOFFSETFROM) и повторяется по RDATE и RRULE; из RRULE поддерживаются
+// ежегодные правила с BYMONTH, BYDAY и BYMONTHDAY, как их пишут Outlook и Google.
+func customZone(tzid string, vtz *component) (*time.Location, error) {
+	var onsets []zoneOnset
+	for _, c := range vtz.children {
+		if c.name != "STANDARD" && c.name != "DAYLIGHT" {
+			continue
+		}
+		from, err := parseUTCOffset(c.value("TZOFFSETFROM"))
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseUTCOffset(c.value("TZOFFSETTO"))
+		if err != nil {
+			return nil, err
+		}
+		starts, err := ruleStarts(c, from)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported custom time zone %q: %w", tzid, err)
+		}
+		typ := zoneType{offset: to, dst: c.name == "DAYLIGHT", name: unescapeText(c.value("TZNAME"))}
+		for _, local := range starts {
+			onsets = append(onsets, zoneOnset{at: local.Add(-time.Duration(from) * time.Second), from: from, typ: typ})
+		}
+	}
+	if len(onsets) == 0 {
+		return nil, fmt.Errorf("time zone %q has no rules", tzid)
+	}
+	slices.SortStableFunc(onsets, func(a, b zoneOnset) int { return a.at.Compare(b.at) })
+
+	// До первой смены, которая умещается в TZif, действует правило перед ней или смещение, от которого она отсчитывается
+	initial := zoneType{offset: onsets[0].from}
+	for _, o := range onsets {
+		if o.at.Unix() >= minZoneChange {
+			break
+		}
+		initial = o.typ
+	}
+	types := []zoneType{initial}
+	var changes []zoneChange
+	for _, o := range onsets {
+		if o.at.Unix() < minZoneChange {
+			continue
+		}
+		if o.at.Year() > customZoneUntil {
+			break
+		}
+		typ := slices.Index(types, o.typ)
+		if typ < 0 {
+			types = append(types, o.typ)
+			typ = len(types) - 1
+		}
+		if n := len(changes); n > 0 && changes[n-1].at.Equal(o.at) {
+			changes[n-1].typ = typ
+			continue
+		}
+		changes = append(changes, zoneChange{at: o.at, typ: typ})
+	}
+	return time.LoadLocationFromTZData(tzid, tzifData(types, changes))
+}
+
+// ruleStarts местные моменты, с которых действует правило пояса: DTSTART, RDATE и повторения RRULE.
+// from — смещение до смены, по нему UNTIL в UTC сравнивается с местным временем.
+func ruleStarts(c *component, from int) ([]time.Time, error) {
+	dtstart, err := time.Parse(localLayout, c.value("DTSTART"))
+	if err != nil {
+		return nil, fmt.Errorf("DTSTART must be local time: %w", err)
+	}
+	starts := []time.Time{dtstart}
+	for _, rdate := range c.all("RDATE") {
+		if v := rdate.param("VALUE"); v != "" && !strings.EqualFold(v, "DATE-TIME") {
+			return nil, fmt.Errorf("RDATE with VALUE=%s", v)
+		}
+		for _, v := range strings.Split(rdate.value, ",") {
+			t, err := time.Parse(localLayout, strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("RDATE must be local time: %w", err)
+			}
+			starts = append(starts, t)
+		}
+	}
+	if rule, ok := c.prop("RRULE"); ok {
+		repeated, err := yearlyStarts(dtstart, from, rule.value)
+		if err != nil {
+			return nil, fmt.Errorf("RRULE: %w", err)
+		}
+		starts = append(starts, repeated...)
+	}
+	return starts, nil
+}
+
+// yearlyStarts повторения правила пояса вида FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU с dtstart до customZoneUntil
+func yearlyStarts(dtstart time.Time, from int, rule string) ([]time.Time, error) {
+	interval, count := 1, 0
+	var until time.Time
+	months := []time.Month{dtstart.Month()}
+	var byDay []domain.WeekdayNum
+	var byMonthDay []int
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		name, value, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			if !strings.EqualFold(value, "YEARLY") {
+				err = fmt.Errorf("FREQ=%s", value)
+			}
+		case "INTERVAL":
+			if interval, err = strconv.Atoi(value); err == nil && interval < 1 {
+				err = errors.New("INTERVAL must be positive")
+			}
+		case "COUNT":
+			count, err = strconv.Atoi(value)
+		case "UNTIL":
+			until, err = zoneRuleUntil(value, from)
+		case "BYMONTH":
+			months = months[:0]
+			for _, v := range strings.Split(value, ",") {
+				m, convErr := strconv.Atoi(v)
+				if convErr != nil || m < 1 || m > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", value)
+				}
+				months = append(months, time.Month(m))
+			}
+		case "BYDAY":
+			byDay, err = parseZoneByDay(value)
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				d, convErr := strconv.Atoi(v)
+				if convErr != nil || d == 0 || d < -31 || d > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", value)
+				}
+				byMonthDay = append(byMonthDay, d)
+			}
+		case "WKST", "":
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(months)
+
+	hour, minute, sec := dtstart.Clock()
+	matches := func(t time.Time, dim int) bool {
+		day := t.Day()
+		if len(byMonthDay) > 0 && !slices.ContainsFunc(byMonthDay, func(md int) bool { return md == day || dim+md+1 == day }) {
+			return false
+		}
+		if len(byDay) > 0 {
+			return slices.ContainsFunc(byDay, func(wd domain.WeekdayNum) bool {
+				return wd.Day == t.Weekday() && (wd.N == 0 || (wd.N > 0 && (day-1)/7+1 == wd.N) || (wd.N < 0 && (dim-day)/7+1 == -wd.N))
+			})
+		}
+		return len(byMonthDay) > 0 || day == dtstart.Day()
+	}
+
+	var starts []time.Time
+	n := 0
+	for year := dtstart.Year(); year <= customZoneUntil; year += interval {
+		for _, month := range months {
+			dim := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+			for day := 1; day <= dim; day++ {
+				t := time.Date(year, month, day, hour, minute, sec, 0, time.UTC)
+				if t.Before(dtstart) || !matches(t, dim) {
+					continue
+				}
+				if !until.IsZero() && t.After(until) {
+					return starts, nil
+				}
+				if n++; count > 0 && n > count {
+					return starts, nil
+				}
+				starts = append(starts, t)
+			}
+		}
+	}
+	return starts, nil
+}
+
+// zoneRuleUntil UNTIL правила пояса в местном времени правила: UTC переводится смещением from,
+// дата без времени включает весь день
+func zoneRuleUntil(value string, from int) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t.Add(time.Duration(from) * time.Second), err
+	}
+	if len(value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, value)
+		return t.Add(24*time.Hour - time.Second), err
+	}
+	return time.Parse(localLayout, value)
+}
+
+var zoneWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseZoneByDay BYDAY правила пояса: SU, 2SU, -1SU
+func parseZoneByDay(value string) ([]domain.WeekdayNum, error) {
+	var days []domain.WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		day, ok := zoneWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", value)
+		}
+		wd := domain.WeekdayNum{Day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY %q", value)
+			}
+			wd.N = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
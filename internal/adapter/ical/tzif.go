@@ -0,0 +1,52 @@
+package ical
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// zoneType смещение пояса с названием, как ttinfo в TZif
+type zoneType struct {
+	offset int
+	dst    bool
+	name   string
+}
+
+// zoneChange смена смещения: с момента at действует type
+type zoneChange struct {
+	at  time.Time
+	typ int
+}
+
+// tzifData пояс в формате TZif версии 1 (RFC 8536) — только так *time.Location строится из своих правил.
+// types[0] действует до первой смены; моменты смен должны умещаться в int32.
+func tzifData(types []zoneType, changes []zoneChange) []byte {
+	var names []byte
+	nameIndex := make(map[string]int)
+	for _, t := range types {
+		if _, ok := nameIndex[t.name]; !ok {
+			nameIndex[t.name] = len(names)
+			names = append(append(names, t.name...), 0)
+		}
+	}
+
+	data := append([]byte("TZif"), make([]byte, 16)...)
+	for _, n := range []int{0, 0, 0, len(changes), len(types), len(names)} {
+		data = binary.BigEndian.AppendUint32(data, uint32(n))
+	}
+	for _, c := range changes {
+		data = binary.BigEndian.AppendUint32(data, uint32(int32(c.at.Unix())))
+	}
+	for _, c := range changes {
+		data = append(data, byte(c.typ))
+	}
+	for _, t := range types {
+		data = binary.BigEndian.AppendUint32(data, uint32(int32(t.offset)))
+		dst := byte(0)
+		if t.dst {
+			dst = 1
+		}
+		data = append(data, dst, byte(nameIndex[t.name]))
+	}
+	return append(data, names...)
+}
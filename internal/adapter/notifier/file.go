@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.Notifier = (*FileNotifier)(nil)
+)
+
+// FileNotifier пишет напоминания JSON-строками в файл или stdout; target не используется
+type FileNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewFileNotifier(w io.Writer) *FileNotifier {
+	return &FileNotifier{w: w}
+}
+
+// OpenFileNotifier открывает файл на дозапись; пустой путь или "-" означает stdout
+func OpenFileNotifier(path string) (*FileNotifier, error) {
+	if path == "" || path == "-" {
+		return NewFileNotifier(os.Stdout), nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+	return NewFileNotifier(f), nil
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, target string, reminder domain.Reminder) error {
+	line, err := json.Marshal(newPayload(reminder))
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, err := n.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
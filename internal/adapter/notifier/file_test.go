@@ -0,0 +1,32 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewFileNotifier(&buf)
+
+	if err := n.Notify(context.Background(), "", testReminder()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if err := n.Notify(context.Background(), "", testReminder()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var got payload
+	if err := json.Unmarshal(lines[0], &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ReminderId != 1 || got.Description != "Standup" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+}
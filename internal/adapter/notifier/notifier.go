@@ -0,0 +1,36 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// payload тело уведомления для вебхука и файлового канала
+type payload struct {
+	ReminderId  int64     `json:"reminder_id"`
+	EventId     int64     `json:"event_id"`
+	UserId      int64     `json:"user_id"`
+	RemindAt    time.Time `json:"remind_at"`
+	EventDate   time.Time `json:"event_date"`
+	Description string    `json:"description"`
+	Message     string    `json:"message"`
+}
+
+func newPayload(r domain.Reminder) payload {
+	return payload{
+		ReminderId:  r.ReminderId,
+		EventId:     r.EventId,
+		UserId:      r.UserId,
+		RemindAt:    r.RemindAt,
+		EventDate:   r.EventDate,
+		Description: r.Description,
+		Message:     message(r),
+	}
+}
+
+// message текст напоминания для человека
+func message(r domain.Reminder) string {
+	return fmt.Sprintf("Reminder: %q starts at %s", r.Description, r.EventDate.Format(time.RFC3339))
+}
@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.Notifier = (*SMTPNotifier)(nil)
+)
+
+// SMTPNotifier отправляет напоминание письмом на адрес из target
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier addr в формате host:port; если username пустой, отправка идёт без авторизации
+func NewSMTPNotifier(addr, from, username, password string) *SMTPNotifier {
+	n := &SMTPNotifier{addr: addr, from: from}
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return n
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, target string, reminder domain.Reminder) error {
+	if target == "" {
+		return errors.New("email address is not set")
+	}
+	if strings.ContainsAny(target, "\r\n") {
+		return fmt.Errorf("invalid email address %q", target)
+	}
+
+	// net/smtp не принимает контекст, поэтому отправляем в горутине и уважаем отмену
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(n.addr, n.auth, n.from, []string{target}, n.buildMessage(target, reminder))
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *SMTPNotifier) buildMessage(to string, reminder domain.Reminder) []byte {
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace("Reminder: " + reminder.Description)
+
+	var b strings.Builder
+	b.WriteString("From: " + n.from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(message(reminder) + "\r\n")
+	return []byte(b.String())
+}
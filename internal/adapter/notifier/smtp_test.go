@@ -0,0 +1,109 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpMessage письмо, принятое тестовым SMTP-сервером
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer крошечный SMTP-приёмник на одно соединение: EHLO, MAIL, RCPT, DATA, QUIT
+func startSMTPServer(t *testing.T) (string, <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+
+		var msg smtpMessage
+		_ = tp.PrintfLine("220 localhost ESMTP test")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				_ = tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				_ = tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				_ = tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				_ = tp.PrintfLine("250 OK")
+			case cmd == "QUIT":
+				_ = tp.PrintfLine("221 Bye")
+				messages <- msg
+				return
+			default:
+				_ = tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	addr, messages := startSMTPServer(t)
+
+	n := NewSMTPNotifier(addr, "calendar@example.com", "", "")
+	if err := n.Notify(context.Background(), "user@example.com", testReminder()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	msg := <-messages
+	if msg.from != "calendar@example.com" {
+		t.Errorf("expected sender calendar@example.com, got %q", msg.from)
+	}
+	if len(msg.to) != 1 || msg.to[0] != "user@example.com" {
+		t.Errorf("expected recipient user@example.com, got %v", msg.to)
+	}
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("parse headers: %v", err)
+	}
+	if headers.Get("Subject") != "Reminder: Standup" {
+		t.Errorf("unexpected subject %q", headers.Get("Subject"))
+	}
+	if !strings.Contains(msg.data, `"Standup" starts at 2026-03-15T10:00:00Z`) {
+		t.Errorf("unexpected body %q", msg.data)
+	}
+}
+
+func TestSMTPNotifier_InvalidAddress(t *testing.T) {
+	n := NewSMTPNotifier("127.0.0.1:1", "calendar@example.com", "", "")
+	if err := n.Notify(context.Background(), "", testReminder()); err == nil {
+		t.Fatal("expected error for empty address")
+	}
+	if err := n.Notify(context.Background(), "a@example.com\r\nBcc: b@example.com", testReminder()); err == nil {
+		t.Fatal("expected error for header injection")
+	}
+}
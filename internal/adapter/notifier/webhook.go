@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.Notifier = (*WebhookNotifier)(nil)
+)
+
+// errInternalAddress вебхук ведёт во внутреннюю сеть
+var errInternalAddress = errors.New("webhook address is not public")
+
+// WebhookNotifier отправляет напоминание POST-запросом с JSON на URL из target.
+// URL проверяется и при сохранении, но имя может указывать на внутренний адрес или смениться позже,
+// поэтому соединения с непубличными адресами запрещены после разрешения имени.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: timeout, Control: publicAddressOnly}
+	return &WebhookNotifier{
+		client: &http.Client{
+			Timeout: timeout,
+			// Без прокси из окружения: проверяется адрес, с которым соединяется сервер
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+// publicAddressOnly Control для net.Dialer: address уже разрешён в IP
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !domain.IsPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", errInternalAddress, host)
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, target string, reminder domain.Reminder) error {
+	if target == "" {
+		return errors.New("webhook url is not set")
+	}
+	// Адрес мог быть сохранён до проверки при сохранении настроек
+	if u, err := url.Parse(target); err != nil || u.Scheme != "https" {
+		return errors.New("webhook url must be an absolute https url")
+	}
+	body, err := json.Marshal(newPayload(reminder))
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func testReminder() domain.Reminder {
+	return domain.Reminder{
+		ReminderId:  1,
+		EventId:     2,
+		UserId:      3,
+		RemindAt:    time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC),
+		EventDate:   time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+		Description: "Standup",
+	}
+}
+
+// testWebhookNotifier вебхук с клиентом тестового сервера: тот слушает 127.0.0.1, куда настоящий клиент не ходит
+func testWebhookNotifier(srv *httptest.Server) *WebhookNotifier {
+	return &WebhookNotifier{client: srv.Client()}
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var got payload
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected application/json, got %q", ct)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := testWebhookNotifier(srv)
+	if err := n.Notify(context.Background(), srv.URL, testReminder()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got.EventId != 2 || got.Description != "Standup" || got.Message == "" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n := testWebhookNotifier(srv)
+	if err := n.Notify(context.Background(), srv.URL, testReminder()); err == nil {
+		t.Fatal("expected error for non-2xx response")
+	}
+	if err := n.Notify(context.Background(), "", testReminder()); err == nil {
+		t.Fatal("expected error for empty url")
+	}
+}
+
+func TestWebhookNotifier_InternalAddress(t *testing.T) {
+	called := false
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(time.Second)
+	err := n.Notify(context.Background(), srv.URL, testReminder())
+	if !errors.Is(err, errInternalAddress) || called {
+		t.Errorf("expected loopback address refused, got %v (called %v)", err, called)
+	}
+	// Имя, которое разрешается во внутренний адрес, тоже не проходит
+	err = n.Notify(context.Background(), strings.Replace(srv.URL, "127.0.0.1", "localhost", 1), testReminder())
+	if !errors.Is(err, errInternalAddress) {
+		t.Errorf("expected localhost refused, got %v", err)
+	}
+	if err := n.Notify(context.Background(), "http://example.com/hook", testReminder()); err == nil {
+		t.Error("expected error for plain http url")
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.APIKeyRepository = (*CacheMap)(nil)
+)
+
+func (c *CacheMap) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextAPIKeyID++
+	key.KeyId = c.nextAPIKeyID
+	key.CreatedAt = time.Now()
+	c.apiKeys[key.Hash] = *key
+	return nil
+}
+
+func (c *CacheMap) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok := c.apiKeys[hash]
+	if !ok {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
@@ -2,9 +2,7 @@ package cache
 
 import (
 	"context"
-	"errors"
 	"sync"
-	"time"
 
 	"github.com/dontpanicw/calendar/internal/domain"
 	"github.com/dontpanicw/calendar/internal/port"
@@ -18,12 +16,32 @@ type CacheMap struct {
 	mu     sync.RWMutex
 	events map[int64]domain.Event
 	nextID int64
+
+	reminders      map[int64]domain.Reminder
+	nextReminderID int64
+
+	settings map[int64]domain.UserSettings
+
+	// apiKeys по хешу ключа
+	apiKeys      map[string]domain.APIKey
+	nextAPIKeyID int64
+
+	idempotency map[idempotencyKey]domain.IdempotencyRecord
+
+	// feeds токены подписки по пользователю
+	feeds map[int64]domain.FeedToken
 }
 
 func NewCacheMap() *CacheMap {
 	return &CacheMap{
-		events: make(map[int64]domain.Event, 128),
-		nextID: 1,
+		events:         make(map[int64]domain.Event, 128),
+		nextID:         1,
+		reminders:      make(map[int64]domain.Reminder),
+		nextReminderID: 1,
+		settings:       make(map[int64]domain.UserSettings),
+		apiKeys:        make(map[string]domain.APIKey),
+		idempotency:    make(map[idempotencyKey]domain.IdempotencyRecord),
+		feeds:          make(map[int64]domain.FeedToken),
 	}
 }
 
@@ -31,6 +49,7 @@ func (c *CacheMap) CreateEvent(ctx context.Context, event *domain.Event) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	event.EventId = c.nextID
+	event.Version = 1
 	c.nextID++
 	c.events[event.EventId] = *event
 	return nil
@@ -39,72 +58,103 @@ func (c *CacheMap) CreateEvent(ctx context.Context, event *domain.Event) error {
 func (c *CacheMap) UpdateEvent(ctx context.Context, event domain.Event) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	_, ok := c.events[event.EventId]
-	if !ok {
-		return errors.New("event not found")
+	current, ok := c.events[event.EventId]
+	if !ok || current.UserId != event.UserId {
+		return domain.ErrEventNotFound
+	}
+	if event.Version != 0 && event.Version != current.Version {
+		return domain.ErrVersionMismatch
 	}
+	event.Version = current.Version + 1
+	// Как в Postgres: UID внешнего календаря при изменении не меняется
+	event.ExternalUID = current.ExternalUID
 	c.events[event.EventId] = event
 	return nil
 }
 
-func (c *CacheMap) DeleteEvent(ctx context.Context, eventId int64) error {
+func (c *CacheMap) DeleteEvent(ctx context.Context, userID, eventId, version int64) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if _, ok := c.events[eventId]; !ok {
-		return errors.New("event not found")
+	e, ok := c.events[eventId]
+	if !ok || e.UserId != userID {
+		return domain.ErrEventNotFound
+	}
+	if version != 0 && version != e.Version {
+		return domain.ErrVersionMismatch
 	}
 	delete(c.events, eventId)
+	// Как ON DELETE CASCADE в Postgres
+	for id, r := range c.reminders {
+		if r.EventId == eventId {
+			delete(c.reminders, id)
+		}
+	}
 	return nil
 }
 
-func sameDay(a, b time.Time) bool {
-	ya, ma, da := a.Date()
-	yb, mb, db := b.Date()
-	return ya == yb && ma == mb && da == db
-}
-
-func inWeek(start time.Time, t time.Time) bool {
-	weekEnd := start.AddDate(0, 0, 7)
-	return !t.Before(start) && t.Before(weekEnd)
+func (c *CacheMap) GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.events[eventId]
+	if !ok || e.UserId != userID {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	return e, nil
 }
 
-func inMonth(start time.Time, t time.Time) bool {
-	monthEnd := start.AddDate(0, 1, 0)
-	return !t.Before(start) && t.Before(monthEnd)
+func (c *CacheMap) GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, e := range c.events {
+		if uid != "" && e.UserId == userID && e.ExternalUID == uid {
+			return e, nil
+		}
+	}
+	return domain.Event{}, domain.ErrEventNotFound
 }
 
-func (c *CacheMap) GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+func (c *CacheMap) EventsMarker(ctx context.Context, userID int64) (domain.EventsMarker, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	var result []domain.Event
+	var marker domain.EventsMarker
 	for _, e := range c.events {
-		if e.UserId == userID && sameDay(e.Date, date) {
-			result = append(result, e)
+		if e.UserId != userID {
+			continue
 		}
+		marker.Count++
+		marker.MaxEventId = max(marker.MaxEventId, e.EventId)
+		marker.VersionSum += e.Version
 	}
-	return result, nil
+	return marker, nil
 }
 
-func (c *CacheMap) GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+func (c *CacheMap) ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	var result []domain.Event
+	var occurrences []domain.Event
 	for _, e := range c.events {
-		if e.UserId == userID && inWeek(start, e.Date) {
-			result = append(result, e)
+		if query.AllUsers || e.UserId == query.UserId {
+			occurrences = append(occurrences, e.Occurrences(query.From, query.To)...)
 		}
 	}
-	return result, nil
+	return query.Page(occurrences), nil
 }
 
-func (c *CacheMap) GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+func (c *CacheMap) ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-	var result []domain.Event
+	var events []domain.Event
 	for _, e := range c.events {
-		if e.UserId == userID && inMonth(start, e.Date) {
-			result = append(result, e)
+		if (query.AllUsers || e.UserId == query.UserId) && query.Includes(e) {
+			events = append(events, e)
+		}
+	}
+	c.mu.RUnlock()
+
+	domain.SortEvents(events)
+	for _, e := range events {
+		if err := fn(e); err != nil {
+			return err
 		}
 	}
-	return result, nil
+	return nil
 }
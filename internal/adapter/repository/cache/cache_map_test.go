@@ -2,12 +2,35 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
 )
 
+// eventsForDay, eventsForWeek и eventsForMonth — выборки за период через ListEvents, как в usecase
+func eventsForDay(ctx context.Context, repo port.EventRepository, userID int64, date time.Time) ([]domain.Event, error) {
+	from, to := domain.DayRange(date)
+	return eventsIn(ctx, repo, userID, from, to)
+}
+
+func eventsForWeek(ctx context.Context, repo port.EventRepository, userID int64, start time.Time) ([]domain.Event, error) {
+	from, to := domain.WeekRange(start)
+	return eventsIn(ctx, repo, userID, from, to)
+}
+
+func eventsForMonth(ctx context.Context, repo port.EventRepository, userID int64, start time.Time) ([]domain.Event, error) {
+	from, to := domain.MonthRange(start)
+	return eventsIn(ctx, repo, userID, from, to)
+}
+
+func eventsIn(ctx context.Context, repo port.EventRepository, userID int64, from, to time.Time) ([]domain.Event, error) {
+	page, err := repo.ListEvents(ctx, domain.EventQuery{UserId: userID, From: from, To: to, IncludeArchived: true})
+	return page.Events, err
+}
+
 func TestCacheMap_CreateEvent(t *testing.T) {
 	ctx := context.Background()
 	c := NewCacheMap()
@@ -21,7 +44,7 @@ func TestCacheMap_CreateEvent(t *testing.T) {
 	if event.EventId == 0 {
 		t.Error("expected EventId to be assigned")
 	}
-	events, _ := c.GetEventsForDay(ctx, 1, date)
+	events, _ := eventsForDay(ctx, c, 1, date)
 	if len(events) != 1 {
 		t.Errorf("expected 1 event, got %d", len(events))
 	}
@@ -36,14 +59,14 @@ func TestCacheMap_GetEventsForDay(t *testing.T) {
 	otherDay := time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: otherDay, Description: "C"})
 
-	events, err := c.GetEventsForDay(ctx, 1, date)
+	events, err := eventsForDay(ctx, c, 1, date)
 	if err != nil {
 		t.Fatalf("GetEventsForDay: %v", err)
 	}
 	if len(events) != 2 {
 		t.Errorf("expected 2 events for day, got %d", len(events))
 	}
-	eventsOther, _ := c.GetEventsForDay(ctx, 1, otherDay)
+	eventsOther, _ := eventsForDay(ctx, c, 1, otherDay)
 	if len(eventsOther) != 1 {
 		t.Errorf("expected 1 event for other day, got %d", len(eventsOther))
 	}
@@ -59,7 +82,7 @@ func TestCacheMap_GetEventsForWeek(t *testing.T) {
 	outOfWeek := time.Date(2024, 1, 23, 0, 0, 0, 0, time.UTC)
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: outOfWeek, Description: "C"})
 
-	events, err := c.GetEventsForWeek(ctx, 1, start)
+	events, err := eventsForWeek(ctx, c, 1, start)
 	if err != nil {
 		t.Fatalf("GetEventsForWeek: %v", err)
 	}
@@ -76,7 +99,7 @@ func TestCacheMap_GetEventsForMonth(t *testing.T) {
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), Description: "B"})
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Description: "C"})
 
-	events, err := c.GetEventsForMonth(ctx, 1, start)
+	events, err := eventsForMonth(ctx, c, 1, start)
 	if err != nil {
 		t.Fatalf("GetEventsForMonth: %v", err)
 	}
@@ -90,7 +113,7 @@ func TestCacheMap_UpdateEvent(t *testing.T) {
 	c := NewCacheMap()
 	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: date, Description: "Old"})
-	events, _ := c.GetEventsForDay(ctx, 1, date)
+	events, _ := eventsForDay(ctx, c, 1, date)
 	if len(events) != 1 {
 		t.Fatalf("expected 1 event, got %d", len(events))
 	}
@@ -100,7 +123,7 @@ func TestCacheMap_UpdateEvent(t *testing.T) {
 	if err != nil {
 		t.Fatalf("UpdateEvent: %v", err)
 	}
-	eventsAfter, _ := c.GetEventsForDay(ctx, 1, date)
+	eventsAfter, _ := eventsForDay(ctx, c, 1, date)
 	if len(eventsAfter) != 1 || eventsAfter[0].Description != "New" {
 		t.Errorf("expected one event with Description New, got %v", eventsAfter)
 	}
@@ -117,6 +140,9 @@ func TestCacheMap_UpdateEvent_NotFound(t *testing.T) {
 	if err.Error() != "event not found" {
 		t.Errorf("expected 'event not found', got %q", err.Error())
 	}
+	if !errors.Is(err, domain.ErrEventNotFound) || !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
 }
 
 func TestCacheMap_DeleteEvent(t *testing.T) {
@@ -124,16 +150,16 @@ func TestCacheMap_DeleteEvent(t *testing.T) {
 	c := NewCacheMap()
 	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: date, Description: "X"})
-	events, _ := c.GetEventsForDay(ctx, 1, date)
+	events, _ := eventsForDay(ctx, c, 1, date)
 	if len(events) != 1 {
 		t.Fatalf("expected 1 event, got %d", len(events))
 	}
 	id := events[0].EventId
-	err := c.DeleteEvent(ctx, id)
+	err := c.DeleteEvent(ctx, 1, id, 0)
 	if err != nil {
 		t.Fatalf("DeleteEvent: %v", err)
 	}
-	eventsAfter, _ := c.GetEventsForDay(ctx, 1, date)
+	eventsAfter, _ := eventsForDay(ctx, c, 1, date)
 	if len(eventsAfter) != 0 {
 		t.Errorf("expected 0 events after delete, got %d", len(eventsAfter))
 	}
@@ -142,11 +168,154 @@ func TestCacheMap_DeleteEvent(t *testing.T) {
 func TestCacheMap_DeleteEvent_NotFound(t *testing.T) {
 	ctx := context.Background()
 	c := NewCacheMap()
-	err := c.DeleteEvent(ctx, 999)
+	err := c.DeleteEvent(ctx, 1, 999, 0)
 	if err == nil {
 		t.Fatal("expected error for non-existent event")
 	}
 	if err.Error() != "event not found" {
 		t.Errorf("expected 'event not found', got %q", err.Error())
 	}
+	if !errors.Is(err, domain.ErrEventNotFound) || !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestCacheMap_GetEventsForDay_Overlap(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	// Начинается накануне вечером, заканчивается утром
+	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: day.Add(-2 * time.Hour), End: day.Add(2 * time.Hour), Description: "Night"})
+	// Заканчивается ровно в начале дня — конец не включительно
+	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: day.Add(-time.Hour), End: day, Description: "Before"})
+
+	events, err := eventsForDay(ctx, c, 1, day)
+	if err != nil {
+		t.Fatalf("GetEventsForDay: %v", err)
+	}
+	if len(events) != 1 || events[0].Description != "Night" {
+		t.Errorf("expected only overlapping event, got %+v", events)
+	}
+}
+
+func TestCacheMap_MultiDayAllDayEvent(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	// Конференция 30 января — 1 февраля включительно
+	start := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start, End: start.AddDate(0, 0, 3), AllDay: true, Description: "Conference"})
+
+	for i := 0; i < 3; i++ {
+		events, _ := eventsForDay(ctx, c, 1, start.AddDate(0, 0, i))
+		if len(events) != 1 {
+			t.Errorf("day %d: expected 1 event, got %d", i, len(events))
+		}
+	}
+	if events, _ := eventsForDay(ctx, c, 1, start.AddDate(0, 0, 3)); len(events) != 0 {
+		t.Errorf("expected no event after the last day, got %d", len(events))
+	}
+	if events, _ := eventsForMonth(ctx, c, 1, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)); len(events) != 1 {
+		t.Errorf("expected event in February view, got %d", len(events))
+	}
+	if events, _ := eventsForWeek(ctx, c, 1, time.Date(2024, 1, 24, 0, 0, 0, 0, time.UTC)); len(events) != 1 {
+		t.Errorf("expected event in week view, got %d", len(events))
+	}
+
+	// День в часовом поясе пользователя сравнивается по календарной дате
+	msk := time.FixedZone("MSK", 3*60*60)
+	if events, _ := eventsForDay(ctx, c, 1, time.Date(2024, 1, 29, 0, 0, 0, 0, msk)); len(events) != 0 {
+		t.Errorf("expected no event on January 29 in MSK, got %d", len(events))
+	}
+}
+
+func TestCacheMap_RecurringEvent(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	rule, _ := domain.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6")
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	event := &domain.Event{UserId: 1, Date: start, End: start.Add(15 * time.Minute), Recurrence: rule, Description: "Standup"}
+	_ = c.CreateEvent(ctx, event)
+
+	events, _ := eventsForWeek(ctx, c, 1, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC))
+	if len(events) != 2 {
+		t.Fatalf("expected 2 occurrences in week, got %d", len(events))
+	}
+	if events[0].SeriesId != event.EventId || !events[0].OriginalStart.Equal(time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected occurrence %+v", events[0])
+	}
+
+	// COUNT=6 — последнее повторение 17 января
+	events, _ = eventsForMonth(ctx, c, 1, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(events) != 6 {
+		t.Errorf("expected 6 occurrences in month, got %d", len(events))
+	}
+}
+
+func TestCacheMap_GetEventsForWeek_DST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	ctx := context.Background()
+	c := NewCacheMap()
+	// Неделя с переходом на летнее время 8 марта 2026: в ней 167 часов, а не 168
+	start := time.Date(2026, 3, 8, 0, 0, 0, 0, newYork)
+	late := time.Date(2026, 3, 14, 23, 30, 0, 0, newYork)
+	next := time.Date(2026, 3, 15, 0, 30, 0, 0, newYork)
+	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: late.UTC(), End: late.Add(20 * time.Minute).UTC(), Description: "Late"})
+	_ = c.CreateEvent(ctx, &domain.Event{UserId: 1, Date: next.UTC(), End: next.Add(20 * time.Minute).UTC(), Description: "Next week"})
+
+	events, _ := eventsForWeek(ctx, c, 1, start)
+	if len(events) != 1 || events[0].Description != "Late" {
+		t.Errorf("expected only the last event of the local week, got %+v", events)
+	}
+}
+
+func TestCacheMap_GetEvent(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "X"}
+	_ = c.CreateEvent(ctx, event)
+
+	got, err := c.GetEvent(ctx, 1, event.EventId)
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if got.Description != "X" {
+		t.Errorf("expected description X, got %q", got.Description)
+	}
+	if _, err := c.GetEvent(ctx, 2, event.EventId); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for another user, got %v", err)
+	}
+}
+
+func TestCacheMap_Version(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "X"}
+	_ = c.CreateEvent(ctx, event)
+	if event.Version != 1 {
+		t.Fatalf("expected version 1 after create, got %d", event.Version)
+	}
+
+	updated := *event
+	updated.Description = "Y"
+	if err := c.UpdateEvent(ctx, updated); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	stored, _ := c.GetEvent(ctx, 1, event.EventId)
+	if stored.Version != 2 {
+		t.Errorf("expected version 2 after update, got %d", stored.Version)
+	}
+
+	// Запись по устаревшей версии не проходит
+	if err := c.UpdateEvent(ctx, updated); !errors.Is(err, domain.ErrVersionMismatch) || !errors.Is(err, domain.ErrPrecondition) {
+		t.Errorf("UpdateEvent: expected ErrVersionMismatch, got %v", err)
+	}
+	if err := c.DeleteEvent(ctx, 1, event.EventId, 1); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("DeleteEvent: expected ErrVersionMismatch, got %v", err)
+	}
+	if err := c.DeleteEvent(ctx, 1, event.EventId, 2); err != nil {
+		t.Errorf("DeleteEvent: %v", err)
+	}
 }
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.FeedTokenRepository = (*CacheMap)(nil)
+)
+
+func (c *CacheMap) SaveFeedToken(ctx context.Context, token *domain.FeedToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	token.CreatedAt = now
+	token.ModifiedAt = now
+	token.ContentHash = ""
+	c.feeds[token.UserId] = *token
+	return nil
+}
+
+func (c *CacheMap) GetFeedTokenByHash(ctx context.Context, hash string) (domain.FeedToken, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, token := range c.feeds {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+	return domain.FeedToken{}, domain.ErrFeedNotFound
+}
+
+func (c *CacheMap) DeleteFeedToken(ctx context.Context, userID int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.feeds[userID]; !ok {
+		return domain.ErrFeedNotFound
+	}
+	delete(c.feeds, userID)
+	return nil
+}
+
+func (c *CacheMap) TouchFeedContent(ctx context.Context, userID int64, contentHash string, at time.Time) (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, ok := c.feeds[userID]
+	if !ok {
+		return time.Time{}, domain.ErrFeedNotFound
+	}
+	if token.ContentHash != contentHash {
+		token.ContentHash = contentHash
+		token.ModifiedAt = at
+		c.feeds[userID] = token
+	}
+	return token.ModifiedAt, nil
+}
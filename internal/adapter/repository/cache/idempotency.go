@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.IdempotencyRepository = (*CacheMap)(nil)
+)
+
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+func (c *CacheMap) CreateIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := idempotencyKey{record.UserId, record.Key}
+	if existing, ok := c.idempotency[id]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return existing, false, nil
+	}
+	c.idempotency[id] = record
+	return record, true, nil
+}
+
+func (c *CacheMap) SaveIdempotencyResponse(ctx context.Context, record domain.IdempotencyRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id := idempotencyKey{record.UserId, record.Key}
+	existing, ok := c.idempotency[id]
+	if !ok {
+		return nil
+	}
+	existing.StatusCode = record.StatusCode
+	existing.ContentType = record.ContentType
+	existing.Location = record.Location
+	existing.Body = record.Body
+	c.idempotency[id] = existing
+	return nil
+}
+
+func (c *CacheMap) DeleteIdempotencyRecord(ctx context.Context, userID int64, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.idempotency, idempotencyKey{userID, key})
+	return nil
+}
+
+func (c *CacheMap) DeleteExpiredIdempotencyRecords(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for id, record := range c.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(c.idempotency, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.ReminderRepository = (*CacheMap)(nil)
+)
+
+func (c *CacheMap) ReplacePendingReminders(ctx context.Context, eventId int64, reminders []domain.Reminder) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deletePendingLocked(eventId)
+	for i := range reminders {
+		rem := &reminders[i]
+		rem.ReminderId = c.nextReminderID
+		c.nextReminderID++
+		rem.EventId = eventId
+		rem.Status = domain.ReminderPending
+		c.reminders[rem.ReminderId] = *rem
+	}
+	return nil
+}
+
+func (c *CacheMap) DeletePendingReminders(ctx context.Context, eventId int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deletePendingLocked(eventId)
+	return nil
+}
+
+func (c *CacheMap) deletePendingLocked(eventId int64) {
+	for id, r := range c.reminders {
+		if r.EventId == eventId && r.Status == domain.ReminderPending {
+			delete(c.reminders, id)
+		}
+	}
+}
+
+func (c *CacheMap) GetPendingReminders(ctx context.Context) ([]domain.Reminder, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := c.remindersWithStatusLocked(domain.ReminderPending)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FireAt().Before(result[j].FireAt())
+	})
+	return result, nil
+}
+
+func (c *CacheMap) GetDeadReminders(ctx context.Context) ([]domain.Reminder, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result := c.remindersWithStatusLocked(domain.ReminderDead)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ReminderId > result[j].ReminderId
+	})
+	return result, nil
+}
+
+func (c *CacheMap) remindersWithStatusLocked(status domain.ReminderStatus) []domain.Reminder {
+	var result []domain.Reminder
+	for _, r := range c.reminders {
+		if r.Status == status {
+			result = append(result, c.withEventLocked(r))
+		}
+	}
+	return result
+}
+
+// withEventLocked подставляет данные события, как JOIN в Postgres.
+// EventDate сохраняется, если напоминание относится к конкретному повторению серии.
+func (c *CacheMap) withEventLocked(r domain.Reminder) domain.Reminder {
+	if e, ok := c.events[r.EventId]; ok {
+		if r.EventDate.IsZero() {
+			r.EventDate = e.Date
+		}
+		r.Description = e.Description
+	}
+	return r
+}
+
+func (c *CacheMap) MarkReminderSent(ctx context.Context, reminderId int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reminders[reminderId]
+	if !ok || r.Status != domain.ReminderPending {
+		return domain.ErrReminderNotFound
+	}
+	r.Status = domain.ReminderSent
+	r.NextAttemptAt = time.Time{}
+	c.reminders[reminderId] = r
+	return nil
+}
+
+func (c *CacheMap) MarkReminderFailed(ctx context.Context, reminderId int64, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reminders[reminderId]
+	if !ok || r.Status != domain.ReminderPending {
+		return domain.ErrReminderNotFound
+	}
+	r.Status = domain.ReminderFailed
+	r.LastError = reason
+	c.reminders[reminderId] = r
+	return nil
+}
+
+func (c *CacheMap) MarkReminderRetry(ctx context.Context, reminderId int64, attempts int, nextAttemptAt time.Time, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reminders[reminderId]
+	if !ok || r.Status != domain.ReminderPending {
+		return domain.ErrReminderNotFound
+	}
+	r.Attempts = attempts
+	r.NextAttemptAt = nextAttemptAt
+	r.LastError = reason
+	c.reminders[reminderId] = r
+	return nil
+}
+
+func (c *CacheMap) MarkReminderDead(ctx context.Context, reminderId int64, attempts int, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reminders[reminderId]
+	if !ok || r.Status != domain.ReminderPending {
+		return domain.ErrReminderNotFound
+	}
+	r.Status = domain.ReminderDead
+	r.Attempts = attempts
+	r.NextAttemptAt = time.Time{}
+	r.LastError = reason
+	c.reminders[reminderId] = r
+	return nil
+}
+
+func (c *CacheMap) RedriveReminder(ctx context.Context, reminderId int64, at time.Time) (domain.Reminder, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.reminders[reminderId]
+	if !ok || r.Status != domain.ReminderDead {
+		return domain.Reminder{}, domain.ErrReminderNotFound
+	}
+	r.Status = domain.ReminderPending
+	r.Attempts = 0
+	r.NextAttemptAt = at
+	c.reminders[reminderId] = r
+	return c.withEventLocked(r), nil
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestCacheMap_ReplacePendingReminders(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	date := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	event := &domain.Event{UserId: 1, Date: date, Description: "Meeting"}
+	_ = c.CreateEvent(ctx, event)
+
+	first := []domain.Reminder{{UserId: 1, RemindAt: date.Add(-time.Hour)}}
+	if err := c.ReplacePendingReminders(ctx, event.EventId, first); err != nil {
+		t.Fatalf("ReplacePendingReminders: %v", err)
+	}
+	if first[0].ReminderId == 0 {
+		t.Error("expected ReminderId to be assigned")
+	}
+	_ = c.MarkReminderSent(ctx, first[0].ReminderId)
+
+	second := []domain.Reminder{{UserId: 1, RemindAt: date.Add(-30 * time.Minute)}}
+	_ = c.ReplacePendingReminders(ctx, event.EventId, second)
+
+	pending, err := c.GetPendingReminders(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingReminders: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ReminderId != second[0].ReminderId {
+		t.Fatalf("expected only the new reminder pending, got %v", pending)
+	}
+	if pending[0].Description != "Meeting" || !pending[0].EventDate.Equal(date) {
+		t.Errorf("expected event data to be attached, got %+v", pending[0])
+	}
+}
+
+func TestCacheMap_DeleteEvent_CascadesReminders(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	date := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	event := &domain.Event{UserId: 1, Date: date, Description: "Meeting"}
+	_ = c.CreateEvent(ctx, event)
+	_ = c.ReplacePendingReminders(ctx, event.EventId, []domain.Reminder{{UserId: 1, RemindAt: date.Add(-time.Hour)}})
+
+	_ = c.DeleteEvent(ctx, event.UserId, event.EventId, 0)
+
+	pending, _ := c.GetPendingReminders(ctx)
+	if len(pending) != 0 {
+		t.Errorf("expected reminders to be deleted with event, got %v", pending)
+	}
+}
+
+func TestCacheMap_MarkReminderFailed(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	reminders := []domain.Reminder{{UserId: 1, RemindAt: time.Now()}}
+	_ = c.ReplacePendingReminders(ctx, 1, reminders)
+
+	if err := c.MarkReminderFailed(ctx, reminders[0].ReminderId, "boom"); err != nil {
+		t.Fatalf("MarkReminderFailed: %v", err)
+	}
+	if r := c.reminders[reminders[0].ReminderId]; r.Status != domain.ReminderFailed || r.LastError != "boom" {
+		t.Errorf("expected failed reminder with last error, got %+v", r)
+	}
+}
+
+// TestCacheMap_MarkReminderNotPending отметка после отправки не перезаписывает напоминание,
+// которое за это время заменили или уже отметили
+func TestCacheMap_MarkReminderNotPending(t *testing.T) {
+	ctx := context.Background()
+	c := NewCacheMap()
+	date := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	event := &domain.Event{UserId: 1, Date: date, Description: "Meeting"}
+	_ = c.CreateEvent(ctx, event)
+	reminders := []domain.Reminder{{UserId: 1, RemindAt: date.Add(-time.Hour)}, {UserId: 1, RemindAt: date.Add(-time.Minute)}}
+	_ = c.ReplacePendingReminders(ctx, event.EventId, reminders)
+	sent, replaced := reminders[0].ReminderId, reminders[1].ReminderId
+
+	if err := c.MarkReminderSent(ctx, sent); err != nil {
+		t.Fatalf("MarkReminderSent: %v", err)
+	}
+	_ = c.ReplacePendingReminders(ctx, event.EventId, nil)
+
+	for name, mark := range map[string]func(id int64) error{
+		"sent":   func(id int64) error { return c.MarkReminderSent(ctx, id) },
+		"failed": func(id int64) error { return c.MarkReminderFailed(ctx, id, "late") },
+		"dead":   func(id int64) error { return c.MarkReminderDead(ctx, id, 5, "timeout") },
+	} {
+		for _, id := range []int64{sent, replaced} {
+			if err := mark(id); !errors.Is(err, domain.ErrReminderNotFound) {
+				t.Errorf("%s %d: expected ErrReminderNotFound, got %v", name, id, err)
+			}
+		}
+	}
+	if r := c.reminders[sent]; r.Status != domain.ReminderSent || r.LastError != "" {
+		t.Errorf("expected sent reminder untouched, got %+v", r)
+	}
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.UserSettingsRepository = (*CacheMap)(nil)
+)
+
+func (c *CacheMap) GetUserSettings(ctx context.Context, userID int64) (domain.UserSettings, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if s, ok := c.settings[userID]; ok {
+		return s, nil
+	}
+	return domain.UserSettings{UserId: userID}, nil
+}
+
+func (c *CacheMap) SaveUserSettings(ctx context.Context, settings domain.UserSettings) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings[settings.UserId] = settings
+	return nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+const (
+	createAPIKeyQuery = `INSERT INTO api_keys (user_id, name, key_hash, admin)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING key_id, created_at`
+	getAPIKeyByHashQuery = `SELECT key_id, user_id, name, key_hash, admin, created_at
+			  FROM api_keys
+			  WHERE key_hash = $1`
+)
+
+var (
+	_ port.APIKeyRepository = (*Repository)(nil)
+)
+
+func (r *Repository) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	err := r.DB.QueryRowContext(ctx, createAPIKeyQuery, key.UserId, key.Name, key.Hash, key.Admin).Scan(&key.KeyId, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.DB.QueryRowContext(ctx, getAPIKeyByHashQuery, hash).Scan(&key.KeyId, &key.UserId, &key.Name, &key.Hash, &key.Admin, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+const (
+	// saveFeedTokenQuery новый токен заменяет прежний, поэтому старая ссылка сразу перестаёт работать
+	saveFeedTokenQuery = `INSERT INTO feed_tokens (user_id, token_hash)
+			  VALUES ($1, $2)
+			  ON CONFLICT (user_id) DO UPDATE
+			  SET token_hash = EXCLUDED.token_hash, content_hash = '', modified_at = NOW(), created_at = NOW()
+			  RETURNING created_at, modified_at`
+	getFeedTokenByHashQuery = `SELECT user_id, token_hash, content_hash, modified_at, created_at
+			  FROM feed_tokens
+			  WHERE token_hash = $1`
+	deleteFeedTokenQuery = `DELETE FROM feed_tokens WHERE user_id = $1`
+	// touchFeedContentQuery время изменения сдвигается, только если хеш содержимого другой
+	touchFeedContentQuery = `UPDATE feed_tokens
+			  SET modified_at = CASE WHEN content_hash = $2 THEN modified_at ELSE $3 END, content_hash = $2
+			  WHERE user_id = $1
+			  RETURNING modified_at`
+)
+
+var (
+	_ port.FeedTokenRepository = (*Repository)(nil)
+)
+
+func (r *Repository) SaveFeedToken(ctx context.Context, token *domain.FeedToken) error {
+	err := r.DB.QueryRowContext(ctx, saveFeedTokenQuery, token.UserId, token.Hash).Scan(&token.CreatedAt, &token.ModifiedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save feed token: %w", err)
+	}
+	token.ContentHash = ""
+	return nil
+}
+
+func (r *Repository) GetFeedTokenByHash(ctx context.Context, hash string) (domain.FeedToken, error) {
+	var token domain.FeedToken
+	err := r.DB.QueryRowContext(ctx, getFeedTokenByHashQuery, hash).
+		Scan(&token.UserId, &token.Hash, &token.ContentHash, &token.ModifiedAt, &token.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.FeedToken{}, domain.ErrFeedNotFound
+	}
+	if err != nil {
+		return domain.FeedToken{}, fmt.Errorf("failed to get feed token: %w", err)
+	}
+	return token, nil
+}
+
+func (r *Repository) DeleteFeedToken(ctx context.Context, userID int64) error {
+	result, err := r.DB.ExecContext(ctx, deleteFeedTokenQuery, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete feed token: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrFeedNotFound
+	}
+	return nil
+}
+
+func (r *Repository) TouchFeedContent(ctx context.Context, userID int64, contentHash string, at time.Time) (time.Time, error) {
+	var modified time.Time
+	err := r.DB.QueryRowContext(ctx, touchFeedContentQuery, userID, contentHash, at).Scan(&modified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, domain.ErrFeedNotFound
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to update feed: %w", err)
+	}
+	return modified, nil
+}
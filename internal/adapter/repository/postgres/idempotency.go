@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+const (
+	// createIdempotencyRecordQuery занимает свободный ключ или ключ с истёкшим сроком
+	createIdempotencyRecordQuery = `INSERT INTO idempotency_keys (user_id, idem_key, request_hash, created_at, expires_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (user_id, idem_key) DO UPDATE
+			  SET request_hash = EXCLUDED.request_hash, status_code = 0, content_type = '', location = '', body = NULL,
+			      created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+			  WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`
+	getIdempotencyRecordQuery = `SELECT user_id, idem_key, request_hash, status_code, content_type, location, body, created_at, expires_at
+			  FROM idempotency_keys
+			  WHERE user_id = $1 AND idem_key = $2`
+	saveIdempotencyResponseQuery = `UPDATE idempotency_keys
+			  SET status_code = $3, content_type = $4, location = $5, body = $6
+			  WHERE user_id = $1 AND idem_key = $2`
+	deleteIdempotencyRecordQuery         = `DELETE FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2`
+	deleteExpiredIdempotencyRecordsQuery = `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`
+)
+
+var (
+	_ port.IdempotencyRepository = (*Repository)(nil)
+)
+
+func (r *Repository) CreateIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	result, err := r.DB.ExecContext(ctx, createIdempotencyRecordQuery,
+		record.UserId, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("failed to create idempotency record: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing domain.IdempotencyRecord
+	err = r.DB.QueryRowContext(ctx, getIdempotencyRecordQuery, record.UserId, record.Key).Scan(
+		&existing.UserId, &existing.Key, &existing.RequestHash, &existing.StatusCode, &existing.ContentType,
+		&existing.Location, &existing.Body, &existing.CreatedAt, &existing.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Запись удалили между вставкой и чтением — её владелец только что снял резерв
+		return domain.IdempotencyRecord{}, false, domain.ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	return existing, false, nil
+}
+
+func (r *Repository) SaveIdempotencyResponse(ctx context.Context, record domain.IdempotencyRecord) error {
+	_, err := r.DB.ExecContext(ctx, saveIdempotencyResponseQuery,
+		record.UserId, record.Key, record.StatusCode, record.ContentType, record.Location, record.Body)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency response: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteIdempotencyRecord(ctx context.Context, userID int64, key string) error {
+	if _, err := r.DB.ExecContext(ctx, deleteIdempotencyRecordQuery, userID, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency record: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteExpiredIdempotencyRecords(ctx context.Context) error {
+	if _, err := r.DB.ExecContext(ctx, deleteExpiredIdempotencyRecordsQuery); err != nil {
+		return fmt.Errorf("failed to delete expired idempotency records: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/dontpanicw/calendar/config"
 	"github.com/dontpanicw/calendar/internal/domain"
@@ -12,25 +14,53 @@ import (
 )
 
 const (
+	eventColumns = `event_id, user_id, version, date, end_date, all_day, is_archived, description, reminders, rrule, exceptions, time_zone, external_uid`
+
 	updateArchiveEventsQuery = `UPDATE events 
-						  SET is_archived = true 
-						  WHERE date < NOW() AND is_archived = false;`
+						  SET is_archived = true, version = version + 1 
+						  WHERE end_date < NOW() AND rrule = '' AND is_archived = false;`
+	// updateEventsQuery и deleteEventQuery ограничены владельцем: чужое событие не найдётся.
+	// Версия $12 (или $3) проверяется, если не 0.
 	updateEventsQuery = `UPDATE events 
-			  SET user_id = $1, date = $2, is_archived = $3, description = $4, updated_at = NOW() 
-			  WHERE event_id = $5`
-	deleteEventQuery     = `DELETE FROM events WHERE event_id = $1`
-	getEventsForDayQuery = `SELECT event_id, user_id, date, is_archived, description 
+			  SET date = $2, end_date = $3, all_day = $4, is_archived = $5, description = $6, reminders = $7, rrule = $8, exceptions = $9, time_zone = $10, version = version + 1, updated_at = NOW() 
+			  WHERE event_id = $11 AND user_id = $1 AND ($12 = 0 OR version = $12)`
+	deleteEventQuery = `DELETE FROM events WHERE event_id = $1 AND user_id = $2 AND ($3 = 0 OR version = $3)`
+	eventExistsQuery = `SELECT EXISTS (SELECT 1 FROM events WHERE event_id = $1 AND user_id = $2)`
+	getEventQuery    = `SELECT ` + eventColumns + ` FROM events WHERE event_id = $1 AND user_id = $2`
+	// eventsMarkerQuery агрегаты по индексу events_user_range_idx, без чтения описаний и правил
+	eventsMarkerQuery = `SELECT COUNT(*), COALESCE(MAX(event_id), 0), COALESCE(SUM(version), 0) FROM events WHERE user_id = $1`
+	// getEventByExternalUIDQuery пустой UID не ищется: у созданных не импортом событий его нет
+	getEventByExternalUIDQuery = `SELECT ` + eventColumns + ` FROM events WHERE user_id = $1 AND external_uid = $2 AND $2 <> ''`
+	// listSingleEvents события без повторения, пересекающиеся с [$2, $3); события на весь день — с плавающими датами [$4, $5).
+	// $6 — включать архивные, $8 — только архивные, $7 — подстрока описания. Страница отбирается в БД:
+	// $10 — есть курсор ($11, $12), $13 — размер страницы (NULL — без ограничения).
+	listSingleEvents = `SELECT ` + eventColumns + ` 
+			  FROM events 
+			  WHERE ($9 OR user_id = $1) AND rrule = '' AND (
+			      (NOT all_day AND date < $3 AND (end_date > $2 OR date >= $2))
+			      OR (all_day AND date < $5 AND end_date > $4))
+			    AND ($6 OR $8 OR NOT is_archived) AND (NOT $8 OR is_archived)
+			    AND ($7 = '' OR POSITION(LOWER($7) IN LOWER(description)) > 0)`
+	listSingleEventsAscQuery  = listSingleEvents + ` AND (NOT $10 OR (date, event_id) > ($11, $12)) ORDER BY date, event_id LIMIT $13`
+	listSingleEventsDescQuery = listSingleEvents + ` AND (NOT $10 OR (date, event_id) < ($11, $12)) ORDER BY date DESC, event_id DESC LIMIT $13`
+	// exportEventsQuery события без повторения, пересекающиеся с интервалом, и серии, начавшиеся до его конца,
+	// одним запросом; параметры как у listSingleEvents. Попадает ли серия в выборку, проверяется в Go.
+	exportEventsQuery = `SELECT ` + eventColumns + ` 
 			  FROM events 
-			  WHERE user_id = $1 AND DATE(date) = DATE($2)
-			  ORDER BY date`
-	getEventsForWeekQuery = `SELECT event_id, user_id, date, is_archived, description
-				FROM events
-				WHERE user_id = $1 AND date >= $2 AND date < $2 + INTERVAL '7 days'
-				ORDER BY date`
-	getEventsForMonthQuery = `SELECT event_id, user_id, date, is_archived, description 
+			  WHERE ($9 OR user_id = $1) AND (
+			      (rrule = '' AND NOT all_day AND date < $3 AND (end_date > $2 OR date >= $2))
+			      OR (rrule = '' AND all_day AND date < $5 AND end_date > $4)
+			      OR (rrule <> '' AND date < GREATEST($3, $5)))
+			    AND ($6 OR $8 OR NOT is_archived) AND (NOT $8 OR is_archived)
+			    AND ($7 = '' OR rrule <> '' OR POSITION(LOWER($7) IN LOWER(description)) > 0)
+			  ORDER BY date, event_id`
+	// listSeriesQuery серии с повторениями, начавшиеся до $2; они разворачиваются в Go, а описание
+	// повторения может быть своим, поэтому текст проверяется после развёртывания
+	listSeriesQuery = `SELECT ` + eventColumns + ` 
 			  FROM events 
-			  WHERE user_id = $1 AND date >= $2 AND date < $2 + INTERVAL '1 month'
-			  ORDER BY date`
+			  WHERE ($3 OR user_id = $1) AND rrule <> '' AND date < $2
+			    AND ($4 OR $5 OR NOT is_archived) AND (NOT $5 OR is_archived)
+			  ORDER BY date, event_id`
 )
 
 type Repository struct {
@@ -67,11 +97,19 @@ func NewRepository(cfg *config.Config, logger *log_worker.Logger) (*Repository,
 }
 
 func (r *Repository) CreateEvent(ctx context.Context, event *domain.Event) error {
-	query := `INSERT INTO events (user_id, date, is_archived, description) 
-			  VALUES ($1, $2, $3, $4) 
-			  RETURNING event_id`
+	query := `INSERT INTO events (user_id, date, end_date, all_day, is_archived, description, reminders, rrule, exceptions, time_zone, external_uid) 
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
+			  RETURNING event_id, version`
 
-	err := r.DB.QueryRowContext(ctx, query, event.UserId, event.Date, event.IsArchived, event.Description).Scan(&event.EventId)
+	reminders, err := marshalReminders(*event)
+	if err != nil {
+		return err
+	}
+	exceptions, err := marshalExceptions(*event)
+	if err != nil {
+		return err
+	}
+	err = r.DB.QueryRowContext(ctx, query, event.UserId, event.Date, eventEnd(*event), event.AllDay, event.IsArchived, event.Description, reminders, recurrence(*event), exceptions, event.TimeZone, event.ExternalUID).Scan(&event.EventId, &event.Version)
 	if err != nil {
 		return fmt.Errorf("failed to create event: %w", err)
 	}
@@ -80,7 +118,15 @@ func (r *Repository) CreateEvent(ctx context.Context, event *domain.Event) error
 }
 
 func (r *Repository) UpdateEvent(ctx context.Context, event domain.Event) error {
-	result, err := r.DB.ExecContext(ctx, updateEventsQuery, event.UserId, event.Date, event.IsArchived, event.Description, event.EventId)
+	reminders, err := marshalReminders(event)
+	if err != nil {
+		return err
+	}
+	exceptions, err := marshalExceptions(event)
+	if err != nil {
+		return err
+	}
+	result, err := r.DB.ExecContext(ctx, updateEventsQuery, event.UserId, event.Date, eventEnd(event), event.AllDay, event.IsArchived, event.Description, reminders, recurrence(event), exceptions, event.TimeZone, event.EventId, event.Version)
 	if err != nil {
 		return fmt.Errorf("failed to update event: %w", err)
 	}
@@ -91,14 +137,26 @@ func (r *Repository) UpdateEvent(ctx context.Context, event domain.Event) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("event with id %d not found", event.EventId)
+		return r.missingEventError(ctx, event.UserId, event.EventId)
 	}
 
 	return nil
 }
 
-func (r *Repository) DeleteEvent(ctx context.Context, eventId int64) error {
-	result, err := r.DB.ExecContext(ctx, deleteEventQuery, eventId)
+// missingEventError объясняет, почему изменение не затронуло ни одной строки: события нет или не совпала версия
+func (r *Repository) missingEventError(ctx context.Context, userID, eventId int64) error {
+	var exists bool
+	if err := r.DB.QueryRowContext(ctx, eventExistsQuery, eventId, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check event: %w", err)
+	}
+	if exists {
+		return domain.ErrVersionMismatch
+	}
+	return domain.NewNotFoundError("event_not_found", "event with id %d not found", eventId)
+}
+
+func (r *Repository) DeleteEvent(ctx context.Context, userID, eventId, version int64) error {
+	result, err := r.DB.ExecContext(ctx, deleteEventQuery, eventId, userID, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
@@ -109,72 +167,196 @@ func (r *Repository) DeleteEvent(ctx context.Context, eventId int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("event with id %d not found", eventId)
+		return r.missingEventError(ctx, userID, eventId)
 	}
 
 	return nil
 }
 
-func (r *Repository) GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
-	rows, err := r.DB.QueryContext(ctx, getEventsForDayQuery, userID, date)
+func (r *Repository) GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error) {
+	event, err := scanEvent(r.DB.QueryRowContext(ctx, getEventQuery, eventId, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Event{}, domain.NewNotFoundError("event_not_found", "event with id %d not found", eventId)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events for day: %w", err)
+		return domain.Event{}, err
 	}
-	defer rows.Close()
+	return event, nil
+}
 
-	var events []domain.Event
-	for rows.Next() {
-		var event domain.Event
-		if err := rows.Scan(&event.EventId, &event.UserId, &event.Date, &event.IsArchived, &event.Description); err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
-		}
-		events = append(events, event)
+func (r *Repository) GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error) {
+	event, err := scanEvent(r.DB.QueryRowContext(ctx, getEventByExternalUIDQuery, userID, uid))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return domain.Event{}, err
 	}
+	return event, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+func (r *Repository) EventsMarker(ctx context.Context, userID int64) (domain.EventsMarker, error) {
+	var marker domain.EventsMarker
+	err := r.DB.QueryRowContext(ctx, eventsMarkerQuery, userID).Scan(&marker.Count, &marker.MaxEventId, &marker.VersionSum)
+	if err != nil {
+		return domain.EventsMarker{}, fmt.Errorf("failed to get events marker: %w", err)
 	}
+	return marker, nil
+}
 
-	return events, nil
+// ListEvents отбирает страницу событий без повторения в БД, а серии разворачивает в Go: повторения в БД
+// не хранятся. Из событий без повторения читается не больше страницы после курсора, поэтому страница
+// из их объединения с повторениями серий та же, что из всей выборки.
+func (r *Repository) ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error) {
+	events, err := r.listSingleEvents(ctx, query)
+	if err != nil {
+		return domain.EventPage{}, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	_, floatingTo := domain.FloatingRange(query.From, query.To)
+	seriesTo := query.To
+	if floatingTo.After(seriesTo) {
+		seriesTo = floatingTo
+	}
+	rows, err := r.DB.QueryContext(ctx, listSeriesQuery, query.UserId, seriesTo, query.AllUsers,
+		query.IncludeArchived, query.OnlyArchived)
+	if err != nil {
+		return domain.EventPage{}, fmt.Errorf("failed to list series: %w", err)
+	}
+	defer rows.Close()
+
+	series, err := scanEvents(rows)
+	if err != nil {
+		return domain.EventPage{}, fmt.Errorf("failed to list series: %w", err)
+	}
+	for _, e := range series {
+		events = append(events, e.Occurrences(query.From, query.To)...)
+	}
+	return query.Page(events), nil
 }
 
-func (r *Repository) GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+// listSingleEvents события без повторения после курсора выборки, не больше страницы и ещё одного —
+// чтобы знать, есть ли следующая страница
+func (r *Repository) listSingleEvents(ctx context.Context, query domain.EventQuery) ([]domain.Event, error) {
+	sqlQuery := listSingleEventsAscQuery
+	if query.Sort == domain.SortDesc {
+		sqlQuery = listSingleEventsDescQuery
+	}
+	var after domain.EventCursor
+	if query.After != nil {
+		after = *query.After
+	}
+	var limit sql.NullInt64
+	if query.Limit > 0 {
+		limit = sql.NullInt64{Int64: int64(query.Limit) + 1, Valid: true}
+	}
+	floatingFrom, floatingTo := domain.FloatingRange(query.From, query.To)
+	rows, err := r.DB.QueryContext(ctx, sqlQuery, query.UserId, query.From, query.To, floatingFrom, floatingTo,
+		query.IncludeArchived, query.Text, query.OnlyArchived, query.AllUsers, query.After != nil, after.Start, after.EventId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanEvents(rows)
+}
 
-	rows, err := r.DB.QueryContext(ctx, getEventsForWeekQuery, userID, start)
+// ExportEvents читает события одним запросом и передаёт их fn по мере чтения строк, не собирая выборку
+// в памяти; пока fn пишет клиенту, соединение с БД занято
+func (r *Repository) ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error {
+	floatingFrom, floatingTo := domain.FloatingRange(query.From, query.To)
+	rows, err := r.DB.QueryContext(ctx, exportEventsQuery, query.UserId, query.From, query.To, floatingFrom, floatingTo,
+		query.IncludeArchived, query.Text, query.OnlyArchived, query.AllUsers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events for week: %w", err)
+		return fmt.Errorf("failed to export events: %w", err)
 	}
 	defer rows.Close()
 
-	var events []domain.Event
 	for rows.Next() {
-		var event domain.Event
-		if err := rows.Scan(&event.EventId, &event.UserId, &event.Date, &event.IsArchived, &event.Description); err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+		event, err := scanEvent(rows)
+		if err != nil {
+			return fmt.Errorf("failed to export events: %w", err)
+		}
+		if event.Recurrence != nil && !query.Includes(event) {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
 		}
-		events = append(events, event)
 	}
-
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+		return fmt.Errorf("failed to export events: %w", err)
 	}
+	return nil
+}
 
-	return events, nil
+func (r *Repository) ArchiveOldEvents(ctx context.Context) error {
+	_, err := r.DB.ExecContext(ctx, updateArchiveEventsQuery)
+	if err != nil {
+		return fmt.Errorf("failed to archive old events: %w", err)
+	}
+
+	return nil
+}
+
+// eventReminders настройки напоминаний события в колонке reminders (JSONB)
+type eventReminders struct {
+	Offsets []domain.Offset `json:"offsets,omitempty"`
+	Times   []time.Time     `json:"times,omitempty"`
+	Channel string          `json:"channel,omitempty"`
+	Target  string          `json:"target,omitempty"`
+}
+
+// eventEnd конец события для колонки end_date; событие без конца хранится как мгновенное
+func eventEnd(event domain.Event) time.Time {
+	if event.End.After(event.Date) {
+		return event.End
+	}
+	return event.Date
+}
+
+// recurrence правило повторения для колонки rrule; пустая строка — событие без повторения
+func recurrence(event domain.Event) string {
+	if event.Recurrence == nil {
+		return ""
+	}
+	return event.Recurrence.String()
 }
 
-func (r *Repository) GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+// eventExceptions исключения серии в колонке exceptions (JSONB)
+type eventExceptions struct {
+	ExDates   []time.Time                 `json:"exdates,omitempty"`
+	Overrides []domain.OccurrenceOverride `json:"overrides,omitempty"`
+}
 
-	rows, err := r.DB.QueryContext(ctx, getEventsForMonthQuery, userID, start)
+func marshalExceptions(event domain.Event) (string, error) {
+	data, err := json.Marshal(eventExceptions{ExDates: event.ExDates, Overrides: event.Overrides})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events for month: %w", err)
+		return "", fmt.Errorf("failed to marshal exceptions: %w", err)
 	}
-	defer rows.Close()
+	return string(data), nil
+}
+
+// marshalReminders возвращает строку: []byte lib/pq передал бы как bytea, а не как JSON
+func marshalReminders(event domain.Event) (string, error) {
+	data, err := json.Marshal(eventReminders{
+		Offsets: event.ReminderOffsets,
+		Times:   event.ReminderTimes,
+		Channel: event.NotifyChannel,
+		Target:  event.NotifyTarget,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal reminders: %w", err)
+	}
+	return string(data), nil
+}
 
+// scanEvents читает события из результата запроса; колонки как в eventColumns
+func scanEvents(rows *sql.Rows) ([]domain.Event, error) {
 	var events []domain.Event
 	for rows.Next() {
-		var event domain.Event
-		if err := rows.Scan(&event.EventId, &event.UserId, &event.Date, &event.IsArchived, &event.Description); err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
 		}
 		events = append(events, event)
 	}
@@ -186,11 +368,44 @@ func (r *Repository) GetEventsForMonth(ctx context.Context, userID int64, start
 	return events, nil
 }
 
-func (r *Repository) ArchiveOldEvents(ctx context.Context) error {
-	_, err := r.DB.ExecContext(ctx, updateArchiveEventsQuery)
+// scanEvent читает одно событие; колонки как в eventColumns
+func scanEvent(row rowScanner) (domain.Event, error) {
+	var event domain.Event
+	var reminders, exceptions []byte
+	var rrule string
+	err := row.Scan(&event.EventId, &event.UserId, &event.Version, &event.Date, &event.End, &event.AllDay, &event.IsArchived, &event.Description,
+		&reminders, &rrule, &exceptions, &event.TimeZone, &event.ExternalUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Event{}, err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to archive old events: %w", err)
+		return domain.Event{}, fmt.Errorf("failed to scan event: %w", err)
 	}
-
-	return nil
+	if rrule != "" {
+		rule, err := domain.ParseRecurrenceRule(rrule)
+		if err != nil {
+			return domain.Event{}, fmt.Errorf("failed to parse rrule of event %d: %w", event.EventId, err)
+		}
+		event.Recurrence = rule
+	}
+	if event.AllDay {
+		// Плавающие даты не должны зависеть от часового пояса соединения
+		event.Date, event.End = event.Date.UTC(), event.End.UTC()
+	}
+	var settings eventReminders
+	if err := json.Unmarshal(reminders, &settings); err != nil {
+		return domain.Event{}, fmt.Errorf("failed to unmarshal reminders: %w", err)
+	}
+	event.ReminderOffsets = settings.Offsets
+	event.ReminderTimes = settings.Times
+	event.NotifyChannel = settings.Channel
+	event.NotifyTarget = settings.Target
+
+	var exc eventExceptions
+	if err := json.Unmarshal(exceptions, &exc); err != nil {
+		return domain.Event{}, fmt.Errorf("failed to unmarshal exceptions: %w", err)
+	}
+	event.ExDates = exc.ExDates
+	event.Overrides = exc.Overrides
+	return event, nil
 }
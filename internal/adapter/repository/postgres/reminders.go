@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+const (
+	reminderColumns = `r.reminder_id, r.event_id, r.user_id, r.remind_at, r.status, r.last_error, r.attempts, r.next_attempt_at,
+			  r.channel, r.target, COALESCE(r.event_date, e.date), e.description`
+
+	deletePendingRemindersQuery = `DELETE FROM reminders WHERE event_id = $1 AND status = 'pending'`
+	insertReminderQuery         = `INSERT INTO reminders (event_id, user_id, remind_at, status, channel, target, event_date)
+			  VALUES ($1, $2, $3, 'pending', $4, $5, $6)
+			  RETURNING reminder_id`
+	getPendingRemindersQuery = `SELECT ` + reminderColumns + `
+			  FROM reminders r
+			  JOIN events e ON e.event_id = r.event_id
+			  WHERE r.status = 'pending'
+			  ORDER BY COALESCE(r.next_attempt_at, r.remind_at)`
+	getDeadRemindersQuery = `SELECT ` + reminderColumns + `
+			  FROM reminders r
+			  JOIN events e ON e.event_id = r.event_id
+			  WHERE r.status = 'dead'
+			  ORDER BY r.updated_at DESC`
+	getReminderQuery = `SELECT ` + reminderColumns + `
+			  FROM reminders r
+			  JOIN events e ON e.event_id = r.event_id
+			  WHERE r.reminder_id = $1`
+	markReminderSentQuery = `UPDATE reminders
+			  SET status = 'sent', next_attempt_at = NULL, updated_at = NOW()
+			  WHERE reminder_id = $1 AND status = 'pending'`
+	markReminderFailedQuery = `UPDATE reminders
+			  SET status = 'failed', last_error = $2, updated_at = NOW()
+			  WHERE reminder_id = $1 AND status = 'pending'`
+	markReminderRetryQuery = `UPDATE reminders
+			  SET attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = NOW()
+			  WHERE reminder_id = $1 AND status = 'pending'`
+	markReminderDeadQuery = `UPDATE reminders
+			  SET status = 'dead', attempts = $2, next_attempt_at = NULL, last_error = $3, updated_at = NOW()
+			  WHERE reminder_id = $1 AND status = 'pending'`
+	redriveReminderQuery = `UPDATE reminders
+			  SET status = 'pending', attempts = 0, next_attempt_at = $2, updated_at = NOW()
+			  WHERE reminder_id = $1 AND status = 'dead'`
+)
+
+var (
+	_ port.ReminderRepository = (*Repository)(nil)
+)
+
+func (r *Repository) ReplacePendingReminders(ctx context.Context, eventId int64, reminders []domain.Reminder) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, deletePendingRemindersQuery, eventId); err != nil {
+		return fmt.Errorf("failed to delete pending reminders: %w", err)
+	}
+
+	for i := range reminders {
+		rem := &reminders[i]
+		err := tx.QueryRowContext(ctx, insertReminderQuery, eventId, rem.UserId, rem.RemindAt, rem.Channel, rem.Target, nullTime(rem.EventDate)).Scan(&rem.ReminderId)
+		if err != nil {
+			return fmt.Errorf("failed to create reminder: %w", err)
+		}
+		rem.EventId = eventId
+		rem.Status = domain.ReminderPending
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reminders: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeletePendingReminders(ctx context.Context, eventId int64) error {
+	if _, err := r.DB.ExecContext(ctx, deletePendingRemindersQuery, eventId); err != nil {
+		return fmt.Errorf("failed to delete pending reminders: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetPendingReminders(ctx context.Context) ([]domain.Reminder, error) {
+	return r.queryReminders(ctx, getPendingRemindersQuery)
+}
+
+func (r *Repository) GetDeadReminders(ctx context.Context) ([]domain.Reminder, error) {
+	return r.queryReminders(ctx, getDeadRemindersQuery)
+}
+
+func (r *Repository) MarkReminderSent(ctx context.Context, reminderId int64) error {
+	result, err := r.DB.ExecContext(ctx, markReminderSentQuery, reminderId)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return pendingReminderUpdated(result)
+}
+
+func (r *Repository) MarkReminderFailed(ctx context.Context, reminderId int64, reason string) error {
+	result, err := r.DB.ExecContext(ctx, markReminderFailedQuery, reminderId, reason)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder failed: %w", err)
+	}
+	return pendingReminderUpdated(result)
+}
+
+func (r *Repository) MarkReminderRetry(ctx context.Context, reminderId int64, attempts int, nextAttemptAt time.Time, reason string) error {
+	result, err := r.DB.ExecContext(ctx, markReminderRetryQuery, reminderId, attempts, nextAttemptAt, reason)
+	if err != nil {
+		return fmt.Errorf("failed to schedule reminder retry: %w", err)
+	}
+	return pendingReminderUpdated(result)
+}
+
+func (r *Repository) MarkReminderDead(ctx context.Context, reminderId int64, attempts int, reason string) error {
+	result, err := r.DB.ExecContext(ctx, markReminderDeadQuery, reminderId, attempts, reason)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder dead: %w", err)
+	}
+	return pendingReminderUpdated(result)
+}
+
+// pendingReminderUpdated проверяет, что запрос изменил напоминание в статусе pending
+func pendingReminderUpdated(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	// Напоминание успели заменить или удалить вместе с событием
+	if rowsAffected == 0 {
+		return domain.ErrReminderNotFound
+	}
+	return nil
+}
+
+func (r *Repository) RedriveReminder(ctx context.Context, reminderId int64, at time.Time) (domain.Reminder, error) {
+	result, err := r.DB.ExecContext(ctx, redriveReminderQuery, reminderId, at)
+	if err != nil {
+		return domain.Reminder{}, fmt.Errorf("failed to redrive reminder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return domain.Reminder{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.Reminder{}, domain.ErrReminderNotFound
+	}
+
+	rem, err := scanReminder(r.DB.QueryRowContext(ctx, getReminderQuery, reminderId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Reminder{}, domain.ErrReminderNotFound
+	}
+	return rem, err
+}
+
+func (r *Repository) queryReminders(ctx context.Context, query string) ([]domain.Reminder, error) {
+	rows, err := r.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []domain.Reminder
+	for rows.Next() {
+		rem, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, rem)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// nullTime нулевое время передаёт как NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// rowScanner общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanReminder читает напоминание; колонки как в reminderColumns
+func scanReminder(row rowScanner) (domain.Reminder, error) {
+	var rem domain.Reminder
+	var nextAttemptAt sql.NullTime
+	err := row.Scan(&rem.ReminderId, &rem.EventId, &rem.UserId, &rem.RemindAt, &rem.Status, &rem.LastError, &rem.Attempts,
+		&nextAttemptAt, &rem.Channel, &rem.Target, &rem.EventDate, &rem.Description)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Reminder{}, err
+	}
+	if err != nil {
+		return domain.Reminder{}, fmt.Errorf("failed to scan reminder: %w", err)
+	}
+	rem.NextAttemptAt = nextAttemptAt.Time
+	return rem, nil
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+const (
+	getUserSettingsQuery = `SELECT user_id, notify_channel, notify_target, time_zone
+			  FROM user_settings
+			  WHERE user_id = $1`
+	saveUserSettingsQuery = `INSERT INTO user_settings (user_id, notify_channel, notify_target, time_zone)
+			  VALUES ($1, $2, $3, $4)
+			  ON CONFLICT (user_id) DO UPDATE
+			  SET notify_channel = EXCLUDED.notify_channel, notify_target = EXCLUDED.notify_target,
+			      time_zone = EXCLUDED.time_zone, updated_at = NOW()`
+)
+
+var (
+	_ port.UserSettingsRepository = (*Repository)(nil)
+)
+
+func (r *Repository) GetUserSettings(ctx context.Context, userID int64) (domain.UserSettings, error) {
+	settings := domain.UserSettings{UserId: userID}
+	err := r.DB.QueryRowContext(ctx, getUserSettingsQuery, userID).Scan(&settings.UserId, &settings.NotifyChannel, &settings.NotifyTarget, &settings.TimeZone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return settings, nil
+	}
+	if err != nil {
+		return domain.UserSettings{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *Repository) SaveUserSettings(ctx context.Context, settings domain.UserSettings) error {
+	_, err := r.DB.ExecContext(ctx, saveUserSettingsQuery, settings.UserId, settings.NotifyChannel, settings.NotifyTarget, settings.TimeZone)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+	return nil
+}
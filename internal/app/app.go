@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"github.com/dontpanicw/calendar/cleaning_worker"
 	"github.com/dontpanicw/calendar/config"
+	"github.com/dontpanicw/calendar/internal/adapter/notifier"
 	"github.com/dontpanicw/calendar/internal/adapter/repository/postgres"
+	"github.com/dontpanicw/calendar/internal/domain"
 	"github.com/dontpanicw/calendar/internal/input/http/handlers"
+	"github.com/dontpanicw/calendar/internal/port"
 	"github.com/dontpanicw/calendar/internal/usecases"
 	"github.com/dontpanicw/calendar/log_worker"
 	"github.com/dontpanicw/calendar/notify_worker"
@@ -29,9 +32,6 @@ func Start(cfg *config.Config) error {
 	logger := log_worker.NewLogger()
 	go logger.Log(ctx)
 
-	notifyWorker := notify_worker.NewNotifyWorker()
-	go notifyWorker.Start(ctx)
-
 	// Retry подключения к PostgreSQL
 	var db *sql.DB
 	var err error
@@ -79,8 +79,27 @@ func Start(cfg *config.Config) error {
 	cleaningWorker := cleaning_worker.NewCleaningWorker(10, eventRepo)
 	go cleaningWorker.Start(ctx)
 
+	notifiers, err := newNotifiers(cfg)
+	if err != nil {
+		return err
+	}
+	notifyWorker := notify_worker.NewNotifyWorker(eventRepo, eventRepo, eventRepo, notifiers)
+	go notifyWorker.Start(ctx)
+
 	eventUsecase := usecases.NewUsecaseEvent(eventRepo, logger, notifyWorker)
-	srv := handlers.NewServer(eventUsecase, logger)
+	userUsecase := usecases.NewUsecaseUser(eventRepo)
+	reminderUsecase := usecases.NewUsecaseReminder(eventRepo, notifyWorker)
+	authUsecase := usecases.NewUsecaseAuth(eventRepo)
+	idempotencyUsecase := usecases.NewUsecaseIdempotency(eventRepo, cfg.IdempotencyTTL)
+	feedUsecase := usecases.NewUsecaseFeed(eventRepo, eventRepo)
+	authenticator, err := newAuthenticator(cfg, authUsecase)
+	if err != nil {
+		return err
+	}
+	if authenticator == nil {
+		logger.Write("Authentication is disabled, user_id is taken from requests")
+	}
+	srv := handlers.NewServer(eventUsecase, userUsecase, reminderUsecase, authUsecase, idempotencyUsecase, feedUsecase, authenticator, logger)
 
 	httpServer := &http.Server{
 		Addr:         cfg.HTTPPort,
@@ -112,3 +131,49 @@ func Start(cfg *config.Config) error {
 	logger.Write("Application stopped")
 	return nil
 }
+
+// newNotifiers собирает каналы доставки напоминаний; email доступен только при заданном SMTP_ADDR
+func newNotifiers(cfg *config.Config) (map[string]port.Notifier, error) {
+	fileNotifier, err := notifier.OpenFileNotifier(cfg.NotifyFile)
+	if err != nil {
+		return nil, err
+	}
+	notifiers := map[string]port.Notifier{
+		domain.ChannelLog:     fileNotifier,
+		domain.ChannelWebhook: notifier.NewWebhookNotifier(10 * time.Second),
+	}
+	if cfg.SMTPAddr != "" {
+		notifiers[domain.ChannelEmail] = notifier.NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+	return notifiers, nil
+}
+
+// newAuthenticator API-ключи принимаются всегда, JWT — если задан секрет HS256 или ключ RS256.
+// При AUTH_DISABLED возвращает nil.
+func newAuthenticator(cfg *config.Config, keys port.AuthUsecases) (*handlers.Authenticator, error) {
+	if cfg.AuthDisabled {
+		return nil, nil
+	}
+	jwtCfg := handlers.JWTConfig{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+	}
+	if cfg.JWTSecret != "" {
+		jwtCfg.HMACSecret = []byte(cfg.JWTSecret)
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt public key: %w", err)
+		}
+		if jwtCfg.RSAPublicKey, err = handlers.ParseRSAPublicKey(data); err != nil {
+			return nil, fmt.Errorf("failed to parse jwt public key: %w", err)
+		}
+	}
+
+	var verifier *handlers.JWTVerifier
+	if jwtCfg.HMACSecret != nil || jwtCfg.RSAPublicKey != nil {
+		verifier = handlers.NewJWTVerifier(jwtCfg)
+	}
+	return handlers.NewAuthenticator(keys, verifier), nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Principal пользователь, от имени которого выполняется запрос; определяется по API-ключу или JWT
+type Principal struct {
+	UserId int64
+	// Admin доступ к служебным эндпоинтам /admin
+	Admin bool
+}
+
+// APIKey ключ доступа к API. Сам ключ показывается один раз при создании, хранится только его хеш.
+type APIKey struct {
+	KeyId     int64     `json:"key_id"`
+	UserId    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"-"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var ErrAPIKeyNotFound = NewNotFoundError("api_key_not_found", "api key not found")
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Виды ошибок бизнес-логики; конкретная ошибка сравнивается с ними через errors.Is
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrValidation = errors.New("validation failed")
+	ErrConflict   = errors.New("conflict")
+	ErrForbidden  = errors.New("forbidden")
+	// ErrUnauthorized запрос без действительных учётных данных
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPrecondition не выполнено условие запроса, например объект изменился после чтения
+	ErrPrecondition = errors.New("precondition failed")
+	// ErrUnprocessable запрос корректен, но выполнить его нельзя
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// Error ошибка бизнес-логики с машиночитаемым кодом для API
+type Error struct {
+	// Kind один из Err* выше
+	Kind    error
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Is считает равными ошибки с одинаковым кодом, чтобы errors.Is(err, ErrEventNotFound)
+// срабатывал и для ошибок с уточнённым сообщением
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// NewValidationError ошибка входных данных
+func NewValidationError(code, format string, args ...any) error {
+	return &Error{Kind: ErrValidation, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewNotFoundError запрошенный объект не существует
+func NewNotFoundError(code, format string, args ...any) error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewConflictError операция противоречит текущему состоянию
+func NewConflictError(code, format string, args ...any) error {
+	return &Error{Kind: ErrConflict, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewForbiddenError нет прав на операцию
+func NewForbiddenError(code, format string, args ...any) error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewUnauthorizedError учётные данные отсутствуют или недействительны
+func NewUnauthorizedError(code, format string, args ...any) error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewPreconditionError условие запроса не выполнено
+func NewPreconditionError(code, format string, args ...any) error {
+	return &Error{Kind: ErrPrecondition, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewUnprocessableError запрос нельзя выполнить
+func NewUnprocessableError(code, format string, args ...any) error {
+	return &Error{Kind: ErrUnprocessable, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+var ErrEventNotFound = NewNotFoundError("event_not_found", "event not found")
+
+// ErrVersionMismatch событие изменили после того, как клиент его прочитал
+var ErrVersionMismatch = NewPreconditionError("version_mismatch", "event was modified, reload it and retry")
@@ -1,11 +1,200 @@
 package domain
 
-import "time"
+import (
+	"cmp"
+	"sort"
+	"time"
+)
+
+// DefaultEventDuration длительность события, если конец не указан
+const DefaultEventDuration = time.Hour
+
+// DefaultReminderOffset напоминание за час до начала, если при создании не указаны ни смещения, ни времена напоминаний
+const DefaultReminderOffset = Offset(time.Hour)
 
 type Event struct {
-	EventId     int64     `json:"event_id"`
-	UserId      int64     `json:"user_id"`
-	Date        time.Time `json:"date"`
-	IsArchived  bool      `json:"is_archived"`
-	Description string    `json:"description"`
+	EventId int64 `json:"event_id"`
+	UserId  int64 `json:"user_id"`
+	// Version растёт при каждом изменении события; при сохранении — ожидаемая версия, 0 — без проверки
+	Version int64 `json:"version"`
+	// Date начало события
+	Date time.Time `json:"date"`
+	// End конец события (не включительно); для события на весь день — начало следующего дня
+	End time.Time `json:"end"`
+	// AllDay событие на весь день: Date и End — «плавающие» даты, полночь UTC без привязки к поясу
+	AllDay      bool   `json:"all_day"`
+	IsArchived  bool   `json:"is_archived"`
+	Description string `json:"description"`
+	// ReminderOffsets за сколько до начала события прислать напоминания. Если при создании оба списка
+	// напоминаний nil, ставится DefaultReminderOffset; пустой список ([]) — без напоминаний.
+	ReminderOffsets []Offset `json:"reminder_offsets,omitempty"`
+	// ReminderTimes напоминания на конкретное время
+	ReminderTimes []time.Time `json:"reminder_times,omitempty"`
+	// NotifyChannel и NotifyTarget переопределяют настройки доставки пользователя для напоминаний этого события
+	NotifyChannel string `json:"notify_channel,omitempty"`
+	NotifyTarget  string `json:"notify_target,omitempty"`
+	// Recurrence правило повторения; Date и End задают первое повторение серии
+	Recurrence *RecurrenceRule `json:"rrule,omitempty"`
+	// TimeZone часовой пояс серии: повторения сохраняют местное время в нём при переходах на летнее время
+	TimeZone string `json:"time_zone,omitempty"`
+	// ExDates исходные начала отменённых повторений серии
+	ExDates []time.Time `json:"exdates,omitempty"`
+	// Overrides изменённые отдельные повторения серии
+	Overrides []OccurrenceOverride `json:"overrides,omitempty"`
+	// SeriesId и OriginalStart заполняются у повторений серии в выборках: id серии и исходное начало повторения
+	SeriesId      int64     `json:"series_id,omitempty"`
+	OriginalStart time.Time `json:"original_start,omitzero"`
+	// ExternalUID UID события в календаре, из которого оно импортировано; задаётся только при создании
+	ExternalUID string `json:"external_uid,omitempty"`
+}
+
+// Overlaps проверяет, пересекается ли событие с интервалом [from, to).
+// Событие без конца считается мгновенным и попадает в интервал, если начинается в нём.
+// Событие на весь день сравнивается по календарным датам интервала, без сдвига часового пояса.
+func (e Event) Overlaps(from, to time.Time) bool {
+	if e.AllDay {
+		from, to = FloatingRange(from, to)
+	}
+	if !e.End.After(e.Date) {
+		return !e.Date.Before(from) && e.Date.Before(to)
+	}
+	return e.Date.Before(to) && e.End.After(from)
+}
+
+// Occurrences возвращает повторения события, пересекающиеся с [from, to), с учётом исключений и переносов.
+// Для события без повторения — само событие, если оно пересекается с интервалом.
+func (e Event) Occurrences(from, to time.Time) []Event {
+	if e.Recurrence == nil {
+		if e.Overlaps(from, to) {
+			return []Event{e}
+		}
+		return nil
+	}
+	if e.AllDay {
+		from, to = FloatingRange(from, to)
+	}
+
+	var result []Event
+	for _, occ := range e.instances(to) {
+		if occ.Overlaps(from, to) {
+			result = append(result, occ)
+		}
+	}
+	// Повторения, перенесённые в интервал с более позднего времени
+	for _, o := range e.Overrides {
+		if o.OriginalStart.Before(to) || e.isExcluded(o.OriginalStart) || !e.IsOccurrence(o.OriginalStart) {
+			continue
+		}
+		if occ := e.instance(o.OriginalStart); occ.Overlaps(from, to) {
+			result = append(result, occ)
+		}
+	}
+	return result
+}
+
+// NextOccurrence ближайшее повторение серии, начинающееся после after
+func (e Event) NextOccurrence(after time.Time) (Event, bool) {
+	if e.Recurrence == nil {
+		return e, e.Date.After(after)
+	}
+	var next Event
+	found := false
+	for _, occ := range e.instances(after.AddDate(nextOccurrenceHorizon, 0, 0)) {
+		if occ.Date.After(after) && (!found || occ.Date.Before(next.Date)) {
+			next, found = occ, true
+		}
+	}
+	return next, found
+}
+
+// nextOccurrenceHorizon на сколько лет вперёд искать следующее повторение
+const nextOccurrenceHorizon = 5
+
+// IsOccurrence проверяет, что start — исходное начало одного из повторений серии
+func (e Event) IsOccurrence(start time.Time) bool {
+	if e.Recurrence == nil {
+		return start.Equal(e.Date)
+	}
+	starts := e.Recurrence.Starts(e.seriesStart(), start.Add(time.Nanosecond))
+	return len(starts) > 0 && starts[len(starts)-1].Equal(start)
+}
+
+// seriesStart начало серии в её часовом поясе; события на весь день не привязаны к поясу
+func (e Event) seriesStart() time.Time {
+	if e.AllDay || e.TimeZone == "" {
+		return e.Date
+	}
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return e.Date
+	}
+	return e.Date.In(loc)
+}
+
+// instances повторения серии без отменённых, с применёнными переносами, исходное начало которых раньше before
+func (e Event) instances(before time.Time) []Event {
+	var result []Event
+	for _, start := range e.Recurrence.Starts(e.seriesStart(), before) {
+		if !e.isExcluded(start) {
+			result = append(result, e.instance(start))
+		}
+	}
+	return result
+}
+
+// instance повторение серии с исходным началом start
+func (e Event) instance(start time.Time) Event {
+	occ := e
+	if !e.End.IsZero() {
+		occ.End = start.Add(e.End.Sub(e.Date))
+	}
+	occ.Date = start
+	occ.SeriesId = e.EventId
+	occ.OriginalStart = start
+	occ.ExDates = nil
+	occ.Overrides = nil
+	if o, ok := e.override(start); ok {
+		occ.Date, occ.End = o.Date, o.End
+		if o.Description != "" {
+			occ.Description = o.Description
+		}
+	}
+	return occ
+}
+
+// SortEvents упорядочивает события по началу, при равенстве — по id, затем по исходному началу повторения
+func SortEvents(events []Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return compareEvents(events[i], events[j]) < 0
+	})
+}
+
+// compareEvents порядок SortEvents: отрицательное, если a раньше b, 0 — одно и то же событие или повторение
+func compareEvents(a, b Event) int {
+	if c := a.Date.Compare(b.Date); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.EventId, b.EventId); c != 0 {
+		return c
+	}
+	return a.OriginalStart.Compare(b.OriginalStart)
+}
+
+// RemindAt возвращает все моменты напоминаний события в порядке возрастания, без повторов
+func (e Event) RemindAt() []time.Time {
+	times := make([]time.Time, 0, len(e.ReminderOffsets)+len(e.ReminderTimes))
+	for _, o := range e.ReminderOffsets {
+		times = append(times, e.Date.Add(-o.Duration()))
+	}
+	times = append(times, e.ReminderTimes...)
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	result := times[:0]
+	for i, t := range times {
+		if i > 0 && t.Equal(result[len(result)-1]) {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
 }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvent_Overlaps(t *testing.T) {
+	day := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	from, to := DayRange(day)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"inside", Event{Date: day.Add(14 * time.Hour), End: day.Add(15 * time.Hour)}, true},
+		{"starts before", Event{Date: day.Add(-time.Hour), End: day.Add(time.Hour)}, true},
+		{"ends at range start", Event{Date: day.Add(-time.Hour), End: day}, false},
+		{"starts at range end", Event{Date: to, End: to.Add(time.Hour)}, false},
+		{"covers range", Event{Date: day.AddDate(0, 0, -1), End: day.AddDate(0, 0, 2)}, true},
+		{"no end", Event{Date: day.Add(time.Hour)}, true},
+		{"no end outside", Event{Date: to}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.event.Overlaps(from, to); got != tt.want {
+			t.Errorf("%s: Overlaps = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDayRange(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	from, to := DayRange(time.Date(2026, 3, 15, 23, 30, 0, 0, loc))
+	if !from.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, loc)) || !to.Equal(time.Date(2026, 3, 16, 0, 0, 0, 0, loc)) {
+		t.Errorf("DayRange = %v - %v", from, to)
+	}
+}
+
+func TestEvent_Overlaps_AllDay(t *testing.T) {
+	// Событие на 15 марта — плавающая дата, без часового пояса
+	event := Event{
+		Date:   time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		AllDay: true,
+	}
+	msk := time.FixedZone("MSK", 3*60*60)
+	nyc := time.FixedZone("EST", -5*60*60)
+
+	for _, loc := range []*time.Location{time.UTC, msk, nyc} {
+		if !event.Overlaps(DayRange(time.Date(2026, 3, 15, 12, 0, 0, 0, loc))) {
+			t.Errorf("expected event on March 15 in %s", loc)
+		}
+		if event.Overlaps(DayRange(time.Date(2026, 3, 14, 12, 0, 0, 0, loc))) {
+			t.Errorf("expected no event on March 14 in %s", loc)
+		}
+		if event.Overlaps(DayRange(time.Date(2026, 3, 16, 12, 0, 0, 0, loc))) {
+			t.Errorf("expected no event on March 16 in %s", loc)
+		}
+	}
+}
+
+func TestFloatingRange(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	from, to := FloatingRange(time.Date(2026, 3, 15, 0, 0, 0, 0, msk), time.Date(2026, 3, 17, 10, 0, 0, 0, msk))
+	if !from.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("FloatingRange = %v - %v", from, to)
+	}
+}
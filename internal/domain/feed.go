@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// FeedToken секретный токен подписки на календарь пользователя (/feeds/{token}.ics).
+// Даёт только чтение ленты; у пользователя один токен, выпуск нового отзывает прежний.
+type FeedToken struct {
+	UserId    int64     `json:"user_id"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	// ContentHash и ModifiedAt хеш последней отданной ленты и время, когда она изменилась, — для ETag и Last-Modified
+	ContentHash string    `json:"-"`
+	ModifiedAt  time.Time `json:"-"`
+}
+
+// EventsMarker дешёвый признак изменения событий пользователя: создание увеличивает Count и MaxEventId,
+// изменение — VersionSum, удаление уменьшает Count. Лента перестраивается, только если он поменялся.
+type EventsMarker struct {
+	Count      int64
+	MaxEventId int64
+	VersionSum int64
+}
+
+var ErrFeedNotFound = NewNotFoundError("feed_not_found", "feed not found")
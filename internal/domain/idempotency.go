@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord запрос с заголовком Idempotency-Key и ответ на него.
+// Пока запрос выполняется, StatusCode равен 0.
+type IdempotencyRecord struct {
+	Key    string
+	UserId int64
+	// RequestHash хеш метода, пути и тела: по нему повтор отличается от другого запроса с тем же ключом
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed ответ на запрос уже сохранён
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+var (
+	ErrIdempotencyKeyReused  = NewUnprocessableError("idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = NewConflictError("idempotency_in_progress", "request with this idempotency key is still in progress")
+)
@@ -0,0 +1,14 @@
+package domain
+
+// ImportStatus итог импорта одного события из внешнего календаря
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportUpdated ImportStatus = "updated"
+	// ImportDeleted событие отменено в исходном календаре, импортированное ранее удалено
+	ImportDeleted ImportStatus = "deleted"
+	// ImportSkipped событие уже импортировано и не изменилось или отменено и не импортировалось
+	ImportSkipped ImportStatus = "skipped"
+	ImportFailed  ImportStatus = "failed"
+)
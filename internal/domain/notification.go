@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Каналы доставки напоминаний
+const (
+	ChannelLog     = "log"     // файл или stdout
+	ChannelWebhook = "webhook" // POST JSON на URL
+	ChannelEmail   = "email"   // письмо через SMTP
+)
+
+// IsValidChannel проверяет, что канал доставки известен; пустой канал означает «по умолчанию»
+func IsValidChannel(channel string) bool {
+	switch channel {
+	case "", ChannelLog, ChannelWebhook, ChannelEmail:
+		return true
+	}
+	return false
+}
+
+// ValidateNotifyTarget проверяет адрес доставки канала. URL вебхука — только https, без адресов
+// внутренней сети: вебхук вызывает сервер, и иначе через него можно достучаться до внутренних сервисов.
+// Пустой адрес допустим: тогда берётся адрес из настроек пользователя.
+func ValidateNotifyTarget(channel, target string) error {
+	if channel != ChannelWebhook || target == "" {
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return NewValidationError("invalid_notify_target", "webhook url must be an absolute https url")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return NewValidationError("invalid_notify_target", "webhook url must not point to an internal address")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddr(addr) {
+		return NewValidationError("invalid_notify_target", "webhook url must not point to an internal address")
+	}
+	return nil
+}
+
+// sharedAddressSpace 100.64.0.0/10 (RFC 6598), адреса за NAT провайдера
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr адрес не из локальной, частной, link-local (в том числе метаданных облака 169.254.169.254)
+// или служебной сети
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsUnspecified() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() && !sharedAddressSpace.Contains(addr)
+}
+
+// UserSettings настройки пользователя
+type UserSettings struct {
+	UserId int64 `json:"user_id"`
+	// NotifyChannel канал доставки напоминаний по умолчанию
+	NotifyChannel string `json:"notify_channel"`
+	// NotifyTarget адрес доставки: URL вебхука или email
+	NotifyTarget string `json:"notify_target"`
+	// TimeZone часовой пояс IANA (например, Europe/Moscow) для границ дня, недели и месяца; пустой — UTC
+	TimeZone string `json:"time_zone"`
+}
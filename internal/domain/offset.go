@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// Offset смещение напоминания до начала события. В JSON пишется строкой: "10m", "1h30m", "1d", "1w".
+type Offset time.Duration
+
+// ParseOffset разбирает смещение: поддерживает единицы time.ParseDuration и суффиксы d (дни), w (недели)
+func ParseOffset(s string) (Offset, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty reminder offset")
+	}
+
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = day
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * day
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid reminder offset %q", s)
+		}
+		return Offset(time.Duration(n) * unit), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid reminder offset %q", s)
+	}
+	return Offset(d), nil
+}
+
+func (o Offset) Duration() time.Duration {
+	return time.Duration(o)
+}
+
+// String возвращает каноничную запись: "1d" для целых суток, иначе "1h30m" без нулевых частей
+func (o Offset) String() string {
+	d := time.Duration(o)
+	if d == 0 {
+		return "0m"
+	}
+	if d%day == 0 {
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	}
+
+	var b strings.Builder
+	if h := d / time.Hour; h > 0 {
+		b.WriteString(strconv.FormatInt(int64(h), 10) + "h")
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		b.WriteString(strconv.FormatInt(int64(m), 10) + "m")
+		d -= m * time.Minute
+	}
+	if d > 0 {
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+func (o Offset) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *Offset) UnmarshalText(text []byte) error {
+	parsed, err := ParseOffset(string(text))
+	if err != nil {
+		return err
+	}
+	*o = parsed
+	return nil
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		str  string
+	}{
+		{"10m", 10 * time.Minute, "10m"},
+		{"1h", time.Hour, "1h"},
+		{"1h30m", 90 * time.Minute, "1h30m"},
+		{"1d", 24 * time.Hour, "1d"},
+		{"2w", 14 * 24 * time.Hour, "14d"},
+		{"36h", 36 * time.Hour, "36h"},
+	}
+	for _, tt := range tests {
+		got, err := ParseOffset(tt.in)
+		if err != nil {
+			t.Fatalf("ParseOffset(%q): %v", tt.in, err)
+		}
+		if got.Duration() != tt.want {
+			t.Errorf("ParseOffset(%q) = %v, want %v", tt.in, got.Duration(), tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("Offset(%v).String() = %q, want %q", tt.want, got.String(), tt.str)
+		}
+	}
+}
+
+func TestParseOffset_Invalid(t *testing.T) {
+	for _, in := range []string{"", "abc", "-1h", "1.5d", "-2d"} {
+		if _, err := ParseOffset(in); err == nil {
+			t.Errorf("ParseOffset(%q): expected error", in)
+		}
+	}
+}
+
+func TestEvent_RemindAt(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	e := Event{
+		Date:            start,
+		ReminderOffsets: []Offset{Offset(time.Hour), Offset(24 * time.Hour)},
+		ReminderTimes:   []time.Time{start.Add(-time.Hour)},
+	}
+	got := e.RemindAt()
+	if len(got) != 2 || !got[0].Equal(start.Add(-24*time.Hour)) || !got[1].Equal(start.Add(-time.Hour)) {
+		t.Errorf("unexpected remind times: %v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+// Patched поле частичного изменения: Set — поле есть в патче. null в патче даёт Set и нулевое Value.
+type Patched[T any] struct {
+	Set   bool
+	Value T
+}
+
+// EventPatch частичное изменение события по RFC 7396 (JSON Merge Patch): меняются только заданные поля
+type EventPatch struct {
+	Date Patched[time.Time]
+	// End нулевое значение — конец по умолчанию
+	End             Patched[time.Time]
+	AllDay          Patched[bool]
+	Description     Patched[string]
+	ReminderOffsets Patched[[]Offset]
+	ReminderTimes   Patched[[]time.Time]
+	NotifyChannel   Patched[string]
+	NotifyTarget    Patched[string]
+	// Recurrence nil — повторение снимается
+	Recurrence Patched[*RecurrenceRule]
+	TimeZone   Patched[string]
+}
+
+// Apply возвращает событие с применённым патчем. Перенос начала без нового конца сохраняет длительность;
+// смена all_day без нового конца сбрасывает конец на значение по умолчанию.
+func (p EventPatch) Apply(event Event) Event {
+	duration := event.End.Sub(event.Date)
+	allDayChanged := p.AllDay.Set && p.AllDay.Value != event.AllDay
+
+	if p.AllDay.Set {
+		event.AllDay = p.AllDay.Value
+	}
+	if p.Date.Set {
+		event.Date = p.Date.Value
+	}
+	switch {
+	case p.End.Set:
+		event.End = p.End.Value
+	case allDayChanged:
+		event.End = time.Time{}
+	case p.Date.Set && duration > 0:
+		event.End = event.Date.Add(duration)
+	}
+
+	if p.Description.Set {
+		event.Description = p.Description.Value
+	}
+	if p.ReminderOffsets.Set {
+		event.ReminderOffsets = p.ReminderOffsets.Value
+	}
+	if p.ReminderTimes.Set {
+		event.ReminderTimes = p.ReminderTimes.Value
+	}
+	if p.NotifyChannel.Set {
+		event.NotifyChannel = p.NotifyChannel.Value
+	}
+	if p.NotifyTarget.Set {
+		event.NotifyTarget = p.NotifyTarget.Value
+	}
+	if p.Recurrence.Set {
+		event.Recurrence = p.Recurrence.Value
+		if event.Recurrence == nil {
+			event.ExDates, event.Overrides = nil, nil
+		}
+	}
+	if p.TimeZone.Set {
+		event.TimeZone = p.TimeZone.Value
+	}
+	return event
+}
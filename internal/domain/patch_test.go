@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventPatch_Apply(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	event := Event{EventId: 1, Date: start, End: start.Add(90 * time.Minute), Description: "Review", NotifyChannel: ChannelLog}
+
+	// Только описание: время и канал не меняются
+	got := EventPatch{Description: Patched[string]{Set: true, Value: "Design review"}}.Apply(event)
+	if got.Description != "Design review" || !got.Date.Equal(event.Date) || !got.End.Equal(event.End) || got.NotifyChannel != ChannelLog {
+		t.Errorf("unexpected event %+v", got)
+	}
+
+	// Перенос начала сохраняет длительность
+	moved := start.AddDate(0, 0, 1)
+	got = EventPatch{Date: Patched[time.Time]{Set: true, Value: moved}}.Apply(event)
+	if !got.End.Equal(moved.Add(90 * time.Minute)) {
+		t.Errorf("expected duration kept, end %v", got.End)
+	}
+
+	// null сбрасывает поле
+	got = EventPatch{NotifyChannel: Patched[string]{Set: true}}.Apply(event)
+	if got.NotifyChannel != "" {
+		t.Errorf("expected channel removed, got %q", got.NotifyChannel)
+	}
+
+	// Смена all_day без конца сбрасывает конец на значение по умолчанию
+	got = EventPatch{AllDay: Patched[bool]{Set: true, Value: true}}.Apply(event)
+	if !got.AllDay || !got.End.IsZero() {
+		t.Errorf("expected all-day event with default end, got %+v", got)
+	}
+}
+
+func TestEventPatch_RemoveRecurrence(t *testing.T) {
+	event := weeklyStandup(t, "FREQ=WEEKLY")
+	event.Exclude(time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC))
+
+	got := EventPatch{Recurrence: Patched[*RecurrenceRule]{Set: true}}.Apply(event)
+	if got.Recurrence != nil || got.ExDates != nil {
+		t.Errorf("expected recurrence and exceptions removed, got %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SortOrder string
+
+const (
+	SortAsc  SortOrder = "asc"
+	SortDesc SortOrder = "desc"
+)
+
+// EventQuery выборка событий пользователя, пересекающихся с [From, To)
+type EventQuery struct {
+	UserId int64
+	// AllUsers события всех пользователей, UserId не учитывается; только для администраторов
+	AllUsers        bool
+	From            time.Time
+	To              time.Time
+	IncludeArchived bool
+	// OnlyArchived только архивные события
+	OnlyArchived bool
+	// Text подстрока описания без учёта регистра; пусто — без фильтра
+	Text string
+	// Sort порядок по началу события; пусто — SortAsc
+	Sort SortOrder
+	// Limit размер страницы; 0 — все события
+	Limit int
+	// After курсор из EventPage.Next: страница начинается со следующего за ним события
+	After *EventCursor
+}
+
+// EventPage страница выборки. Next nil — страница последняя.
+type EventPage struct {
+	Events []Event
+	Next   *EventCursor
+}
+
+// EventCursor положение события в выборке: начало, id и исходное начало повторения. У повторений серии id
+// общий, а перенесённые повторения могут совпасть по началу, поэтому различаются по OriginalStart.
+type EventCursor struct {
+	Start         time.Time
+	EventId       int64
+	OriginalStart time.Time
+	// Filter отпечаток выборки, из которой получен курсор (EventQuery.Filter)
+	Filter string
+}
+
+// String непрозрачный токен страницы для клиента
+func (c EventCursor) String() string {
+	raw := fmt.Sprintf("%d:%d:%d:%s", c.Start.UnixNano(), c.EventId, unixNano(c.OriginalStart), c.Filter)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseEventCursor разбирает токен, полученный из EventCursor.String
+func ParseEventCursor(token string) (EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return EventCursor{}, errInvalidPageToken()
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[3] == "" {
+		return EventCursor{}, errInvalidPageToken()
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return EventCursor{}, errInvalidPageToken()
+	}
+	eventId, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || eventId <= 0 {
+		return EventCursor{}, errInvalidPageToken()
+	}
+	original, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return EventCursor{}, errInvalidPageToken()
+	}
+	cursor := EventCursor{Start: time.Unix(0, nanos).UTC(), EventId: eventId, Filter: parts[3]}
+	if original != 0 {
+		cursor.OriginalStart = time.Unix(0, original).UTC()
+	}
+	return cursor, nil
+}
+
+func errInvalidPageToken() error {
+	return NewValidationError("invalid_page_token", "invalid page token")
+}
+
+// unixNano время в наносекундах; нулевое время (у события не из серии нет OriginalStart) — 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Filter отпечаток условий выборки без курсора и размера страницы: токен страницы
+// подходит только к той выборке, из которой получен
+func (q EventQuery) Filter() string {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d|%t|%d|%d|%t|%t|%s|%s", q.UserId, q.AllUsers, q.From.UnixNano(), q.To.UnixNano(),
+		q.IncludeArchived, q.OnlyArchived, q.Sort, q.Text)
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// CheckCursor проверяет, что курсор q.After получен из этой же выборки
+func (q EventQuery) CheckCursor() error {
+	if q.After != nil && q.After.Filter != q.Filter() {
+		return errInvalidPageToken()
+	}
+	return nil
+}
+
+// Matches событие (или повторение) проходит фильтры выборки, кроме интервала
+func (q EventQuery) Matches(e Event) bool {
+	if e.IsArchived && !q.IncludeArchived && !q.OnlyArchived || !e.IsArchived && q.OnlyArchived {
+		return false
+	}
+	return q.Text == "" || strings.Contains(strings.ToLower(e.Description), strings.ToLower(q.Text))
+}
+
+// Includes событие попадает в выборку: для серии — хотя бы одно её повторение
+func (q EventQuery) Includes(e Event) bool {
+	for _, occ := range e.Occurrences(q.From, q.To) {
+		if q.Matches(occ) {
+			return true
+		}
+	}
+	return false
+}
+
+// Page отбирает из событий, пересекающихся с интервалом выборки, одну страницу
+func (q EventQuery) Page(events []Event) EventPage {
+	var matched []Event
+	for _, e := range events {
+		if q.Matches(e) && (q.After == nil || q.after(e)) {
+			matched = append(matched, e)
+		}
+	}
+	SortEvents(matched)
+	if q.Sort == SortDesc {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+
+	page := EventPage{Events: matched}
+	if q.Limit > 0 && len(matched) > q.Limit {
+		page.Events = matched[:q.Limit]
+		last := page.Events[q.Limit-1]
+		page.Next = &EventCursor{Start: last.Date, EventId: last.EventId, OriginalStart: last.OriginalStart, Filter: q.Filter()}
+	}
+	return page
+}
+
+// after событие идёт в порядке выборки после курсора q.After
+func (q EventQuery) after(e Event) bool {
+	c := q.After
+	cmp := compareEvents(e, Event{Date: c.Start, EventId: c.EventId, OriginalStart: c.OriginalStart})
+	if q.Sort == SortDesc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
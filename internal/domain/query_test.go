@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventCursor_Token(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	for _, cursor := range []EventCursor{
+		{Start: start, EventId: 42, Filter: "abc"},
+		{Start: start, EventId: 42, OriginalStart: start.AddDate(0, 0, 1), Filter: "abc"},
+	} {
+		parsed, err := ParseEventCursor(cursor.String())
+		if err != nil {
+			t.Fatalf("ParseEventCursor: %v", err)
+		}
+		if !parsed.Start.Equal(cursor.Start) || parsed.EventId != 42 || !parsed.OriginalStart.Equal(cursor.OriginalStart) ||
+			parsed.OriginalStart.IsZero() != cursor.OriginalStart.IsZero() || parsed.Filter != "abc" {
+			t.Errorf("expected %+v, got %+v", cursor, parsed)
+		}
+	}
+	for _, bad := range []string{"", "garbage", "MTp4", "MTo0Mg"} {
+		if _, err := ParseEventCursor(bad); !errors.Is(err, ErrValidation) {
+			t.Errorf("%q: expected validation error, got %v", bad, err)
+		}
+	}
+}
+
+func TestEventQuery_PageDesc(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{EventId: 1, Date: start},
+		{EventId: 2, Date: start},
+		{EventId: 3, Date: start.Add(time.Hour)},
+	}
+	query := EventQuery{Sort: SortDesc, Limit: 2}
+	page := query.Page(events)
+	if len(page.Events) != 2 || page.Events[0].EventId != 3 || page.Events[1].EventId != 2 || page.Next == nil {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	query.After = page.Next
+	page = query.Page(events)
+	if len(page.Events) != 1 || page.Events[0].EventId != 1 || page.Next != nil {
+		t.Errorf("unexpected last page %+v", page)
+	}
+}
+
+// TestEventQuery_PageSameStart два повторения серии, перенесённые на одно время, не теряются и не повторяются между страницами
+func TestEventQuery_PageSameStart(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{EventId: 1, SeriesId: 1, Date: start, OriginalStart: start.AddDate(0, 0, 1)},
+		{EventId: 1, SeriesId: 1, Date: start, OriginalStart: start},
+		{EventId: 2, Date: start},
+	}
+	for _, sort := range []SortOrder{SortAsc, SortDesc} {
+		query := EventQuery{Sort: sort, Limit: 1}
+		var got []string
+		for {
+			page := query.Page(events)
+			for _, e := range page.Events {
+				got = append(got, fmt.Sprintf("%d/%s", e.EventId, e.OriginalStart.Format("02")))
+			}
+			if page.Next == nil {
+				break
+			}
+			query.After = page.Next
+		}
+		want := "1/15, 1/16, 2/01"
+		if sort == SortDesc {
+			want = "2/01, 1/16, 1/15"
+		}
+		if strings.Join(got, ", ") != want {
+			t.Errorf("%s: expected %s, got %s", sort, want, strings.Join(got, ", "))
+		}
+	}
+}
+
+func TestEventQuery_CheckCursor(t *testing.T) {
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{{EventId: 1, Date: start}, {EventId: 2, Date: start}}
+	query := EventQuery{UserId: 1, From: start, To: start.AddDate(0, 0, 1), Limit: 1}
+	next := query.Page(events).Next
+	if next == nil {
+		t.Fatal("expected next page")
+	}
+
+	query.After, query.Limit = next, 50
+	if err := query.CheckCursor(); err != nil {
+		t.Errorf("same query with another limit: unexpected error %v", err)
+	}
+	for _, other := range []EventQuery{
+		{UserId: 2, From: query.From, To: query.To},
+		{UserId: 1, From: query.From, To: query.To, Text: "x"},
+		{UserId: 1, From: query.From, To: query.To, Sort: SortDesc},
+		{UserId: 1, From: query.From, To: query.To.Add(time.Hour)},
+		{UserId: 1, From: query.From, To: query.To, IncludeArchived: true},
+	} {
+		other.After = next
+		if err := other.CheckCursor(); !errors.Is(err, ErrValidation) {
+			t.Errorf("%+v: expected validation error, got %v", other, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayRange интервал [начало дня, начало следующего дня) в часовом поясе date
+func DayRange(date time.Time) (time.Time, time.Time) {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 0, 1)
+}
+
+// WeekRange интервал в 7 календарных дней от start
+func WeekRange(start time.Time) (time.Time, time.Time) {
+	return start, start.AddDate(0, 0, 7)
+}
+
+// MonthRange интервал в один календарный месяц от start
+func MonthRange(start time.Time) (time.Time, time.Time) {
+	return start, start.AddDate(0, 1, 0)
+}
+
+// FloatingDate календарная дата t в её часовом поясе как полночь UTC — так хранятся события на весь день
+func FloatingDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FloatingRange переводит интервал [from, to) в плавающие даты: день, который to задевает частично,
+// входит в интервал целиком
+func FloatingRange(from, to time.Time) (time.Time, time.Time) {
+	floatingTo := FloatingDate(to)
+	if !to.Equal(time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location())) {
+		floatingTo = floatingTo.AddDate(0, 0, 1)
+	}
+	return FloatingDate(from), floatingTo
+}
+
+// LoadLocation часовой пояс по имени IANA; пустое имя — UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
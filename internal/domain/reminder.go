@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+)
+
+// ReminderStatus состояние напоминания в очереди
+type ReminderStatus string
+
+const (
+	ReminderPending ReminderStatus = "pending"
+	ReminderSent    ReminderStatus = "sent"
+	ReminderFailed  ReminderStatus = "failed"
+	// ReminderDead исчерпаны попытки доставки, напоминание в dead-letter очереди
+	ReminderDead ReminderStatus = "dead"
+)
+
+var ErrReminderNotFound = NewNotFoundError("reminder_not_found", "reminder not found")
+
+// Reminder напоминание о событии. EventDate и Description — данные события на момент чтения,
+// нужны для текста уведомления.
+type Reminder struct {
+	ReminderId int64          `json:"reminder_id"`
+	EventId    int64          `json:"event_id"`
+	UserId     int64          `json:"user_id"`
+	RemindAt   time.Time      `json:"remind_at"`
+	Status     ReminderStatus `json:"status"`
+	LastError  string         `json:"last_error,omitempty"`
+	// Attempts число неудачных попыток доставки
+	Attempts int `json:"attempts"`
+	// NextAttemptAt время следующей попытки после неудачи; нулевое — доставка в RemindAt
+	NextAttemptAt time.Time `json:"next_attempt_at,omitzero"`
+	// Channel и Target пустые, если используются настройки пользователя
+	Channel     string    `json:"channel,omitempty"`
+	Target      string    `json:"target,omitempty"`
+	EventDate   time.Time `json:"event_date"`
+	Description string    `json:"description"`
+}
+
+// FireAt момент, когда напоминание должно быть отправлено с учётом повторных попыток
+func (r Reminder) FireAt() time.Time {
+	if !r.NextAttemptAt.IsZero() {
+		return r.NextAttemptAt
+	}
+	return r.RemindAt
+}
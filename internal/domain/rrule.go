@@ -0,0 +1,403 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency частота повторения по RFC 5545
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+// maxRecurrencePeriods ограничивает перебор периодов при разворачивании правила
+const maxRecurrencePeriods = 100000
+
+const untilLayout = "20060102T150405Z"
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum день недели из BYDAY; N — номер в месяце (1 — первый, -1 — последний), 0 — каждый
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Day.String()[:2])
+	if w.N == 0 {
+		return code
+	}
+	return strconv.Itoa(w.N) + code
+}
+
+// RecurrenceRule правило повторения (подмножество RRULE: FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT, UNTIL)
+type RecurrenceRule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	// Count число повторений, включая первое; 0 — без ограничения
+	Count int
+	// Until последнее возможное начало повторения включительно; нулевое — без ограничения
+	Until time.Time
+}
+
+// ParseRecurrenceRule разбирает правило вида "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", префикс "RRULE:" допускается
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "WKST":
+			// Недели всегда начинаются с понедельника
+			if strings.ToUpper(value) != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule %s: %w", strings.ToUpper(key), err)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func parseUntil(s string) (time.Time, error) {
+	if t, err := time.Parse(untilLayout, s); err == nil {
+		return t, nil
+	}
+	// Дата без времени — повторения до конца этого дня
+	t, err := time.Parse("20060102", s)
+	if err != nil {
+		return time.Time{}, errors.New("use YYYYMMDD or YYYYMMDDTHHMMSSZ")
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func parseByDay(s string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToUpper(item)
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", item)
+		}
+		var n int
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid weekday %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+func parseByMonthDay(s string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(s, ",") {
+		d, err := strconv.Atoi(item)
+		if err != nil || d == 0 || d < -31 || d > 31 {
+			return nil, fmt.Errorf("invalid month day %q", item)
+		}
+		days = append(days, d)
+	}
+	return days, nil
+}
+
+// Validate проверяет согласованность правила
+func (r RecurrenceRule) Validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	case "":
+		return errors.New("recurrence rule FREQ is required")
+	default:
+		return fmt.Errorf("unsupported recurrence frequency %q", r.Freq)
+	}
+	if r.Interval < 1 {
+		return errors.New("recurrence rule INTERVAL must be positive")
+	}
+	if r.Count < 0 {
+		return errors.New("recurrence rule COUNT must not be negative")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("recurrence rule must not contain both COUNT and UNTIL")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != FreqMonthly {
+			return errors.New("numbered BYDAY is supported only with FREQ=MONTHLY")
+		}
+	}
+	if len(r.ByDay) > 0 && r.Freq == FreqYearly {
+		return errors.New("BYDAY is not supported with FREQ=YEARLY")
+	}
+	return nil
+}
+
+// String возвращает правило в формате RRULE без префикса
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = d.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (r RecurrenceRule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *RecurrenceRule) UnmarshalText(text []byte) error {
+	parsed, err := ParseRecurrenceRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// Starts возвращает начала повторений, начиная с dtstart и раньше before.
+// Повторения строятся по местному времени dtstart, поэтому переходы на летнее время не сдвигают их.
+func (r RecurrenceRule) Starts(dtstart, before time.Time) []time.Time {
+	var starts []time.Time
+	count := 0
+	for k := 0; k < maxRecurrencePeriods; k++ {
+		periodStart, candidates := r.period(dtstart, k)
+		if !periodStart.Before(before) {
+			break
+		}
+		for _, c := range candidates {
+			if c.Before(dtstart) {
+				continue
+			}
+			if !c.Before(before) || (!r.Until.IsZero() && c.After(r.Until)) {
+				return starts
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return starts
+			}
+			starts = append(starts, c)
+		}
+	}
+	return starts
+}
+
+// period возвращает начало k-го периода правила и кандидатов на повторение в нём по возрастанию
+func (r RecurrenceRule) period(dtstart time.Time, k int) (time.Time, []time.Time) {
+	loc := dtstart.Location()
+	hour, minute, sec := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, sec, dtstart.Nanosecond(), loc)
+	}
+	year, month, day := dtstart.Date()
+	step := k * r.Interval
+
+	switch r.Freq {
+	case FreqDaily:
+		t := at(year, month, day+step)
+		if !r.matchesDay(t) {
+			return t, nil
+		}
+		return t, []time.Time{t}
+
+	case FreqWeekly:
+		// Неделя начинается с понедельника
+		monday := day - (int(dtstart.Weekday())+6)%7 + 7*step
+		weekdays := []WeekdayNum{{Day: dtstart.Weekday()}}
+		if len(r.ByDay) > 0 {
+			weekdays = r.ByDay
+		}
+		var days []time.Time
+		for _, wd := range weekdays {
+			t := at(year, month, monday+(int(wd.Day)+6)%7)
+			if r.matchesMonthDay(t) {
+				days = append(days, t)
+			}
+		}
+		return at(year, month, monday), sortUnique(days)
+
+	case FreqMonthly:
+		first := at(year, month+time.Month(step), 1)
+		var days []time.Time
+		for _, d := range r.monthDays(first.Year(), first.Month(), day) {
+			days = append(days, at(first.Year(), first.Month(), d))
+		}
+		return first, days
+
+	case FreqYearly:
+		// BYMONTH не поддерживается, поэтому BYMONTHDAY без него раскрывается по всем месяцам (RFC 5545),
+		// а без BYMONTHDAY повторение приходится на месяц начала серии
+		months := []time.Month{month}
+		first := at(year+step, month, 1)
+		if len(r.ByMonthDay) > 0 {
+			months = months[:0]
+			for m := time.January; m <= time.December; m++ {
+				months = append(months, m)
+			}
+			first = at(year+step, time.January, 1)
+		}
+		var days []time.Time
+		for _, m := range months {
+			for _, d := range r.monthDays(first.Year(), m, day) {
+				days = append(days, at(first.Year(), m, d))
+			}
+		}
+		return first, days
+	}
+	return dtstart, nil
+}
+
+// monthDays дни месяца по BYMONTHDAY и BYDAY (их пересечение, если заданы оба), иначе день начала серии
+func (r RecurrenceRule) monthDays(year int, month time.Month, defaultDay int) []int {
+	dim := daysIn(year, month)
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if defaultDay > dim {
+			return nil
+		}
+		return []int{defaultDay}
+	}
+
+	var days []int
+	for d := 1; d <= dim; d++ {
+		t := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+		if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(t) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !r.matchesWeekdayInMonth(t, dim) {
+			continue
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+func (r RecurrenceRule) matchesDay(t time.Time) bool {
+	if !r.matchesMonthDay(t) {
+		return false
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RecurrenceRule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	dim := daysIn(t.Year(), t.Month())
+	for _, md := range r.ByMonthDay {
+		if md == t.Day() || (md < 0 && dim+md+1 == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RecurrenceRule) matchesWeekdayInMonth(t time.Time, dim int) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day != t.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0:
+			return true
+		case wd.N > 0 && (t.Day()-1)/7+1 == wd.N:
+			return true
+		case wd.N < 0 && (dim-t.Day())/7+1 == -wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func sortUnique(times []time.Time) []time.Time {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	result := times[:0]
+	for i, t := range times {
+		if i > 0 && t.Equal(result[len(result)-1]) {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"freq=monthly;byday=-1fr;count=3", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231T235959Z", "FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20261231T235959Z"},
+		{"FREQ=YEARLY;INTERVAL=1", "FREQ=YEARLY"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.in)
+		if err != nil {
+			t.Fatalf("ParseRecurrenceRule(%q): %v", tt.in, err)
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRecurrenceRule(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecurrenceRule_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;BYHOUR=10",
+	} {
+		if _, err := ParseRecurrenceRule(in); err == nil {
+			t.Errorf("ParseRecurrenceRule(%q): expected error", in)
+		}
+	}
+}
+
+func mustRule(t *testing.T, s string) *RecurrenceRule {
+	t.Helper()
+	rule, err := ParseRecurrenceRule(s)
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule(%q): %v", s, err)
+	}
+	return rule
+}
+
+func dates(times []time.Time) []string {
+	result := make([]string, len(times))
+	for i, t := range times {
+		result[i] = t.Format("2006-01-02")
+	}
+	return result
+}
+
+func TestRecurrenceRule_Starts(t *testing.T) {
+	// 2026-03-02 — понедельник
+	dtstart := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule string
+		want []string
+	}{
+		{"FREQ=DAILY;COUNT=3", []string{"2026-03-02", "2026-03-03", "2026-03-04"}},
+		{"FREQ=DAILY;INTERVAL=10;UNTIL=20260322", []string{"2026-03-02", "2026-03-12", "2026-03-22"}},
+		{"FREQ=WEEKLY;BYDAY=MO,FR;COUNT=4", []string{"2026-03-02", "2026-03-06", "2026-03-09", "2026-03-13"}},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=3", []string{"2026-03-02", "2026-03-16", "2026-03-30"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", []string{"2026-03-27", "2026-04-24", "2026-05-29"}},
+		{"FREQ=MONTHLY;BYDAY=1MO,3MO;COUNT=4", []string{"2026-03-02", "2026-03-16", "2026-04-06", "2026-04-20"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", []string{"2026-03-31", "2026-04-30", "2026-05-31"}},
+		{"FREQ=YEARLY;COUNT=2", []string{"2026-03-02"}},
+		{"FREQ=YEARLY;BYMONTHDAY=1,-1", []string{"2026-03-31", "2026-04-01", "2026-04-30", "2026-05-01", "2026-05-31"}},
+	}
+	for _, tt := range tests {
+		got := dates(mustRule(t, tt.rule).Starts(dtstart, before))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.rule, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.rule, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRecurrenceRule_Starts_SkipsMissingDays(t *testing.T) {
+	// 31-е число есть не в каждом месяце
+	dtstart := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	got := dates(mustRule(t, "FREQ=MONTHLY;COUNT=3").Starts(dtstart, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)))
+	want := []string{"2026-01-31", "2026-03-31", "2026-05-31"}
+	if len(got) != 3 || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecurrenceRule_Starts_YearlyByMonthDay(t *testing.T) {
+	// Без BYMONTH день месяца повторяется в каждом месяце, а не только в месяце начала серии
+	dtstart := time.Date(2026, 11, 15, 9, 0, 0, 0, time.UTC)
+	got := dates(mustRule(t, "FREQ=YEARLY;INTERVAL=2;BYMONTHDAY=15;COUNT=4").Starts(dtstart, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+	want := []string{"2026-11-15", "2026-12-15", "2028-01-15", "2028-02-15"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRecurrenceRule_Starts_KeepsLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// Переход на летнее время 8 марта 2026
+	dtstart := time.Date(2026, 3, 6, 9, 0, 0, 0, loc)
+	starts := mustRule(t, "FREQ=DAILY;COUNT=4").Starts(dtstart, dtstart.AddDate(0, 1, 0))
+	for _, s := range starts {
+		if s.Hour() != 9 {
+			t.Errorf("expected 09:00 local, got %v", s)
+		}
+	}
+}
+
+func TestEvent_Occurrences(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	event := Event{
+		EventId:    7,
+		Date:       start,
+		End:        start.Add(30 * time.Minute),
+		Recurrence: mustRule(t, "FREQ=WEEKLY;BYDAY=MO,WE"),
+	}
+
+	from, to := WeekRange(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC))
+	occurrences := event.Occurrences(from, to)
+	if len(occurrences) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d", len(occurrences))
+	}
+	occ := occurrences[1]
+	want := time.Date(2026, 3, 11, 10, 0, 0, 0, time.UTC)
+	if occ.SeriesId != 7 || !occ.OriginalStart.Equal(want) || !occ.Date.Equal(want) || occ.End.Sub(occ.Date) != 30*time.Minute {
+		t.Errorf("unexpected occurrence %+v", occ)
+	}
+
+	next, ok := event.NextOccurrence(time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC))
+	if !ok || !next.Date.Equal(time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextOccurrence = %v, %v", next.Date, ok)
+	}
+}
+
+func TestEvent_Occurrences_SeriesTimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	// Серия хранится в UTC, но разворачивается в поясе серии
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, newYork).UTC()
+	event := Event{Date: start, End: start.Add(time.Hour), TimeZone: "America/New_York", Recurrence: mustRule(t, "FREQ=WEEKLY")}
+
+	occurrences := event.Occurrences(time.Date(2026, 3, 1, 0, 0, 0, 0, newYork), time.Date(2026, 4, 1, 0, 0, 0, 0, newYork))
+	if len(occurrences) != 5 {
+		t.Fatalf("expected 5 occurrences, got %d", len(occurrences))
+	}
+	for _, occ := range occurrences {
+		if local := occ.Date.In(newYork); local.Hour() != 9 {
+			t.Errorf("expected 09:00 New York time, got %v", local)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EditScope какие повторения серии затрагивает изменение или удаление
+type EditScope string
+
+const (
+	// ScopeAll вся серия; для событий без повторения — единственный вариант
+	ScopeAll EditScope = "all"
+	// ScopeThis одно повторение
+	ScopeThis EditScope = "this"
+	// ScopeFollowing повторение и все следующие — серия разделяется на две
+	ScopeFollowing EditScope = "following"
+)
+
+// ParseEditScope разбирает scope запроса; пустой — вся серия
+func ParseEditScope(s string) (EditScope, error) {
+	switch scope := EditScope(s); scope {
+	case "":
+		return ScopeAll, nil
+	case ScopeAll, ScopeThis, ScopeFollowing:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown scope %q, use this, following or all", s)
+	}
+}
+
+var ErrOccurrenceNotFound = NewNotFoundError("occurrence_not_found", "occurrence not found")
+
+// OccurrenceOverride изменённое повторение серии: новое время и, если задано, описание
+type OccurrenceOverride struct {
+	OriginalStart time.Time `json:"original_start"`
+	Date          time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Description   string    `json:"description,omitempty"`
+}
+
+func (e Event) isExcluded(start time.Time) bool {
+	return slices.ContainsFunc(e.ExDates, start.Equal)
+}
+
+func (e Event) override(start time.Time) (OccurrenceOverride, bool) {
+	for _, o := range e.Overrides {
+		if o.OriginalStart.Equal(start) {
+			return o, true
+		}
+	}
+	return OccurrenceOverride{}, false
+}
+
+// Exclude отменяет повторение с исходным началом start
+func (e *Event) Exclude(start time.Time) {
+	e.Overrides = slices.DeleteFunc(slices.Clone(e.Overrides), func(o OccurrenceOverride) bool {
+		return o.OriginalStart.Equal(start)
+	})
+	if !e.isExcluded(start) {
+		e.ExDates = append(slices.Clone(e.ExDates), start)
+	}
+}
+
+// Override заменяет время и описание одного повторения
+func (e *Event) Override(o OccurrenceOverride) {
+	e.Overrides = slices.DeleteFunc(slices.Clone(e.Overrides), func(existing OccurrenceOverride) bool {
+		return existing.OriginalStart.Equal(o.OriginalStart)
+	})
+	e.Overrides = append(e.Overrides, o)
+}
+
+// SplitAt обрезает серию перед повторением start и возвращает правило для продолжения серии с этого повторения.
+// Исключения и переносы начиная со start отбрасываются.
+func (e *Event) SplitAt(start time.Time) *RecurrenceRule {
+	rest := *e.Recurrence
+	truncated := *e.Recurrence
+
+	before := len(e.Recurrence.Starts(e.seriesStart(), start))
+	if e.Recurrence.Count > 0 {
+		truncated.Count = before
+		rest.Count = e.Recurrence.Count - before
+	} else {
+		truncated.Until = start.Add(-time.Nanosecond)
+	}
+	e.Recurrence = &truncated
+
+	e.ExDates = slices.DeleteFunc(slices.Clone(e.ExDates), func(t time.Time) bool { return !t.Before(start) })
+	e.Overrides = slices.DeleteFunc(slices.Clone(e.Overrides), func(o OccurrenceOverride) bool {
+		return !o.OriginalStart.Before(start)
+	})
+	return &rest
+}
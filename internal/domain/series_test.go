@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func weeklyStandup(t *testing.T, rule string) Event {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	return Event{EventId: 1, Date: start, End: start.Add(15 * time.Minute), Recurrence: mustRule(t, rule), Description: "Standup"}
+}
+
+func TestEvent_Exclude(t *testing.T) {
+	event := weeklyStandup(t, "FREQ=WEEKLY")
+	event.Exclude(time.Date(2026, 3, 9, 10, 0, 0, 0, time.UTC))
+
+	occurrences := event.Occurrences(MonthRange(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)))
+	got := dates(startsOf(occurrences))
+	want := []string{"2026-03-02", "2026-03-16", "2026-03-23", "2026-03-30"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEvent_Override(t *testing.T) {
+	event := weeklyStandup(t, "FREQ=WEEKLY")
+	original := time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)
+	moved := time.Date(2026, 3, 13, 15, 0, 0, 0, time.UTC)
+	event.Override(OccurrenceOverride{OriginalStart: original, Date: moved, End: moved.Add(time.Hour), Description: "Moved"})
+
+	// Повторение перенесено на неделю раньше своего исходного начала
+	occurrences := event.Occurrences(WeekRange(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)))
+	if len(occurrences) != 2 {
+		t.Fatalf("expected regular and moved occurrence, got %d", len(occurrences))
+	}
+	occ := occurrences[1]
+	if !occ.Date.Equal(moved) || !occ.OriginalStart.Equal(original) || occ.Description != "Moved" {
+		t.Errorf("unexpected moved occurrence %+v", occ)
+	}
+
+	if occurrences := event.Occurrences(WeekRange(time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC))); len(occurrences) != 0 {
+		t.Errorf("expected no occurrence at the original time, got %d", len(occurrences))
+	}
+}
+
+func TestEvent_SplitAt(t *testing.T) {
+	event := weeklyStandup(t, "FREQ=WEEKLY;COUNT=5")
+	split := time.Date(2026, 3, 16, 10, 0, 0, 0, time.UTC)
+	event.Exclude(time.Date(2026, 3, 23, 10, 0, 0, 0, time.UTC))
+
+	rest := event.SplitAt(split)
+	if event.Recurrence.Count != 2 || rest.Count != 3 {
+		t.Errorf("expected counts 2 and 3, got %d and %d", event.Recurrence.Count, rest.Count)
+	}
+	if len(event.ExDates) != 0 {
+		t.Errorf("expected exceptions after split to be dropped, got %v", event.ExDates)
+	}
+
+	event = weeklyStandup(t, "FREQ=WEEKLY")
+	rest = event.SplitAt(split)
+	starts := event.Recurrence.Starts(event.Date, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(starts) != 2 || !rest.Until.IsZero() {
+		t.Errorf("expected series truncated to 2 occurrences, got %d (rest %v)", len(starts), rest)
+	}
+}
+
+func startsOf(events []Event) []time.Time {
+	result := make([]time.Time, len(events))
+	for i, e := range events {
+		result[i] = e.Date
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+// AdminHandler служебные эндпоинты: dead-letter очередь напоминаний
+type AdminHandler struct {
+	reminders port.ReminderUsecases
+	logger    *log_worker.Logger
+}
+
+func NewAdminHandler(reminders port.ReminderUsecases, logger *log_worker.Logger) *AdminHandler {
+	return &AdminHandler{
+		reminders: reminders,
+		logger:    logger,
+	}
+}
+
+func (h *AdminHandler) DeadReminders(w http.ResponseWriter, r *http.Request) {
+	reminders, err := h.reminders.ListDeadReminders(r.Context())
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if reminders == nil {
+		reminders = []domain.Reminder{}
+	}
+	writeResult(w, reminders)
+}
+
+func (h *AdminHandler) RedriveReminder(w http.ResponseWriter, r *http.Request) {
+	reminderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || reminderID <= 0 {
+		writeError(w, "reminder id must be positive integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.reminders.RedriveReminder(r.Context(), reminderID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	h.logger.Writef("reminder %d redriven", reminderID)
+	writeResultMessage(w, "reminder redriven")
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+type MockReminders struct {
+	dead map[int64]domain.Reminder
+}
+
+func (m *MockReminders) ListDeadReminders(ctx context.Context) ([]domain.Reminder, error) {
+	var result []domain.Reminder
+	for _, r := range m.dead {
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func (m *MockReminders) RedriveReminder(ctx context.Context, reminderId int64) error {
+	if _, ok := m.dead[reminderId]; !ok {
+		return domain.ErrReminderNotFound
+	}
+	delete(m.dead, reminderId)
+	return nil
+}
+
+func newAdminMux(reminders *MockReminders) *http.ServeMux {
+	h := NewAdminHandler(reminders, log_worker.NewLogger())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/reminders/dead", h.DeadReminders)
+	mux.HandleFunc("POST /admin/reminders/{id}/redrive", h.RedriveReminder)
+	return mux
+}
+
+func TestAdminHandler_DeadReminders(t *testing.T) {
+	reminders := &MockReminders{dead: map[int64]domain.Reminder{
+		7: {ReminderId: 7, Status: domain.ReminderDead, Attempts: 5, LastError: "timeout"},
+	}}
+	mux := newAdminMux(reminders)
+
+	req := httptest.NewRequest("GET", "/admin/reminders/dead", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response struct {
+		Result []domain.Reminder `json:"result"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Result) != 1 || response.Result[0].LastError != "timeout" {
+		t.Errorf("Expected one dead reminder with last error, got %+v", response.Result)
+	}
+}
+
+func TestAdminHandler_RedriveReminder(t *testing.T) {
+	reminders := &MockReminders{dead: map[int64]domain.Reminder{7: {ReminderId: 7}}}
+	mux := newAdminMux(reminders)
+
+	req := httptest.NewRequest("POST", "/admin/reminders/7/redrive", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/admin/reminders/7/redrive", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for second redrive, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/admin/reminders/abc/redrive", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
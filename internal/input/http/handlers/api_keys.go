@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+// APIKeyHandler выпуск API-ключей
+type APIKeyHandler struct {
+	auth   port.AuthUsecases
+	logger *log_worker.Logger
+}
+
+func NewAPIKeyHandler(auth port.AuthUsecases, logger *log_worker.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		auth:   auth,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey выпускает ключ для текущего пользователя. Ключ с правами администратора
+// может выпустить только администратор.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := parseBodyCreateAPIKey(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if key.UserId, err = actingUser(r, key.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if principal, ok := principalFrom(r.Context()); ok && key.Admin && !principal.Admin {
+		writeUsecaseError(w, domain.NewForbiddenError("admin_required", "only admins can create admin keys"))
+		return
+	}
+
+	plain, err := h.auth.CreateAPIKey(r.Context(), &key)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	h.logger.Writef("api key %d created for user %d", key.KeyId, key.UserId)
+	writeResult(w, types.CreateAPIKeyResponse{APIKey: key, Key: plain})
+}
+
+// parseBodyCreateAPIKey парсит JSON или form для выпуска API-ключа
+func parseBodyCreateAPIKey(r *http.Request) (domain.APIKey, error) {
+	ct := r.Header.Get("Content-Type")
+	if strings.Contains(ct, "application/json") {
+		var req types.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return domain.APIKey{}, errors.New("invalid JSON body")
+		}
+		return domain.APIKey{UserId: req.UserID, Name: req.Name, Admin: req.Admin}, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return domain.APIKey{}, errors.New("invalid form body")
+	}
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+	admin := false
+	if s := r.FormValue("admin"); s != "" {
+		if admin, err = strconv.ParseBool(s); err != nil {
+			return domain.APIKey{}, errors.New("admin must be true or false")
+		}
+	}
+	return domain.APIKey{UserId: userID, Name: r.FormValue("name"), Admin: admin}, nil
+}
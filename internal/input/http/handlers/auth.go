@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+// Authenticator проверяет учётные данные запроса: API-ключ (Authorization: Bearer cal_... или X-API-Key)
+// либо JWT (Authorization: Bearer <jwt>). Для календарных приложений, которые умеют только Basic,
+// API-ключ принимается паролем в Authorization: Basic; имя пользователя не проверяется.
+type Authenticator struct {
+	keys port.AuthUsecases
+	// jwt nil — JWT не принимаются
+	jwt *JWTVerifier
+}
+
+func NewAuthenticator(keys port.AuthUsecases, jwt *JWTVerifier) *Authenticator {
+	return &Authenticator{
+		keys: keys,
+		jwt:  jwt,
+	}
+}
+
+// Middleware пропускает дальше только запросы с действительными учётными данными
+// и кладёт пользователя в контекст запроса
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.Authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+			w.Header().Add("WWW-Authenticate", `Basic realm="calendar"`)
+			writeUsecaseError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
+	})
+}
+
+func (a *Authenticator) Authenticate(r *http.Request) (domain.Principal, error) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return a.keys.AuthenticateAPIKey(r.Context(), key)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Basic") {
+		return a.authenticateBasic(r, token)
+	}
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return domain.Principal{}, domain.NewUnauthorizedError("unauthorized", "authentication required")
+	}
+	// У JWT ровно три сегмента через точку, в API-ключе точек нет
+	if strings.Count(token, ".") != 2 {
+		return a.keys.AuthenticateAPIKey(r.Context(), token)
+	}
+	if a.jwt == nil {
+		return domain.Principal{}, invalidToken("jwt authentication is not configured")
+	}
+	return a.jwt.Verify(token)
+}
+
+// authenticateBasic API-ключ из пароля Basic
+func (a *Authenticator) authenticateBasic(r *http.Request, credentials string) (domain.Principal, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(credentials))
+	if err != nil {
+		return domain.Principal{}, domain.NewUnauthorizedError("unauthorized", "invalid basic credentials")
+	}
+	_, password, ok := strings.Cut(string(decoded), ":")
+	if !ok || password == "" {
+		return domain.Principal{}, domain.NewUnauthorizedError("unauthorized", "invalid basic credentials")
+	}
+	return a.keys.AuthenticateAPIKey(r.Context(), password)
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, principal domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// principalFrom пользователь из учётных данных; false — аутентификация отключена
+func principalFrom(ctx context.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return principal, ok
+}
+
+// actingUser пользователь, от имени которого выполняется запрос. С аутентификацией он берётся из учётных данных,
+// а user_id из запроса необязателен и должен с ними совпадать; без аутентификации — user_id из запроса.
+func actingUser(r *http.Request, claimed int64) (int64, error) {
+	if principal, ok := principalFrom(r.Context()); ok {
+		if claimed != 0 && claimed != principal.UserId {
+			return 0, domain.NewForbiddenError("user_mismatch", "user_id does not match credentials")
+		}
+		return principal.UserId, nil
+	}
+	if claimed <= 0 {
+		return 0, domain.NewValidationError("invalid_user_id", "user_id is required and must be positive integer")
+	}
+	return claimed, nil
+}
+
+// requireAdmin пропускает только администраторов; без аутентификации пропускает всех
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := principalFrom(r.Context()); ok && !principal.Admin {
+			writeUsecaseError(w, domain.NewForbiddenError("admin_required", "admin access required"))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+type MockAuth struct {
+	keys map[string]domain.Principal
+}
+
+func (m *MockAuth) AuthenticateAPIKey(ctx context.Context, key string) (domain.Principal, error) {
+	principal, ok := m.keys[key]
+	if !ok {
+		return domain.Principal{}, domain.NewUnauthorizedError("invalid_api_key", "invalid api key")
+	}
+	return principal, nil
+}
+
+func (m *MockAuth) CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, error) {
+	key.KeyId = int64(len(m.keys) + 1)
+	plain := "cal_new"
+	m.keys[plain] = domain.Principal{UserId: key.UserId, Admin: key.Admin}
+	return plain, nil
+}
+
+func jsonBody(v any) *bytes.Buffer {
+	data, _ := json.Marshal(v)
+	return bytes.NewBuffer(data)
+}
+
+func newAuthServer(t *testing.T, usecases *MockUsecases) *Server {
+	t.Helper()
+	secret := []byte("test-secret")
+	auth := &MockAuth{keys: map[string]domain.Principal{
+		"cal_user":  {UserId: 1},
+		"cal_admin": {UserId: 2, Admin: true},
+	}}
+	return NewServer(usecases, NewMockUsers(), &MockReminders{dead: map[int64]domain.Reminder{}}, auth, nil, NewMockFeeds(),
+		NewAuthenticator(auth, NewJWTVerifier(JWTConfig{HMACSecret: secret})), log_worker.NewLogger())
+}
+
+func TestServer_AuthRequired(t *testing.T) {
+	srv := newAuthServer(t, NewMockUsecases())
+
+	for _, header := range []string{"", "Bearer", "Basic dXNlcjpwYXNz", "Bearer cal_unknown", "Bearer a.b.c"} {
+		req := httptest.NewRequest("GET", "/events_for_day?date=2026-03-15", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%q: expected status 401, got %d", header, w.Code)
+		}
+		if w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: expected WWW-Authenticate header", header)
+		}
+	}
+}
+
+func TestServer_UserFromCredentials(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+	jwt := signJWT(t, "HS256", []byte("test-secret"), map[string]any{"sub": "1", "exp": 4102444800})
+
+	for _, set := range []func(*http.Request){
+		func(r *http.Request) { r.Header.Set("Authorization", "Bearer cal_user") },
+		func(r *http.Request) { r.Header.Set("X-API-Key", "cal_user") },
+		func(r *http.Request) { r.SetBasicAuth("anyone", "cal_user") },
+		func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+jwt) },
+	} {
+		req := httptest.NewRequest("POST", "/create_event", jsonBody(map[string]any{"date": "2026-03-15", "event": "Mine"}))
+		req.Header.Set("Content-Type", "application/json")
+		set(req)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+	}
+	for _, event := range usecases.events {
+		if event.UserId != 1 {
+			t.Errorf("Expected event owned by user 1, got %d", event.UserId)
+		}
+	}
+
+	// Чужой user_id в запросе не позволяет действовать от имени другого пользователя
+	req := httptest.NewRequest("GET", "/events_for_day?user_id=2&date=2026-03-15", nil)
+	req.Header.Set("Authorization", "Bearer cal_user")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for foreign user_id, got %d", w.Code)
+	}
+}
+
+func TestServer_AdminRequired(t *testing.T) {
+	srv := newAuthServer(t, NewMockUsecases())
+
+	for key, code := range map[string]int{"cal_user": http.StatusForbidden, "cal_admin": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/admin/reminders/dead", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d", key, code, w.Code)
+		}
+	}
+}
+
+func TestServer_CreateAPIKey(t *testing.T) {
+	srv := newAuthServer(t, NewMockUsecases())
+
+	for key, code := range map[string]int{"cal_user": http.StatusForbidden, "cal_admin": http.StatusOK} {
+		req := httptest.NewRequest("POST", "/api_keys", jsonBody(map[string]any{"name": "ci", "admin": true}))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Fatalf("%s: expected status %d, got %d", key, code, w.Code)
+		}
+		if code != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Result types.CreateAPIKeyResponse `json:"result"`
+		}
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		if response.Result.Key == "" || response.Result.UserId != 2 {
+			t.Errorf("Expected key for user 2, got %+v", response.Result)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/bulk"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+)
+
+const (
+	// maxBulkImportSize наибольший размер загружаемого файла; строки читаются по одной, поэтому он больше, чем у .ics
+	maxBulkImportSize = 100 << 20
+	// exportFlushSize через сколько событий выгрузка отправляет накопленное клиенту
+	exportFlushSize = 500
+	// exportWriteTimeout сколько может писаться одна порция выгрузки. WriteTimeout сервера ограничивает
+	// весь ответ и оборвал бы большую выгрузку, поэтому дедлайн записи сдвигается перед каждой порцией.
+	exportWriteTimeout = 30 * time.Second
+)
+
+// ExportEventsV1 GET /v1/export/events?format=csv|ndjson&from=...&to=... — события за [from, to) файлом CSV
+// или NDJSON. Серия выгружается одной строкой с правилом. Границы, include_archived и tz — как у /v1/events.
+// all_users=true — события всех пользователей, только для администраторов.
+func (h *Handler) ExportEventsV1(w http.ResponseWriter, r *http.Request) {
+	format := bulk.FormatCSV
+	if s := r.URL.Query().Get("format"); s != "" {
+		var err error
+		if format, err = bulk.ParseFormat(s); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	query := domain.EventQuery{IncludeArchived: includeArchived(r)}
+	query.AllUsers = r.URL.Query().Get("all_users") == "true"
+	loc, err := h.exportLocation(r, &query)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if query.From, err = parseRangeBound(r.URL.Query().Get("from"), loc); err != nil {
+		writeError(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.To, err = parseRangeBound(r.URL.Query().Get("to"), loc); err != nil {
+		writeError(w, "to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Заголовки отправляются с первым событием: до него ошибку выборки ещё можно вернуть статусом
+	var encoder *bulk.Encoder
+	rc := http.NewResponseController(w)
+	begin := func() {
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", `attachment; filename="events.`+string(format)+`"`)
+		encoder = bulk.NewEncoder(w, format)
+		extendWriteDeadline(rc)
+	}
+	written := 0
+	err = h.usecases.ExportEvents(r.Context(), query, func(event domain.Event) error {
+		if encoder == nil {
+			begin()
+		}
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		if written++; written%exportFlushSize == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			extendWriteDeadline(rc)
+		}
+		return nil
+	})
+	if err != nil {
+		if encoder == nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		// Заголовки уже отправлены, ответ с ошибкой не вернуть
+		log.Printf("failed to export events: %v", err)
+		return
+	}
+	if encoder == nil {
+		begin()
+	}
+	if err := encoder.Flush(); err != nil {
+		log.Printf("failed to export events: %v", err)
+	}
+}
+
+// extendWriteDeadline даёт следующей порции выгрузки exportWriteTimeout на запись
+func extendWriteDeadline(rc *http.ResponseController) {
+	if err := rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("failed to extend export write deadline: %v", err)
+	}
+}
+
+// exportLocation проверяет, чьи события можно выгрузить, и возвращает пояс для границ интервала:
+// для всех пользователей — tz или UTC, для одного — как у /v1/events
+func (h *Handler) exportLocation(r *http.Request, query *domain.EventQuery) (*time.Location, error) {
+	if query.AllUsers {
+		if principal, ok := principalFrom(r.Context()); ok && !principal.Admin {
+			return nil, domain.NewForbiddenError("admin_required", "only admins can export events of all users")
+		}
+		loc, err := domain.LoadLocation(r.URL.Query().Get("tz"))
+		if err != nil {
+			return nil, domain.NewValidationError("invalid_time_zone", "%v", err)
+		}
+		return loc, nil
+	}
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		return nil, domain.NewValidationError("invalid_user_id", "%v", err)
+	}
+	if query.UserId, err = actingUser(r, userID); err != nil {
+		return nil, err
+	}
+	return h.userLocation(r, query.UserId)
+}
+
+// ImportEventsV1 POST /v1/import/events — загрузка событий из CSV или NDJSON: тело запроса целиком
+// или поле file в multipart/form-data. Формат — параметр format, иначе по Content-Type или расширению файла.
+// Строка с event_id обновляет событие (version — ожидаемая версия), с external_uid — импортирует его
+// как из .ics, остальные строки создают события. Все строки проходят те же проверки, что и запросы API;
+// ошибка в строке не мешает загрузке остальных. Если файл не дочитан, отчёт о загруженных строках всё равно
+// отдаётся, с truncated и строкой, на которой чтение прервалось. Администратор может загружать события
+// других пользователей через колонку user_id; user_id в query — пользователь для строк без него,
+// если аутентификация отключена.
+func (h *Handler) ImportEventsV1(w http.ResponseWriter, r *http.Request) {
+	defaultUser, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkImportSize)
+	body, format, err := bulkImportBody(r)
+	if err != nil {
+		writeBulkReadError(w, err)
+		return
+	}
+	decoder, err := bulk.NewDecoder(body, format)
+	if err != nil {
+		writeBulkReadError(w, err)
+		return
+	}
+
+	report := types.BulkImportReport{Errors: []types.BulkImportError{}}
+	for {
+		record, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Строки до этой уже загружены, поэтому вместо ошибки запроса — отчёт о них
+			report.Failed++
+			report.Truncated = true
+			report.Errors = append(report.Errors, types.BulkImportError{Row: record.Row, Error: bulkReadError(err)})
+			break
+		}
+		status, eventID, err := h.importRecord(r, record, defaultUser)
+		switch status {
+		case domain.ImportCreated:
+			report.Created++
+		case domain.ImportUpdated:
+			report.Updated++
+		case domain.ImportSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+			report.Errors = append(report.Errors, types.BulkImportError{Row: record.Row, EventID: eventID, Error: err.Error()})
+		}
+	}
+	h.logger.Writef("bulk import: %d created, %d updated, %d skipped, %d failed, truncated %t",
+		report.Created, report.Updated, report.Skipped, report.Failed, report.Truncated)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// importRecord сохраняет событие строки через usecases; при ошибке статус — domain.ImportFailed
+func (h *Handler) importRecord(r *http.Request, record bulk.Record, defaultUser int64) (domain.ImportStatus, int64, error) {
+	if record.Err != nil {
+		return domain.ImportFailed, 0, record.Err
+	}
+	event := record.Event
+	var err error
+	if event.UserId, err = importOwner(r, event.UserId, defaultUser); err != nil {
+		return domain.ImportFailed, event.EventId, err
+	}
+	// Архивный флаг задаётся только новым событиям, у существующих он не меняется, как и в PUT
+	archived := event.IsArchived
+	event.IsArchived = false
+
+	ctx := r.Context()
+	switch {
+	case event.EventId > 0:
+		if err := h.usecases.UpdateEvent(ctx, event, domain.ScopeAll); err != nil {
+			return domain.ImportFailed, event.EventId, err
+		}
+		return domain.ImportUpdated, event.EventId, nil
+	case event.ExternalUID != "":
+		status, err := h.usecases.ImportEvent(ctx, &event)
+		if err != nil {
+			return domain.ImportFailed, event.EventId, err
+		}
+		return status, event.EventId, nil
+	}
+	if err := h.usecases.CreateEvent(ctx, &event); err != nil {
+		return domain.ImportFailed, 0, err
+	}
+	if archived {
+		if _, err := h.usecases.ArchiveEvent(ctx, event.UserId, event.EventId, 0); err != nil {
+			return domain.ImportFailed, event.EventId, err
+		}
+	}
+	return domain.ImportCreated, event.EventId, nil
+}
+
+// importOwner владелец события строки. Администратор загружает события любых пользователей,
+// остальные — только свои; пустой user_id строки — пользователь запроса.
+func importOwner(r *http.Request, rowUser, defaultUser int64) (int64, error) {
+	if principal, ok := principalFrom(r.Context()); ok && principal.Admin && rowUser > 0 {
+		return rowUser, nil
+	}
+	if rowUser == 0 {
+		rowUser = defaultUser
+	}
+	return actingUser(r, rowUser)
+}
+
+// bulkImportBody файл для загрузки и его формат. multipart/form-data читается потоком до поля file,
+// поэтому поля после файла не учитываются.
+func bulkImportBody(r *http.Request) (io.Reader, bulk.Format, error) {
+	var body io.Reader = r.Body
+	contentType, fileName := r.Header.Get("Content-Type"), ""
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			return nil, "", errBulkFileRequired
+		}
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil, "", errBulkFileRequired
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			if part.FormName() == "file" {
+				body, contentType, fileName = part, part.Header.Get("Content-Type"), part.FileName()
+				break
+			}
+		}
+	}
+
+	if s := r.URL.Query().Get("format"); s != "" {
+		format, err := bulk.ParseFormat(s)
+		return body, format, err
+	}
+	if format, ok := bulk.FormatByContentType(contentType); ok {
+		return body, format, nil
+	}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return body, bulk.FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return body, bulk.FormatNDJSON, nil
+	}
+	return nil, "", errors.New("format is required: pass format=csv or format=ndjson")
+}
+
+var errBulkFileRequired = errors.New("multipart field file is required")
+
+// writeBulkReadError ошибка чтения файла до первой строки: 413 для слишком большого файла, иначе 400
+func writeBulkReadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeErrorCode(w, bulkReadError(err), "payload_too_large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	writeErrorCode(w, err.Error(), "invalid_file", http.StatusBadRequest)
+}
+
+// bulkReadError текст ошибки чтения файла
+func bulkReadError(err error) string {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return "file is too large"
+	}
+	return err.Error()
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/bulk"
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/internal/usecases"
+	"github.com/dontpanicw/calendar/log_worker"
+	"github.com/dontpanicw/calendar/notify_worker"
+)
+
+func TestServer_ExportEventsV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	start := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start, End: start.Add(time.Hour), Description: "Standup", Recurrence: rule})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(3 * time.Hour), Description: "Lunch"})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 2, Date: start, Description: "Other user"})
+
+	req := httptest.NewRequest("GET", "/v1/export/events?user_id=1&from=2026-03-15&to=2026-03-22", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != bulk.FormatCSV.ContentType() {
+		t.Errorf("Unexpected Content-Type %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	// Заголовок и по строке на событие: серия — одна строка с правилом
+	if len(lines) != 3 || !strings.Contains(lines[1], "Standup") || !strings.Contains(lines[1], "FREQ=DAILY") || !strings.Contains(lines[2], "Lunch") {
+		t.Errorf("Unexpected export:\n%s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/v1/export/events?format=ndjson&all_users=true&from=2026-03-15&to=2026-03-22", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := strings.Count(w.Body.String(), "\n"); n != 3 || !strings.Contains(w.Body.String(), `"description":"Other user"`) {
+		t.Errorf("Expected events of all users:\n%s", w.Body.String())
+	}
+
+	for _, path := range []string{
+		"/v1/export/events?user_id=1&from=2026-03-15",
+		"/v1/export/events?user_id=1&format=xlsx&from=2026-03-15&to=2026-03-22",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, w.Code)
+		}
+	}
+}
+
+// TestServer_ExportEventsV1_Large выгрузка больше одной порции: каждое событие ровно один раз
+func TestServer_ExportEventsV1_Large(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	start := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	total := 2*exportFlushSize + 7
+	for i := range total {
+		// По несколько событий на одно время
+		usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(time.Duration(i/3) * time.Minute), Description: "Event"})
+	}
+
+	req := httptest.NewRequest("GET", "/v1/export/events?format=ndjson&user_id=1&from=2026-03-15&to=2026-03-22", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	seen := make(map[int64]bool)
+	decoder := json.NewDecoder(w.Body)
+	for decoder.More() {
+		var event domain.Event
+		if err := decoder.Decode(&event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if seen[event.EventId] {
+			t.Errorf("Event %d exported twice", event.EventId)
+		}
+		seen[event.EventId] = true
+	}
+	if len(seen) != total {
+		t.Errorf("Expected %d events, got %d", total, len(seen))
+	}
+}
+
+// slowExport выгрузка, которая читает каждую порцию событий дольше WriteTimeout сервера
+type slowExport struct {
+	*MockUsecases
+	delay time.Duration
+}
+
+func (s slowExport) ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error {
+	read := 0
+	return s.MockUsecases.ExportEvents(ctx, query, func(event domain.Event) error {
+		if read++; read%exportFlushSize == 0 {
+			time.Sleep(s.delay)
+		}
+		return fn(event)
+	})
+}
+
+// TestServer_ExportEventsV1_WriteTimeout WriteTimeout сервера не обрывает выгрузку, которая идёт дольше него
+func TestServer_ExportEventsV1_WriteTimeout(t *testing.T) {
+	usecases := NewMockUsecases()
+	start := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	total := 3 * exportFlushSize
+	for i := range total {
+		usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(time.Duration(i) * time.Minute), Description: "Event"})
+	}
+	handler := NewServer(slowExport{MockUsecases: usecases, delay: 150 * time.Millisecond}, NewMockUsers(), &MockReminders{}, &MockAuth{}, nil, NewMockFeeds(), nil, log_worker.NewLogger())
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/export/events?format=ndjson&user_id=1&from=2026-03-15&to=2026-03-22")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Export was cut off after %d bytes: %v", len(body), err)
+	}
+	if n := bytes.Count(body, []byte("\n")); n != total {
+		t.Errorf("Expected %d events, got %d", total, n)
+	}
+}
+
+func TestServer_ExportEventsV1_AllUsersRequiresAdmin(t *testing.T) {
+	srv := newAuthServer(t, NewMockUsecases())
+	for key, code := range map[string]int{"cal_user": http.StatusForbidden, "cal_admin": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/v1/export/events?all_users=true&from=2026-03-15&to=2026-03-22", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d", key, code, w.Code)
+		}
+	}
+}
+
+func bulkImport(t *testing.T, srv *Server, req *http.Request) types.BulkImportReport {
+	t.Helper()
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var report types.BulkImportReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	return report
+}
+
+func TestServer_ImportEventsV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Old"})
+
+	csv := "event_id,user_id,date,description,is_archived,external_uid\n" +
+		"1,,2026-03-15T10:00:00Z,Renamed,,\n" +
+		",,2026-03-16T10:00:00Z,Created,true,\n" +
+		",,not a date,Broken,,\n" +
+		",2,2026-03-16T10:00:00Z,Someone else,,\n" +
+		"42,,2026-03-16T10:00:00Z,Missing,,\n" +
+		",,2026-03-17T10:00:00Z,From calendar,,uid@example.com\n"
+	req := httptest.NewRequest("POST", "/v1/import/events?user_id=1", strings.NewReader(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	report := bulkImport(t, srv, req)
+
+	if report.Created != 3 || report.Updated != 1 || report.Failed != 2 || len(report.Errors) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	// Без аутентификации user_id строки задаёт владельца, поэтому строка 5 загружается пользователю 2
+	for i, want := range []types.BulkImportError{{Row: 4}, {Row: 6, EventID: 42}} {
+		got := report.Errors[i]
+		if got.Row != want.Row || got.EventID != want.EventID || got.Error == "" {
+			t.Errorf("error %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	if e := usecases.events[1]; e.Description != "Renamed" || e.Version != 2 {
+		t.Errorf("Expected updated event, got %+v", e)
+	}
+	if e := usecases.events[2]; e.Description != "Created" || !e.IsArchived || e.UserId != 1 {
+		t.Errorf("Expected archived event of user 1, got %+v", e)
+	}
+	if e := usecases.events[3]; e.UserId != 2 {
+		t.Errorf("Expected event of user 2, got %+v", e)
+	}
+
+	// NDJSON в multipart: формат по расширению файла; external_uid не создаёт дублей
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "events.ndjson")
+	_, _ = fw.Write([]byte(`{"date":"2026-03-17T10:00:00Z","description":"From calendar","external_uid":"uid@example.com"}` + "\n"))
+	_ = mw.Close()
+	req = httptest.NewRequest("POST", "/v1/import/events?user_id=1", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if report := bulkImport(t, srv, req); report.Skipped != 1 || report.Created != 0 {
+		t.Errorf("Expected skipped event, got %+v", report)
+	}
+
+	for _, tc := range []struct {
+		path, contentType, body string
+		code                    int
+	}{
+		{"/v1/import/events?user_id=1", "text/plain", "date,description\n", http.StatusBadRequest},
+		{"/v1/import/events?user_id=1&format=csv", "", "date,colour\n", http.StatusBadRequest},
+		{"/v1/import/events?user_id=x&format=csv", "", "date,description\n", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s %q: expected status %d, got %d", tc.path, tc.body, tc.code, w.Code)
+		}
+	}
+}
+
+func TestServer_ImportEventsV1_Owner(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+	csv := "user_id,date,description\n,2026-03-15T10:00:00Z,Own\n3,2026-03-15T10:00:00Z,Other\n"
+
+	// Пользователь загружает только свои события, администратор — любые
+	for key, want := range map[string]struct{ created, failed int }{"cal_user": {1, 1}, "cal_admin": {2, 0}} {
+		req := httptest.NewRequest("POST", "/v1/import/events", strings.NewReader(csv))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", "Bearer "+key)
+		report := bulkImport(t, srv, req)
+		if report.Created != want.created || report.Failed != want.failed {
+			t.Errorf("%s: unexpected report %+v", key, report)
+		}
+	}
+}
+
+// TestServer_ImportEventsV1_ReadError оборванный файл: уже загруженные строки попадают в отчёт
+func TestServer_ImportEventsV1_ReadError(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+
+	csv := "date,description\n" +
+		"2026-03-15T10:00:00Z,First\n" +
+		"2026-03-16T10:00:00Z,Second\n"
+	body := io.MultiReader(strings.NewReader(csv), iotest.ErrReader(errors.New("connection reset")))
+	req := httptest.NewRequest("POST", "/v1/import/events?user_id=1&format=csv", body)
+	report := bulkImport(t, srv, req)
+
+	if report.Created != 2 || report.Failed != 1 || !report.Truncated || len(report.Errors) != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if e := report.Errors[0]; e.Row != 4 || !strings.Contains(e.Error, "connection reset") {
+		t.Errorf("Expected read error on row 4, got %+v", e)
+	}
+	if len(usecases.events) != 2 {
+		t.Errorf("Expected 2 imported events, got %d", len(usecases.events))
+	}
+}
+
+// TestServer_BulkRoundTrip выгрузка, загруженная в пустой календарь, даёт те же напоминания и тот же
+// конец события на весь день: напоминание по умолчанию не добавляется событию без напоминаний
+func TestServer_BulkRoundTrip(t *testing.T) {
+	newServer := func() (*Server, *usecases.UsecaseEvent) {
+		repo := cache.NewCacheMap()
+		uc := usecases.NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+		return NewServer(uc, NewMockUsers(), &MockReminders{}, &MockAuth{}, nil, NewMockFeeds(), nil, log_worker.NewLogger()), uc
+	}
+	ctx := context.Background()
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 2)
+	events := []domain.Event{
+		{UserId: 1, Date: day.Add(9 * time.Hour), Description: "Default reminder"},
+		{UserId: 1, Date: day.Add(10 * time.Hour), Description: "No reminders", ReminderOffsets: []domain.Offset{}},
+		{UserId: 1, Date: day.Add(11 * time.Hour), Description: "Offsets", ReminderOffsets: []domain.Offset{domain.Offset(10 * time.Minute), domain.Offset(24 * time.Hour)}},
+		{UserId: 1, Date: day, End: day.AddDate(0, 0, 3), AllDay: true, Description: "Trip", ReminderTimes: []time.Time{day.Add(-6 * time.Hour)}},
+	}
+	srcServer, src := newServer()
+	for i := range events {
+		if err := src.CreateEvent(ctx, &events[i]); err != nil {
+			t.Fatalf("CreateEvent: %v", err)
+		}
+	}
+	period := "&from=" + day.Format(time.DateOnly) + "&to=" + day.AddDate(0, 0, 7).Format(time.DateOnly)
+
+	for _, format := range []bulk.Format{bulk.FormatCSV, bulk.FormatNDJSON} {
+		req := httptest.NewRequest("GET", "/v1/export/events?user_id=1&format="+string(format)+period, nil)
+		w := httptest.NewRecorder()
+		srcServer.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", format, w.Code, w.Body.String())
+		}
+
+		dstServer, dst := newServer()
+		req = httptest.NewRequest("POST", "/v1/import/events?user_id=1&format="+string(format), strings.NewReader(withoutIDs(t, format, w.Body.String())))
+		if report := bulkImport(t, dstServer, req); report.Created != len(events) || report.Failed != 0 {
+			t.Fatalf("%s: unexpected report %+v", format, report)
+		}
+		// Выгрузка упорядочена по началу, поэтому события сопоставляются по описанию
+		imported := make(map[string]domain.Event)
+		for id := range int64(len(events)) {
+			event, err := dst.GetEvent(ctx, 1, id+1)
+			if err != nil {
+				t.Fatalf("%s: event %d: %v", format, id+1, err)
+			}
+			imported[event.Description] = event
+		}
+		for i, want := range events {
+			got := imported[want.Description]
+			if got.Description != want.Description || !got.Date.Equal(want.Date) || !got.End.Equal(want.End) || got.AllDay != want.AllDay ||
+				!slices.Equal(got.ReminderOffsets, want.ReminderOffsets) || len(got.ReminderTimes) != len(want.ReminderTimes) {
+				t.Errorf("%s: event %d differs:\ngot  %+v\nwant %+v", format, i, got, want)
+			}
+		}
+	}
+}
+
+// withoutIDs убирает из выгрузки event_id и version, чтобы строки создали новые события
+func withoutIDs(t *testing.T, format bulk.Format, exported string) string {
+	t.Helper()
+	var out strings.Builder
+	if format == bulk.FormatNDJSON {
+		for _, line := range strings.Split(strings.TrimSpace(exported), "\n") {
+			var fields map[string]any
+			if err := json.Unmarshal([]byte(line), &fields); err != nil {
+				t.Fatalf("invalid NDJSON line %q: %v", line, err)
+			}
+			delete(fields, "event_id")
+			delete(fields, "version")
+			data, _ := json.Marshal(fields)
+			out.Write(append(data, '\n'))
+		}
+		return out.String()
+	}
+	records, err := csv.NewReader(strings.NewReader(exported)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	cw := csv.NewWriter(&out)
+	for _, record := range records {
+		// event_id и version — первая и третья колонки
+		_ = cw.Write(append(record[1:2:2], record[3:]...))
+	}
+	cw.Flush()
+	return out.String()
+}
@@ -0,0 +1,857 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/ical"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+// CalDAV (RFC 4791) для календарных приложений. Ресурсы:
+//
+//	/dav/                                  — точка входа: current-user-principal
+//	/dav/principals/{id}/                  — пользователь: calendar-home-set
+//	/dav/calendars/{id}/                   — домашняя коллекция с одним календарём
+//	/dav/calendars/{id}/events/            — календарь событий
+//	/dav/calendars/{id}/events/{uid}.ics   — событие; серия — один ресурс с правилом
+//
+// Поддерживаются PROPFIND, REPORT calendar-query и calendar-multiget, GET, PUT и DELETE событий.
+// ETag ресурса — версия события, getctag календаря меняется при любом изменении его событий.
+const (
+	davPrefix   = "/dav/"
+	davCalendar = "events"
+
+	nsDAV       = "DAV:"
+	nsCalDAV    = "urn:ietf:params:xml:ns:caldav"
+	nsCalServer = "http://calendarserver.org/ns/"
+
+	// davWindow календарь CalDAV содержит события за год до и год после текущего момента
+	davWindow = 365 * 24 * time.Hour
+	// davChunk интервал одного запроса ListEvents, не длиннее допустимого
+	davChunk = 180 * 24 * time.Hour
+	// davTimeLayout время в time-range calendar-query
+	davTimeLayout = "20060102T150405Z"
+	// davEventContentType тип ресурса события
+	davEventContentType = "text/calendar; charset=utf-8; component=vevent"
+)
+
+// davPrefixes префиксы пространств имён в ответах
+var davPrefixes = map[string]string{nsDAV: "d", nsCalDAV: "c", nsCalServer: "cs"}
+
+// CalDAVHandler обслуживает /dav/ через usecases событий
+type CalDAVHandler struct {
+	usecases port.EventUsecases
+	// users нужны для часового пояса «плавающего» времени в загружаемых событиях
+	users  port.UserUsecases
+	logger *log_worker.Logger
+	now    func() time.Time
+}
+
+func NewCalDAVHandler(usecases port.EventUsecases, users port.UserUsecases, logger *log_worker.Logger) *CalDAVHandler {
+	return &CalDAVHandler{
+		usecases: usecases,
+		users:    users,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// davKind вид ресурса CalDAV
+type davKind int
+
+const (
+	davRootKind davKind = iota
+	davPrincipalKind
+	davHomeKind
+	davCalendarKind
+	davEventKind
+)
+
+// davPath разобранный путь ресурса
+type davPath struct {
+	kind   davKind
+	userID int64
+	// uid UID события для davEventKind
+	uid string
+}
+
+// parseDAVPath разбирает экранированный путь запроса; false — такого ресурса нет
+func parseDAVPath(escaped string) (davPath, bool) {
+	rest, ok := strings.CutPrefix(escaped, davPrefix)
+	if !ok {
+		return davPath{}, false
+	}
+	rest = strings.TrimSuffix(rest, "/")
+	if rest == "" {
+		return davPath{kind: davRootKind}, true
+	}
+	segments := strings.Split(rest, "/")
+	if len(segments) < 2 {
+		return davPath{}, false
+	}
+	userID, err := strconv.ParseInt(segments[1], 10, 64)
+	if err != nil || userID <= 0 {
+		return davPath{}, false
+	}
+	p := davPath{userID: userID}
+	switch {
+	case segments[0] == "principals" && len(segments) == 2:
+		p.kind = davPrincipalKind
+	case segments[0] != "calendars":
+		return davPath{}, false
+	case len(segments) == 2:
+		p.kind = davHomeKind
+	case segments[2] != davCalendar:
+		return davPath{}, false
+	case len(segments) == 3:
+		p.kind = davCalendarKind
+	case len(segments) == 4 && strings.HasSuffix(segments[3], ".ics"):
+		uid, err := url.PathUnescape(strings.TrimSuffix(segments[3], ".ics"))
+		if err != nil || uid == "" {
+			return davPath{}, false
+		}
+		p.kind, p.uid = davEventKind, uid
+	default:
+		return davPath{}, false
+	}
+	return p, true
+}
+
+func principalHref(userID int64) string {
+	return fmt.Sprintf("%sprincipals/%d/", davPrefix, userID)
+}
+
+func homeHref(userID int64) string {
+	return fmt.Sprintf("%scalendars/%d/", davPrefix, userID)
+}
+
+func calendarHref(userID int64) string {
+	return homeHref(userID) + davCalendar + "/"
+}
+
+func eventHref(userID int64, uid string) string {
+	return calendarHref(userID) + url.PathEscape(uid) + ".ics"
+}
+
+func (h *CalDAVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := parseDAVPath(r.URL.EscapedPath())
+	if !ok {
+		writeError(w, "resource not found", http.StatusNotFound)
+		return
+	}
+	// Пользователь точки входа — из учётных данных, без аутентификации — из user_id
+	claimed := p.userID
+	if p.kind == davRootKind {
+		var err error
+		if claimed, err = parseUserID(r.URL.Query().Get("user_id")); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	userID, err := actingUser(r, claimed)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	p.userID = userID
+
+	switch {
+	case r.Method == http.MethodOptions:
+		w.Header().Set("DAV", "1, calendar-access")
+		w.Header().Set("Allow", davAllow(p.kind))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == "PROPFIND":
+		h.propfind(w, r, p)
+	case r.Method == "REPORT" && p.kind == davCalendarKind:
+		h.report(w, r, p)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && p.kind == davEventKind:
+		h.get(w, r, p)
+	case r.Method == http.MethodPut && p.kind == davEventKind:
+		h.put(w, r, p)
+	case r.Method == http.MethodDelete && p.kind == davEventKind:
+		h.delete(w, r, p)
+	default:
+		w.Header().Set("Allow", davAllow(p.kind))
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// davAllow методы ресурса для заголовка Allow
+func davAllow(kind davKind) string {
+	switch kind {
+	case davCalendarKind:
+		return "OPTIONS, PROPFIND, REPORT"
+	case davEventKind:
+		return "OPTIONS, PROPFIND, GET, HEAD, PUT, DELETE"
+	}
+	return "OPTIONS, PROPFIND"
+}
+
+// propfind PROPFIND: Depth 0 — сам ресурс, 1 (и infinity) — ещё и вложенные
+func (h *CalDAVHandler) propfind(w http.ResponseWriter, r *http.Request, p davPath) {
+	req, err := parsePropfind(r.Body)
+	if err != nil {
+		writeErrorCode(w, err.Error(), "invalid_xml", http.StatusBadRequest)
+		return
+	}
+	depth := r.Header.Get("Depth") != "0"
+
+	ctx := r.Context()
+	var resources []davResource
+	switch p.kind {
+	case davRootKind:
+		resources = append(resources, davResource{href: davPrefix, props: principalProps(p.userID, false)})
+	case davPrincipalKind:
+		resources = append(resources, davResource{href: principalHref(p.userID), props: principalProps(p.userID, true)})
+	case davHomeKind:
+		resources = append(resources, davResource{href: homeHref(p.userID), props: homeProps(p.userID)})
+		if depth {
+			calendar, err := h.calendarResource(ctx, p.userID)
+			if err != nil {
+				writeUsecaseError(w, err)
+				return
+			}
+			resources = append(resources, calendar)
+		}
+	case davCalendarKind:
+		calendar, err := h.calendarResource(ctx, p.userID)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		resources = append(resources, calendar)
+		if depth {
+			events, err := h.windowEvents(ctx, p.userID)
+			if err != nil {
+				writeUsecaseError(w, err)
+				return
+			}
+			for _, event := range events {
+				resources = append(resources, h.eventResource(p.userID, event, req.wants(nsCalDAV, "calendar-data")))
+			}
+		}
+	case davEventKind:
+		event, err := h.event(ctx, p.userID, p.uid)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		resources = append(resources, h.eventResource(p.userID, event, req.wants(nsCalDAV, "calendar-data")))
+	}
+
+	responses := make([]davResponse, len(resources))
+	for i, res := range resources {
+		responses[i] = res.response(req)
+	}
+	writeMultistatus(w, responses)
+}
+
+// report REPORT календаря: calendar-query и calendar-multiget
+func (h *CalDAVHandler) report(w http.ResponseWriter, r *http.Request, p davPath) {
+	var req reportRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorCode(w, "invalid XML body", "invalid_xml", http.StatusBadRequest)
+		return
+	}
+	props := propRequest{names: req.Prop.Names}
+	if len(props.names) == 0 {
+		props.names = []xml.Name{{Space: nsDAV, Local: "getetag"}, {Space: nsCalDAV, Local: "calendar-data"}}
+	}
+	withData := props.wants(nsCalDAV, "calendar-data")
+
+	ctx := r.Context()
+	var responses []davResponse
+	switch req.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		now := h.now()
+		from, to, ok, err := req.queryRange(now.Add(-davWindow), now.Add(davWindow))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !ok {
+			break
+		}
+		events, err := h.eventsBetween(ctx, p.userID, from, to)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		for _, event := range events {
+			responses = append(responses, h.eventResource(p.userID, event, withData).response(props))
+		}
+
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, href := range req.Hrefs {
+			target, ok := parseDAVPath(hrefPath(href))
+			if !ok || target.kind != davEventKind || target.userID != p.userID {
+				responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+				continue
+			}
+			event, err := h.event(ctx, p.userID, target.uid)
+			if errors.Is(err, domain.ErrEventNotFound) {
+				responses = append(responses, davResponse{Href: href, Status: davStatus(http.StatusNotFound)})
+				continue
+			}
+			if err != nil {
+				writeUsecaseError(w, err)
+				return
+			}
+			responses = append(responses, h.eventResource(p.userID, event, withData).response(props))
+		}
+
+	default:
+		writeErrorCode(w, "only calendar-query and calendar-multiget reports are supported", "unsupported_report", http.StatusForbidden)
+		return
+	}
+	writeMultistatus(w, responses)
+}
+
+// hrefPath путь из href multiget: абсолютный URL или путь, в экранированном виде
+func hrefPath(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	return u.EscapedPath()
+}
+
+// get GET события: VCALENDAR с одним VEVENT
+func (h *CalDAVHandler) get(w http.ResponseWriter, r *http.Request, p davPath) {
+	event, err := h.event(r.Context(), p.userID, p.uid)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	data, err := h.calendarData(event)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, event)
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+// put PUT события: создаёт или заменяет событие целиком. If-None-Match: * — только создать,
+// If-Match — заменить, только если версия совпадает.
+func (h *CalDAVHandler) put(w http.ResponseWriter, r *http.Request, p davPath) {
+	ctx := r.Context()
+	loc, err := h.location(ctx, p.userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	decoder := ical.NewDecoder(r.Body)
+	decoder.Location = loc
+	items, err := decoder.Decode()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErrorCode(w, "calendar is too large", "payload_too_large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeErrorCode(w, err.Error(), "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+	if len(items) != 1 || items[0].Cancelled {
+		writeErrorCode(w, "resource must contain exactly one event", "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+	item := items[0]
+	if item.Err != nil {
+		writeErrorCode(w, item.Err.Error(), "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+	if item.UID != p.uid {
+		writeErrorCode(w, "UID of the event must match the resource name", "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.event(ctx, p.userID, p.uid)
+	exists := err == nil
+	if err != nil && !errors.Is(err, domain.ErrEventNotFound) {
+		writeUsecaseError(w, err)
+		return
+	}
+	version, matchPresent, err := parseIfMatch(r)
+	switch {
+	case err != nil:
+		writeErrorCode(w, err.Error(), "version_mismatch", http.StatusPreconditionFailed)
+		return
+	case exists && strings.TrimSpace(r.Header.Get("If-None-Match")) == "*":
+		writeErrorCode(w, "resource already exists", "already_exists", http.StatusPreconditionFailed)
+		return
+	case !exists && matchPresent:
+		writeUsecaseError(w, domain.ErrVersionMismatch)
+		return
+	}
+	if _, native := ical.EventID(p.uid); native && !exists {
+		// Такой UID выдал бы сервер другому событию
+		writeErrorCode(w, "UID is reserved for events created by the server", "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+
+	// Версия из If-Match проверяется при сохранении, а не здесь: между чтением и записью событие могло измениться
+	event := item.Event
+	event.UserId, event.Version = p.userID, version
+	if exists && current.ExternalUID == "" {
+		// UID выдан сервером, событие не импортированное
+		event.EventId, event.ExternalUID = current.EventId, ""
+		err = h.usecases.ReplaceEvent(ctx, &event)
+	} else {
+		_, err = h.usecases.ImportEvent(ctx, &event)
+	}
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	saved, err := h.event(ctx, p.userID, p.uid)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, saved)
+	if exists {
+		h.logger.Writef("caldav: event %d updated", saved.EventId)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.logger.Writef("caldav: event %d created", saved.EventId)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// delete DELETE события; If-Match — удалить, только если версия совпадает
+func (h *CalDAVHandler) delete(w http.ResponseWriter, r *http.Request, p davPath) {
+	version, ok := ifMatchVersion(w, r, false)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	event, err := h.event(ctx, p.userID, p.uid)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.usecases.DeleteEvent(ctx, p.userID, event.EventId, version, domain.ScopeAll, time.Time{}); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	h.logger.Writef("caldav: event %d deleted", event.EventId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// event событие по UID ресурса: выданный сервером UID — по id, остальные — по UID импорта
+func (h *CalDAVHandler) event(ctx context.Context, userID int64, uid string) (domain.Event, error) {
+	if id, ok := ical.EventID(uid); ok {
+		event, err := h.usecases.GetEvent(ctx, userID, id)
+		if err == nil && event.ExternalUID == "" {
+			return event, nil
+		}
+		if err != nil && !errors.Is(err, domain.ErrEventNotFound) {
+			return domain.Event{}, err
+		}
+	}
+	return h.usecases.GetEventByExternalUID(ctx, userID, uid)
+}
+
+// windowEvents события календаря CalDAV
+func (h *CalDAVHandler) windowEvents(ctx context.Context, userID int64) ([]domain.Event, error) {
+	now := h.now()
+	return h.eventsBetween(ctx, userID, now.Add(-davWindow), now.Add(davWindow))
+}
+
+// eventsBetween неархивные события за [from, to): серия — одним событием. Интервал запрашивается частями,
+// потому что ListEvents не принимает интервалы длиннее года.
+func (h *CalDAVHandler) eventsBetween(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	var events []domain.Event
+	seen := make(map[int64]bool)
+	for start := from; start.Before(to); start = start.Add(davChunk) {
+		end := minTime(start.Add(davChunk), to)
+		chunk, err := calendarEvents(ctx, h.usecases, domain.EventQuery{UserId: userID, From: start, To: end})
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range chunk {
+			if !seen[event.EventId] {
+				seen[event.EventId] = true
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// location пояс пользователя для «плавающего» времени
+func (h *CalDAVHandler) location(ctx context.Context, userID int64) (*time.Location, error) {
+	settings, err := h.users.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := domain.LoadLocation(settings.TimeZone)
+	if err != nil {
+		return nil, domain.NewValidationError("invalid_time_zone", "%v", err)
+	}
+	return loc, nil
+}
+
+// calendarData событие в формате iCalendar
+func (h *CalDAVHandler) calendarData(event domain.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := ical.NewEncoder(&buf)
+	encoder.Stamp = h.now()
+	if err := encoder.Encode([]domain.Event{event}); err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// calendarResource календарь со свойствами; getctag — хеш id и версий его событий
+func (h *CalDAVHandler) calendarResource(ctx context.Context, userID int64) (davResource, error) {
+	events, err := h.windowEvents(ctx, userID)
+	if err != nil {
+		return davResource{}, err
+	}
+	tags := make([]string, len(events))
+	for i, event := range events {
+		tags[i] = fmt.Sprintf("%d:%d", event.EventId, event.Version)
+	}
+	slices.Sort(tags)
+	sum := sha256.Sum256([]byte(strings.Join(tags, ",")))
+	ctag := hex.EncodeToString(sum[:])
+
+	props := []davProp{
+		xmlProp(nsDAV, "resourcetype", "<d:collection/><c:calendar/>"),
+		textProp(nsDAV, "displayname", "Календарь"),
+		hrefProp(nsDAV, "current-user-principal", principalHref(userID)),
+		hrefProp(nsDAV, "owner", principalHref(userID)),
+		xmlProp(nsDAV, "current-user-privilege-set", "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>"),
+		xmlProp(nsDAV, "supported-report-set",
+			"<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>"+
+				"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>"),
+		xmlProp(nsCalDAV, "supported-calendar-component-set", `<c:comp name="VEVENT"/>`),
+		textProp(nsCalServer, "getctag", ctag),
+	}
+	return davResource{href: calendarHref(userID), props: props}, nil
+}
+
+// eventResource событие со свойствами; calendar-data только по запросу
+func (h *CalDAVHandler) eventResource(userID int64, event domain.Event, withData bool) davResource {
+	props := []davProp{
+		xmlProp(nsDAV, "resourcetype", ""),
+		textProp(nsDAV, "getetag", strconv.Quote(strconv.FormatInt(event.Version, 10))),
+		textProp(nsDAV, "getcontenttype", davEventContentType),
+		hrefProp(nsDAV, "current-user-principal", principalHref(userID)),
+	}
+	if withData {
+		if data, err := h.calendarData(event); err == nil {
+			props = append(props, textProp(nsCalDAV, "calendar-data", string(data)))
+		} else {
+			h.logger.Writef("caldav: %v", err)
+		}
+	}
+	return davResource{href: eventHref(userID, ical.UID(event)), props: props}
+}
+
+// principalProps свойства точки входа и пользователя
+func principalProps(userID int64, principal bool) []davProp {
+	resourceType := "<d:collection/>"
+	if principal {
+		resourceType = "<d:principal/>"
+	}
+	return []davProp{
+		xmlProp(nsDAV, "resourcetype", resourceType),
+		hrefProp(nsDAV, "current-user-principal", principalHref(userID)),
+		hrefProp(nsDAV, "principal-URL", principalHref(userID)),
+		hrefProp(nsCalDAV, "calendar-home-set", homeHref(userID)),
+	}
+}
+
+// homeProps свойства домашней коллекции
+func homeProps(userID int64) []davProp {
+	return []davProp{
+		xmlProp(nsDAV, "resourcetype", "<d:collection/>"),
+		hrefProp(nsDAV, "current-user-principal", principalHref(userID)),
+		hrefProp(nsDAV, "owner", principalHref(userID)),
+	}
+}
+
+// Разбор запросов и запись ответов в XML.
+
+// propRequest запрошенные свойства: все (allprop, пустое тело), только имена (propname) или перечисленные
+type propRequest struct {
+	all      bool
+	nameOnly bool
+	names    []xml.Name
+}
+
+// wants запрошено ли свойство явно
+func (req propRequest) wants(space, local string) bool {
+	return slices.Contains(req.names, xml.Name{Space: space, Local: local})
+}
+
+// propList имена свойств элемента prop
+type propList struct {
+	Names []xml.Name
+}
+
+func (l *propList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			l.Names = append(l.Names, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type propfindBody struct {
+	AllProp  *struct{} `xml:"DAV: allprop"`
+	PropName *struct{} `xml:"DAV: propname"`
+	Prop     *propList `xml:"DAV: prop"`
+}
+
+// parsePropfind тело PROPFIND; пустое тело — allprop
+func parsePropfind(body io.Reader) (propRequest, error) {
+	data, err := io.ReadAll(io.LimitReader(body, 1<<20))
+	if err != nil {
+		return propRequest{}, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return propRequest{all: true}, nil
+	}
+	var req propfindBody
+	if err := xml.Unmarshal(data, &req); err != nil {
+		return propRequest{}, errors.New("invalid XML body")
+	}
+	switch {
+	case req.Prop != nil:
+		return propRequest{names: req.Prop.Names}, nil
+	case req.PropName != nil:
+		return propRequest{all: true, nameOnly: true}, nil
+	}
+	return propRequest{all: true}, nil
+}
+
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    propList `xml:"DAV: prop"`
+	Hrefs   []string `xml:"DAV: href"`
+	Filter  *struct {
+		Comps []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type compFilter struct {
+	Name      string       `xml:"name,attr"`
+	TimeRange *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	Comps     []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// queryRange интервал calendar-query внутри [from, to): фильтр VCALENDAR > VEVENT с необязательным time-range.
+// ok false — запрошены не события (например, задачи VTODO), ответ пустой.
+func (req reportRequest) queryRange(from, to time.Time) (time.Time, time.Time, bool, error) {
+	if req.Filter == nil || len(req.Filter.Comps) == 0 {
+		return from, to, true, nil
+	}
+	calendar := req.Filter.Comps[0]
+	if calendar.Name != "VCALENDAR" {
+		return from, to, false, nil
+	}
+	if len(calendar.Comps) == 0 {
+		return from, to, true, nil
+	}
+	i := slices.IndexFunc(calendar.Comps, func(c compFilter) bool { return c.Name == "VEVENT" })
+	if i < 0 {
+		return from, to, false, nil
+	}
+	if tr := calendar.Comps[i].TimeRange; tr != nil {
+		if tr.Start != "" {
+			start, err := time.Parse(davTimeLayout, tr.Start)
+			if err != nil {
+				return from, to, false, errors.New("time-range start must be UTC time like 20060102T150405Z")
+			}
+			from = maxTime(from, start)
+		}
+		if tr.End != "" {
+			end, err := time.Parse(davTimeLayout, tr.End)
+			if err != nil {
+				return from, to, false, errors.New("time-range end must be UTC time like 20060102T150405Z")
+			}
+			to = minTime(to, end)
+		}
+	}
+	return from, to, from.Before(to), nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// davProp свойство ресурса; XMLName — имя с префиксом из davPrefixes для ответа
+type davProp struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+	Inner   string `xml:",innerxml"`
+	// name имя свойства с пространством имён для сравнения с запрошенными
+	name xml.Name
+}
+
+func newProp(space, local string) davProp {
+	return davProp{XMLName: davName(space, local), name: xml.Name{Space: space, Local: local}}
+}
+
+func textProp(space, local, text string) davProp {
+	p := newProp(space, local)
+	p.Text = text
+	return p
+}
+
+// xmlProp свойство с готовым XML внутри; префиксы — из davPrefixes
+func xmlProp(space, local, inner string) davProp {
+	p := newProp(space, local)
+	p.Inner = inner
+	return p
+}
+
+func hrefProp(space, local, href string) davProp {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(href))
+	return xmlProp(space, local, "<d:href>"+buf.String()+"</d:href>")
+}
+
+// davName имя элемента для ответа: известные пространства имён — префиксом, остальные — атрибутом xmlns
+func davName(space, local string) xml.Name {
+	if prefix, ok := davPrefixes[space]; ok {
+		return xml.Name{Local: prefix + ":" + local}
+	}
+	return xml.Name{Space: space, Local: local}
+}
+
+// davResource ресурс в ответе PROPFIND и REPORT: href и все его свойства
+type davResource struct {
+	href  string
+	props []davProp
+}
+
+// response ответ по ресурсу: найденные свойства — 200, неизвестные — 404
+func (res davResource) response(req propRequest) davResponse {
+	response := davResponse{Href: res.href}
+	if req.all {
+		props := res.props
+		if req.nameOnly {
+			props = make([]davProp, len(res.props))
+			for i, p := range res.props {
+				props[i] = newProp(p.name.Space, p.name.Local)
+			}
+		}
+		response.Propstats = []davPropstat{newPropstat(props, http.StatusOK)}
+		return response
+	}
+
+	var found, missing []davProp
+	for _, name := range req.names {
+		i := slices.IndexFunc(res.props, func(p davProp) bool { return p.name == name })
+		if i < 0 {
+			missing = append(missing, newProp(name.Space, name.Local))
+			continue
+		}
+		found = append(found, res.props[i])
+	}
+	if len(found) > 0 {
+		response.Propstats = append(response.Propstats, newPropstat(found, http.StatusOK))
+	}
+	if len(missing) > 0 {
+		response.Propstats = append(response.Propstats, newPropstat(missing, http.StatusNotFound))
+	}
+	return response
+}
+
+type davMultistatus struct {
+	XMLName   xml.Name      `xml:"d:multistatus"`
+	DAV       string        `xml:"xmlns:d,attr"`
+	CalDAV    string        `xml:"xmlns:c,attr"`
+	CalServer string        `xml:"xmlns:cs,attr"`
+	Responses []davResponse `xml:"d:response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"d:href"`
+	Propstats []davPropstat `xml:"d:propstat,omitempty"`
+	// Status статус ресурса целиком, например 404 в calendar-multiget
+	Status string `xml:"d:status,omitempty"`
+}
+
+type davPropstat struct {
+	Prop struct {
+		// Props без тега: имя элемента — XMLName свойства
+		Props []davProp
+	} `xml:"d:prop"`
+	Status string `xml:"d:status"`
+}
+
+func newPropstat(props []davProp, code int) davPropstat {
+	ps := davPropstat{Status: davStatus(code)}
+	ps.Prop.Props = props
+	return ps
+}
+
+func davStatus(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// writeMultistatus пишет ответ 207 Multi-Status
+func writeMultistatus(w http.ResponseWriter, responses []davResponse) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = io.WriteString(w, xml.Header)
+	ms := davMultistatus{DAV: nsDAV, CalDAV: nsCalDAV, CalServer: nsCalServer, Responses: responses}
+	if err := xml.NewEncoder(w).Encode(ms); err != nil {
+		// Заголовки уже отправлены, ответ с ошибкой не вернуть
+		log.Printf("failed to write multistatus: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// davAuth API-ключ cal_user паролем Basic, как его передают календарные приложения
+var davAuth = "Basic " + base64.StdEncoding.EncodeToString([]byte("user:cal_user"))
+
+func davEvent(uid, summary string, start time.Time) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//Client//EN\r\n" +
+		"BEGIN:VEVENT\r\nUID:" + uid + "\r\nDTSTAMP:20260101T000000Z\r\n" +
+		"DTSTART:" + start.UTC().Format(davTimeLayout) + "\r\n" +
+		"DTEND:" + start.Add(time.Hour).UTC().Format(davTimeLayout) + "\r\n" +
+		"SUMMARY:" + summary + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+var ctagPattern = regexp.MustCompile(`<cs:getctag>([0-9a-f]+)</cs:getctag>`)
+
+// TestServer_CalDAV разговор календарного приложения с сервером: поиск календаря, синхронизация,
+// создание, изменение и удаление событий
+func TestServer_CalDAV(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+
+	dav := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", davAuth)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	expect := func(w *httptest.ResponseRecorder, status int, fragments ...string) {
+		t.Helper()
+		if w.Code != status {
+			t.Fatalf("Expected status %d, got %d: %s", status, w.Code, w.Body.String())
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(w.Body.String(), fragment) {
+				t.Errorf("Expected %q in response:\n%s", fragment, w.Body.String())
+			}
+		}
+	}
+	ctag := func() string {
+		t.Helper()
+		w := dav("PROPFIND", "/dav/calendars/1/events/",
+			`<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/"><d:prop><cs:getctag/></d:prop></d:propfind>`,
+			"Depth", "0")
+		expect(w, http.StatusMultiStatus)
+		m := ctagPattern.FindStringSubmatch(w.Body.String())
+		if m == nil {
+			t.Fatalf("No getctag in response:\n%s", w.Body.String())
+		}
+		return m[1]
+	}
+
+	// Поиск календаря
+	w := dav("PROPFIND", "/.well-known/caldav", "")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/dav/" {
+		t.Fatalf("Expected redirect to /dav/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	w = dav("OPTIONS", "/dav/calendars/1/events/", "")
+	// Класс 2 не объявляется: LOCK и UNLOCK не поддерживаются
+	if dav := w.Header().Get("DAV"); dav != "1, calendar-access" {
+		t.Errorf("Expected DAV: 1, calendar-access, got %q", dav)
+	}
+	w = dav("PROPFIND", "/dav/",
+		`<?xml version="1.0"?><propfind xmlns="DAV:"><prop><current-user-principal/><displayname/></prop></propfind>`,
+		"Depth", "0")
+	expect(w, http.StatusMultiStatus,
+		`<d:current-user-principal><d:href>/dav/principals/1/</d:href></d:current-user-principal>`,
+		`<d:status>HTTP/1.1 404 Not Found</d:status>`)
+	w = dav("PROPFIND", "/dav/principals/1/",
+		`<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-home-set/></d:prop></d:propfind>`,
+		"Depth", "0")
+	expect(w, http.StatusMultiStatus, `<c:calendar-home-set><d:href>/dav/calendars/1/</d:href></c:calendar-home-set>`)
+	w = dav("PROPFIND", "/dav/calendars/1/", "", "Depth", "1")
+	expect(w, http.StatusMultiStatus,
+		`<d:href>/dav/calendars/1/events/</d:href>`,
+		`<d:resourcetype><d:collection/><c:calendar/></d:resourcetype>`,
+		`<c:comp name="VEVENT"/>`)
+	emptyTag := ctag()
+
+	// Создание события
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	path := "/dav/calendars/1/events/dentist@example.com.ics"
+	w = dav("PUT", path, davEvent("dentist@example.com", "Dentist", start), "If-None-Match", "*")
+	expect(w, http.StatusCreated)
+	etag := w.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Errorf(`Expected ETag "1", got %q`, etag)
+	}
+	w = dav("PUT", path, davEvent("dentist@example.com", "Dentist", start), "If-None-Match", "*")
+	expect(w, http.StatusPreconditionFailed)
+	w = dav("PUT", "/dav/calendars/1/events/other.ics", davEvent("dentist@example.com", "Dentist", start))
+	expect(w, http.StatusBadRequest)
+
+	createdTag := ctag()
+	if createdTag == emptyTag {
+		t.Error("Expected getctag to change after PUT")
+	}
+
+	// Синхронизация: список ETag, затем данные изменённых ресурсов
+	w = dav("PROPFIND", "/dav/calendars/1/events/",
+		`<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/></d:prop></d:propfind>`, "Depth", "1")
+	expect(w, http.StatusMultiStatus, `<d:href>`+path+`</d:href>`, `<d:getetag>&#34;1&#34;</d:getetag>`)
+	w = dav("REPORT", "/dav/calendars/1/events/",
+		`<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`+
+			`<d:prop><d:getetag/><c:calendar-data/></d:prop>`+
+			`<d:href>`+path+`</d:href><d:href>/dav/calendars/1/events/missing.ics</d:href>`+
+			`</c:calendar-multiget>`)
+	expect(w, http.StatusMultiStatus, "UID:dentist@example.com", "SUMMARY:Dentist",
+		`<d:href>/dav/calendars/1/events/missing.ics</d:href><d:status>HTTP/1.1 404 Not Found</d:status>`)
+
+	query := func(component string) string {
+		return `<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">` +
+			`<d:prop><d:getetag/></d:prop><c:filter><c:comp-filter name="VCALENDAR">` +
+			`<c:comp-filter name="` + component + `"><c:time-range start="` +
+			time.Now().UTC().Format(davTimeLayout) + `" end="` + time.Now().Add(30*24*time.Hour).UTC().Format(davTimeLayout) + `"/>` +
+			`</c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+	}
+	w = dav("REPORT", "/dav/calendars/1/events/", query("VEVENT"), "Depth", "1")
+	expect(w, http.StatusMultiStatus, `<d:href>`+path+`</d:href>`)
+	if strings.Contains(w.Body.String(), "calendar-data") {
+		t.Error("Expected no calendar-data when only getetag is requested")
+	}
+	w = dav("REPORT", "/dav/calendars/1/events/", query("VTODO"), "Depth", "1")
+	expect(w, http.StatusMultiStatus)
+	if strings.Contains(w.Body.String(), "<d:response>") {
+		t.Errorf("Expected no events for VTODO query, got:\n%s", w.Body.String())
+	}
+
+	w = dav("GET", path, "")
+	expect(w, http.StatusOK, "BEGIN:VCALENDAR", "UID:dentist@example.com")
+	if w.Header().Get("ETag") != etag {
+		t.Errorf("Expected ETag %s on GET, got %q", etag, w.Header().Get("ETag"))
+	}
+
+	// Изменение: устаревший ETag отклоняется
+	w = dav("PUT", path, davEvent("dentist@example.com", "Dentist, room 4", start), "If-Match", `"7"`)
+	expect(w, http.StatusPreconditionFailed)
+	w = dav("PUT", path, davEvent("dentist@example.com", "Dentist, room 4", start), "If-Match", etag)
+	expect(w, http.StatusNoContent)
+	if w.Header().Get("ETag") == etag {
+		t.Error("Expected new ETag after update")
+	}
+	if ctag() == createdTag {
+		t.Error("Expected getctag to change after update")
+	}
+
+	// Событие, созданное через API, видно под выданным сервером UID и меняется через UpdateEvent
+	native := &domain.Event{UserId: 1, Date: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour), Description: "Planning"}
+	_ = usecases.CreateEvent(context.Background(), native)
+	nativePath := eventHref(1, "event-2@calendar")
+	w = dav("PROPFIND", "/dav/calendars/1/events/", "", "Depth", "1")
+	expect(w, http.StatusMultiStatus, `<d:href>`+nativePath+`</d:href>`)
+	w = dav("PUT", nativePath, davEvent("event-2@calendar", "Planning, moved", start.Add(26*time.Hour)), "If-Match", `"1"`)
+	expect(w, http.StatusNoContent)
+	if e := usecases.events[native.EventId]; e.Description != "Planning, moved" || e.ExternalUID != "" || !e.Date.Equal(start.Add(26*time.Hour)) {
+		t.Errorf("Unexpected native event after PUT: %+v", e)
+	}
+	w = dav("PUT", "/dav/calendars/1/events/event-99@calendar.ics", davEvent("event-99@calendar", "Reserved", start))
+	expect(w, http.StatusBadRequest)
+
+	// Удаление
+	w = dav("DELETE", path, "", "If-Match", etag)
+	expect(w, http.StatusPreconditionFailed)
+	w = dav("DELETE", path, "", "If-Match", `"2"`)
+	expect(w, http.StatusNoContent)
+	expect(dav("GET", path, ""), http.StatusNotFound)
+
+	// Чужой календарь и запросы без учётных данных
+	expect(dav("PROPFIND", "/dav/calendars/2/events/", "", "Depth", "0"), http.StatusForbidden)
+	req := httptest.NewRequest("PROPFIND", "/dav/", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(strings.Join(w.Header().Values("WWW-Authenticate"), ","), "Basic") {
+		t.Errorf("Expected 401 with Basic challenge, got %d %v", w.Code, w.Header().Values("WWW-Authenticate"))
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/dontpanicw/calendar/internal/adapter/ical"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+// ExportCalendarV1 GET /v1/users/{id}/calendar.ics?from=...&to=... — события пользователя за [from, to)
+// в формате iCalendar. Границы и include_archived — как у /v1/events.
+func (h *Handler) ExportCalendarV1(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(w, "user id must be positive integer", http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	loc, err := h.userLocation(r, userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	query := domain.EventQuery{UserId: userID, IncludeArchived: includeArchived(r)}
+	if query.From, err = parseRangeBound(r.URL.Query().Get("from"), loc); err != nil {
+		writeError(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.To, err = parseRangeBound(r.URL.Query().Get("to"), loc); err != nil {
+		writeError(w, "to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := calendarEvents(r.Context(), h.usecases, query)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	if err := ical.NewEncoder(w).Encode(events); err != nil {
+		// Заголовки уже отправлены, ответ с ошибкой не вернуть
+		log.Printf("failed to export calendar: %v", err)
+	}
+}
+
+// calendarEvents события за интервал для выгрузки: вместо повторений серии — сама серия
+// с правилом, исключениями и переносами, по одному разу
+func calendarEvents(ctx context.Context, usecases port.EventUsecases, query domain.EventQuery) ([]domain.Event, error) {
+	page, err := usecases.ListEvents(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	var events []domain.Event
+	seen := make(map[int64]bool)
+	for _, event := range page.Events {
+		if event.SeriesId == 0 {
+			events = append(events, event)
+			continue
+		}
+		if seen[event.SeriesId] {
+			continue
+		}
+		seen[event.SeriesId] = true
+		series, err := usecases.GetEvent(ctx, query.UserId, event.SeriesId)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, series)
+	}
+	return events, nil
+}
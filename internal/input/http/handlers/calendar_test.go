@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/ical"
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestServer_ExportCalendarV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	start := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start, End: start.Add(time.Hour), Description: "Standup", Recurrence: rule})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(3 * time.Hour), Description: "Lunch"})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(4 * time.Hour), Description: "Old", IsArchived: true})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 2, Date: start, Description: "Other user"})
+
+	req := httptest.NewRequest("GET", "/v1/users/1/calendar.ics?from=2026-03-15&to=2026-03-22", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ical.ContentType {
+		t.Errorf("Expected Content-Type %q, got %q", ct, ical.ContentType)
+	}
+	body := w.Body.String()
+	// Серия выгружается один раз с RRULE, а не семью повторениями
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("Expected 2 events, got %d:\n%s", n, body)
+	}
+	for _, want := range []string{"UID:" + ical.UID(domain.Event{EventId: 1}), "RRULE:FREQ=DAILY", "SUMMARY:Lunch"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in calendar:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Other user") || strings.Contains(body, "SUMMARY:Old") {
+		t.Errorf("Unexpected event in calendar:\n%s", body)
+	}
+
+	for _, path := range []string{
+		"/v1/users/1/calendar.ics?from=2026-03-15",
+		"/v1/users/x/calendar.ics?from=2026-03-15&to=2026-03-22",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// Версия события отдаётся как ETag "N" и проверяется по If-Match при изменении и удалении.
+
+// setETag пишет ETag с версией события
+func setETag(w http.ResponseWriter, event domain.Event) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(event.Version, 10)))
+}
+
+// parseIfMatch версия из If-Match: "N" или W/"N"; * и отсутствующий заголовок — 0, без проверки.
+// present — заголовок передан.
+func parseIfMatch(r *http.Request) (version int64, present bool, err error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, false, nil
+	}
+	if value == "*" {
+		return 0, true, nil
+	}
+	tag, err := strconv.Unquote(strings.TrimPrefix(value, "W/"))
+	if err == nil {
+		version, err = strconv.ParseInt(tag, 10, 64)
+	}
+	if err != nil || version <= 0 {
+		return 0, true, errors.New("If-Match must be an ETag returned by the server")
+	}
+	return version, true, nil
+}
+
+// ifMatchVersion версия из If-Match. required — без заголовка ответить 428.
+// При ошибке пишет ответ и возвращает false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, required bool) (int64, bool) {
+	version, present, err := parseIfMatch(r)
+	if err != nil {
+		// Такой тег не совпадает ни с одной версией события
+		writeErrorCode(w, err.Error(), "version_mismatch", http.StatusPreconditionFailed)
+		return 0, false
+	}
+	if required && !present {
+		writeError(w, "If-Match header is required, use the ETag of the event", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	return version, true
+}
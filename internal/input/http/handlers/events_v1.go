@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+)
+
+// REST API /v1/events. Тела запросов те же, что у /create_event и /update_event,
+// но id события берётся из пути, а ответы — сами ресурсы и HTTP-статусы вместо {"result": ...}.
+
+// defaultPageSize страница /v1/events без limit
+const defaultPageSize = 100
+
+// ListEventsV1 GET /v1/events?from=...&to=...&limit=...&page_token=... — события, пересекающиеся с [from, to).
+// Фильтры: include_archived=true, q — подстрока описания; sort=asc|desc — порядок по началу.
+func (h *Handler) ListEventsV1(w http.ResponseWriter, r *http.Request) {
+	h.listEvents(w, r, false)
+}
+
+// ListArchiveV1 GET /v1/archive — архивные события, по умолчанию за последний год и сначала новые.
+// Параметры те же, что у /v1/events.
+func (h *Handler) ListArchiveV1(w http.ResponseWriter, r *http.Request) {
+	h.listEvents(w, r, true)
+}
+
+func (h *Handler) listEvents(w http.ResponseWriter, r *http.Request, archive bool) {
+	query := r.URL.Query()
+	userID, err := parseUserID(query.Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	loc, err := h.userLocation(r, userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	// Архив просматривают без интервала: по умолчанию год до конца сегодняшнего дня, сначала новые
+	var from, to time.Time
+	if archive && !query.Has("to") {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	} else if to, err = parseRangeBound(query.Get("to"), loc); err != nil {
+		writeError(w, "to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if archive && !query.Has("from") {
+		from = to.AddDate(0, 0, -365)
+	} else if from, err = parseRangeBound(query.Get("from"), loc); err != nil {
+		writeError(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if archive && !query.Has("sort") {
+		query.Set("sort", string(domain.SortDesc))
+	}
+	eventQuery := domain.EventQuery{
+		UserId:          userID,
+		From:            from,
+		To:              to,
+		IncludeArchived: includeArchived(r),
+		OnlyArchived:    archive,
+		Text:            query.Get("q"),
+		Sort:            domain.SortOrder(query.Get("sort")),
+		Limit:           defaultPageSize,
+	}
+	if s := query.Get("limit"); s != "" {
+		if eventQuery.Limit, err = strconv.Atoi(s); err != nil || eventQuery.Limit <= 0 {
+			writeError(w, "limit must be positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if token := query.Get("page_token"); token != "" {
+		cursor, err := domain.ParseEventCursor(token)
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		eventQuery.After = &cursor
+	}
+
+	page, err := h.usecases.ListEvents(r.Context(), eventQuery)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	response := types.EventListResponse{Events: page.Events}
+	if response.Events == nil {
+		response.Events = []domain.Event{}
+	}
+	if page.Next != nil {
+		response.NextPageToken = page.Next.String()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// CreateEventV1 POST /v1/events — 201, Location и созданное событие
+func (h *Handler) CreateEventV1(w http.ResponseWriter, r *http.Request) {
+	event, err := parseBodyCreate(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if event.UserId, err = actingUser(r, event.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.fillTimeZone(r, event); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.usecases.CreateEvent(r.Context(), event); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Location", eventLocation(event.EventId))
+	setETag(w, *event)
+	writeJSON(w, http.StatusCreated, event)
+}
+
+// GetEventV1 GET /v1/events/{id}
+func (h *Handler) GetEventV1(w http.ResponseWriter, r *http.Request) {
+	eventID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	event, err := h.usecases.GetEvent(r.Context(), userID, eventID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, event)
+}
+
+// UpdateEventV1 PUT /v1/events/{id} — полная замена события, 204. Требует If-Match.
+func (h *Handler) UpdateEventV1(w http.ResponseWriter, r *http.Request) {
+	eventID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, true)
+	if !ok {
+		return
+	}
+	event, scope, err := parseBodyUpdate(r, eventID)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if event.UserId, err = actingUser(r, event.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	event.Version = version
+	if err := h.fillTimeZone(r, &event); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.usecases.UpdateEvent(r.Context(), event, scope); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PatchEventV1 PATCH /v1/events/{id} — частичное изменение по RFC 7396, 200 и событие после изменения.
+// Требует If-Match.
+func (h *Handler) PatchEventV1(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); !strings.Contains(ct, "application/merge-patch+json") && !strings.Contains(ct, "application/json") {
+		writeErrorCode(w, "use Content-Type application/merge-patch+json", "unsupported_media_type", http.StatusUnsupportedMediaType)
+		return
+	}
+	eventID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, true)
+	if !ok {
+		return
+	}
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	patch, err := parseEventPatch(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := h.usecases.PatchEvent(r.Context(), userID, eventID, version, patch)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, event)
+}
+
+// DeleteEventV1 DELETE /v1/events/{id}?scope=...&original_start=... — 204. Требует If-Match.
+func (h *Handler) DeleteEventV1(w http.ResponseWriter, r *http.Request) {
+	eventID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r, true)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	userID, err := parseUserID(query.Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	scope, occurrence, err := parseScope(query.Get("scope"), query.Get("original_start"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.usecases.DeleteEvent(r.Context(), userID, eventID, version, scope, occurrence); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EventActionV1 POST /v1/events/{id}:archive и /v1/events/{id}:unarchive — 200 и событие после изменения.
+// If-Match необязателен: повторный перенос в архив ничего не меняет.
+func (h *Handler) EventActionV1(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(r.PathValue("id"), ":")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || eventID <= 0 {
+		writeError(w, "event id must be positive integer", http.StatusBadRequest)
+		return
+	}
+	var apply func(ctx context.Context, userID, eventId, version int64) (domain.Event, error)
+	switch action {
+	case "archive":
+		apply = h.usecases.ArchiveEvent
+	case "unarchive":
+		apply = h.usecases.UnarchiveEvent
+	default:
+		writeError(w, "unknown event action, use :archive or :unarchive", http.StatusNotFound)
+		return
+	}
+	version, ok := ifMatchVersion(w, r, false)
+	if !ok {
+		return
+	}
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	event, err := apply(r.Context(), userID, eventID, version)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, event)
+	writeJSON(w, http.StatusOK, event)
+}
+
+// parseEventPatch парсит JSON Merge Patch события. Ключи — поля представления события (как в ответе GET),
+// null сбрасывает поле. Дата YYYY-MM-DD в date означает событие на весь день, в end — последний день включительно.
+func parseEventPatch(r *http.Request) (domain.EventPatch, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil || fields == nil {
+		return domain.EventPatch{}, errors.New("patch must be a JSON object")
+	}
+
+	var patch domain.EventPatch
+	dateOnly := false
+	for name, raw := range fields {
+		var err error
+		switch name {
+		case "date":
+			var s string
+			if s, err = patchString(raw); err == nil {
+				patch.Date.Set = true
+				patch.Date.Value, dateOnly, err = parsePatchTime(s)
+			}
+		case "end":
+			var s string
+			if s, err = patchString(raw); err == nil && s != "" {
+				var isDate bool
+				patch.End.Value, isDate, err = parsePatchTime(s)
+				if isDate {
+					patch.End.Value = patch.End.Value.AddDate(0, 0, 1)
+				}
+			}
+			patch.End.Set = true
+		case "all_day":
+			patch.AllDay.Set = true
+			if !isNull(raw) {
+				err = json.Unmarshal(raw, &patch.AllDay.Value)
+			}
+		case "description":
+			patch.Description.Set = true
+			patch.Description.Value, err = patchString(raw)
+		case "reminder_offsets":
+			var offsets []string
+			patch.ReminderOffsets.Set = true
+			if err = json.Unmarshal(raw, &offsets); err == nil {
+				patch.ReminderOffsets.Value, _, err = parseReminders(offsets, nil)
+			}
+		case "reminder_times":
+			var times []string
+			patch.ReminderTimes.Set = true
+			if err = json.Unmarshal(raw, &times); err == nil {
+				_, patch.ReminderTimes.Value, err = parseReminders(nil, times)
+			}
+		case "notify_channel":
+			patch.NotifyChannel.Set = true
+			patch.NotifyChannel.Value, err = patchString(raw)
+		case "notify_target":
+			patch.NotifyTarget.Set = true
+			patch.NotifyTarget.Value, err = patchString(raw)
+		case "rrule":
+			var s string
+			patch.Recurrence.Set = true
+			if s, err = patchString(raw); err == nil {
+				patch.Recurrence.Value, err = parseRecurrence(s)
+			}
+		case "time_zone":
+			patch.TimeZone.Set = true
+			patch.TimeZone.Value, err = patchString(raw)
+		case "event_id", "user_id", "version", "is_archived", "external_uid", "exdates", "overrides", "series_id", "original_start":
+			return domain.EventPatch{}, fmt.Errorf("field %q is read-only", name)
+		default:
+			return domain.EventPatch{}, fmt.Errorf("unknown field %q", name)
+		}
+		if err != nil {
+			return domain.EventPatch{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if patch.Date.Set && patch.Date.Value.IsZero() {
+		return domain.EventPatch{}, errors.New("date cannot be removed")
+	}
+	if dateOnly && !patch.AllDay.Set {
+		patch.AllDay = domain.Patched[bool]{Set: true, Value: true}
+	}
+	return patch, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+// patchString строковое поле патча; null — пустая строка
+func patchString(raw json.RawMessage) (string, error) {
+	if isNull(raw) {
+		return "", nil
+	}
+	var s string
+	err := json.Unmarshal(raw, &s)
+	return s, err
+}
+
+// parsePatchTime YYYY-MM-DD или RFC 3339; true — задана только дата
+func parsePatchTime(s string) (time.Time, bool, error) {
+	if s == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, errors.New("use YYYY-MM-DD or RFC 3339")
+	}
+	return t, false, nil
+}
+
+// pathID id события из пути {id}. При ошибке пишет ответ и возвращает false.
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, "event id must be positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// parseRangeBound граница выборки: YYYY-MM-DD — полночь в поясе пользователя, иначе RFC 3339
+func parseRangeBound(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, errors.New("is required")
+	}
+	if t, err := time.ParseInLocation(dateLayout, s, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid format, use YYYY-MM-DD or RFC 3339")
+	}
+	return t, nil
+}
+
+func eventLocation(eventID int64) string {
+	return "/v1/events/" + strconv.FormatInt(eventID, 10)
+}
+
+// writeJSON отправляет значение как JSON с заданным статусом
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
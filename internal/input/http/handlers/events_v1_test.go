@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+// newV1Server сервер без аутентификации: пользователь берётся из user_id
+func newV1Server(usecases *MockUsecases) *Server {
+	return NewServer(usecases, NewMockUsers(), &MockReminders{}, &MockAuth{}, nil, NewMockFeeds(), nil, log_worker.NewLogger())
+}
+
+func TestServer_CreateEventV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+
+	req := httptest.NewRequest("POST", "/v1/events", jsonBody(map[string]any{
+		"user_id": 1,
+		"start":   "2026-03-15T10:00:00Z",
+		"event":   "Standup",
+	}))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Location"); got != "/v1/events/1" {
+		t.Errorf("Expected Location /v1/events/1, got %q", got)
+	}
+	var event domain.Event
+	if err := json.NewDecoder(w.Body).Decode(&event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if event.EventId != 1 || event.Description != "Standup" {
+		t.Errorf("Expected created event in body, got %+v", event)
+	}
+}
+
+func TestServer_ListEventsV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "X"})
+
+	req := httptest.NewRequest("GET", "/v1/events?user_id=1&from=2026-03-01&to=2026-03-15T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var response types.EventListResponse
+	_ = json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Events) != 1 {
+		t.Errorf("Expected 1 event, got %d", len(response.Events))
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !usecases.lastStart.Equal(want) {
+		t.Errorf("Expected range start %v, got %v", want, usecases.lastStart)
+	}
+	if want := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC); !usecases.lastEnd.Equal(want) {
+		t.Errorf("Expected range end %v, got %v", want, usecases.lastEnd)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/events?user_id=1&from=2026-03-01", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without to, got %d", w.Code)
+	}
+}
+
+func TestServer_ListEventsV1_Pages(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start.Add(time.Duration(i) * time.Hour), Description: "X"})
+	}
+
+	list := func(query string) (int, types.EventListResponse) {
+		req := httptest.NewRequest("GET", "/v1/events?user_id=1&from=2026-03-15&to=2026-03-16"+query, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var response types.EventListResponse
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	code, first := list("&limit=2")
+	if code != http.StatusOK || len(first.Events) != 2 || first.NextPageToken == "" {
+		t.Fatalf("Expected first page of 2 events with next_page_token, got %d %+v", code, first)
+	}
+	_, second := list("&limit=2&page_token=" + first.NextPageToken)
+	if len(second.Events) != 1 || second.Events[0].EventId != 3 || second.NextPageToken != "" {
+		t.Errorf("Expected last page with event 3, got %+v", second)
+	}
+
+	list("&include_archived=true&q=standup&sort=desc")
+	if q := usecases.lastQuery; !q.IncludeArchived || q.Text != "standup" || q.Sort != domain.SortDesc || q.Limit != defaultPageSize {
+		t.Errorf("Expected filters passed to ListEvents, got %+v", q)
+	}
+
+	// Токен другой выборки не подходит
+	if code, _ := list("&limit=2&sort=desc&page_token=" + first.NextPageToken); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for page_token of another query, got %d", code)
+	}
+
+	for _, query := range []string{"&limit=0", "&limit=x", "&page_token=garbage"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, code)
+		}
+	}
+}
+
+func TestServer_UpdateEventV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Original"})
+
+	for _, tc := range []struct {
+		path string
+		body map[string]any
+		code int
+	}{
+		{"/v1/events/1", map[string]any{"user_id": 1, "date": "2026-03-16", "event": "Updated"}, http.StatusNoContent},
+		{"/v1/events/1", map[string]any{"event_id": 2, "user_id": 1, "date": "2026-03-16", "event": "Updated"}, http.StatusBadRequest},
+		{"/v1/events/7", map[string]any{"user_id": 1, "date": "2026-03-16", "event": "Updated"}, http.StatusNotFound},
+		{"/v1/events/abc", map[string]any{"user_id": 1, "date": "2026-03-16", "event": "Updated"}, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("PUT", tc.path, jsonBody(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%s %v: expected status %d, got %d", tc.path, tc.body, tc.code, w.Code)
+		}
+	}
+	if usecases.events[1].Description != "Updated" {
+		t.Errorf("Expected event updated, got %q", usecases.events[1].Description)
+	}
+}
+
+func TestServer_DeleteEventV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "To delete"})
+
+	for _, code := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest("DELETE", "/v1/events/1?user_id=1", nil)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("Expected status %d, got %d", code, w.Code)
+		}
+	}
+
+	// Старые маршруты продолжают работать
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBufferString("user_id=1&date=2026-03-15&event=Legacy"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected legacy route status 200, got %d", w.Code)
+	}
+}
+
+func TestServer_GetEventV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Mine"})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 2, Date: time.Now(), Description: "Not mine"})
+
+	for path, code := range map[string]int{
+		"/v1/events/1":      http.StatusOK,
+		"/v1/events/2":      http.StatusNotFound,
+		"/v1/events/3":      http.StatusNotFound,
+		"/event?event_id=1": http.StatusOK,
+		"/event?event_id=2": http.StatusNotFound,
+		"/event":            http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer cal_user")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("%s: expected status %d, got %d", path, code, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/v1/events/1", nil)
+	req.Header.Set("Authorization", "Bearer cal_user")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	var event domain.Event
+	_ = json.NewDecoder(w.Body).Decode(&event)
+	if event.EventId != 1 || event.Description != "Mine" {
+		t.Errorf("Expected event 1 in body, got %+v", event)
+	}
+}
+
+func TestServer_PatchEventV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: start, End: start.Add(time.Hour), Description: "Original", NotifyChannel: "log"})
+
+	req := httptest.NewRequest("PATCH", "/v1/events/1?user_id=1", bytes.NewBufferString(`{"date": "2026-03-16T10:00:00Z", "notify_channel": null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var event domain.Event
+	_ = json.NewDecoder(w.Body).Decode(&event)
+	if event.Description != "Original" || !event.Date.Equal(start.AddDate(0, 0, 1)) || event.NotifyChannel != "" {
+		t.Errorf("Expected moved event with description kept and channel removed, got %+v", event)
+	}
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("Expected ETag of the new version \"2\", got %q", got)
+	}
+
+	for _, tc := range []struct {
+		body        string
+		contentType string
+		code        int
+	}{
+		{`{"user_id": 2}`, "application/merge-patch+json", http.StatusBadRequest},
+		{`{"title": "x"}`, "application/merge-patch+json", http.StatusBadRequest},
+		{`[1, 2]`, "application/merge-patch+json", http.StatusBadRequest},
+		{`{"date": null}`, "application/merge-patch+json", http.StatusBadRequest},
+		{`description=x`, "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+	} {
+		req := httptest.NewRequest("PATCH", "/v1/events/1?user_id=1", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%s: expected status %d, got %d", tc.body, tc.code, w.Code)
+		}
+	}
+}
+
+func TestServer_EventV1_IfMatch(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Original"})
+
+	req := httptest.NewRequest("GET", "/v1/events/1?user_id=1", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf("Expected ETag \"1\", got %q", got)
+	}
+
+	update := map[string]any{"user_id": 1, "date": "2026-03-16", "event": "Updated"}
+	for _, tc := range []struct {
+		method  string
+		ifMatch string
+		code    int
+	}{
+		{"PUT", "", http.StatusPreconditionRequired},
+		{"PUT", `"5"`, http.StatusPreconditionFailed},
+		{"PUT", "not-an-etag", http.StatusPreconditionFailed},
+		{"PUT", `"1"`, http.StatusNoContent},
+		// Первая запись сменила версию, второй клиент со старым ETag получает 412
+		{"PUT", `"1"`, http.StatusPreconditionFailed},
+		{"DELETE", "", http.StatusPreconditionRequired},
+		{"DELETE", `"1"`, http.StatusPreconditionFailed},
+		{"DELETE", `W/"2"`, http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(tc.method, "/v1/events/1?user_id=1", jsonBody(update))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%s If-Match %q: expected status %d, got %d: %s", tc.method, tc.ifMatch, tc.code, w.Code, w.Body)
+		}
+	}
+}
+
+func TestServer_ArchiveV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	now := time.Now()
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: now, Description: "Active"})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: now, Description: "Old"})
+
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/v1/events/2:archive?user_id=1", http.StatusOK},
+		{"/v1/events/2:archive?user_id=1", http.StatusOK},
+		{"/v1/events/1:delete?user_id=1", http.StatusNotFound},
+		{"/v1/events/x:archive?user_id=1", http.StatusBadRequest},
+		{"/v1/events/9:archive?user_id=1", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest("POST", tc.path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.code, w.Code)
+		}
+	}
+	if !usecases.events[2].IsArchived {
+		t.Fatal("Expected event 2 archived")
+	}
+
+	titles := func(path string) []string {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		var response types.EventListResponse
+		_ = json.NewDecoder(w.Body).Decode(&response)
+		var result []string
+		for _, e := range response.Events {
+			result = append(result, e.Description)
+		}
+		return result
+	}
+	day := now.Format(dateLayout)
+	next := now.AddDate(0, 0, 1).Format(dateLayout)
+	if got := titles("/v1/archive?user_id=1"); len(got) != 1 || got[0] != "Old" {
+		t.Errorf("Expected only the archived event in /v1/archive, got %v", got)
+	}
+	if !usecases.lastQuery.OnlyArchived || usecases.lastQuery.Sort != domain.SortDesc {
+		t.Errorf("Expected archive query sorted newest first, got %+v", usecases.lastQuery)
+	}
+	if got := titles("/v1/events?user_id=1&from=" + day + "&to=" + next); len(got) != 1 || got[0] != "Active" {
+		t.Errorf("Expected archived event hidden from /v1/events, got %v", got)
+	}
+	if got := titles("/v1/events?user_id=1&include_archived=true&from=" + day + "&to=" + next); len(got) != 2 {
+		t.Errorf("Expected both events with include_archived, got %v", got)
+	}
+
+	req := httptest.NewRequest("POST", "/v1/events/2:unarchive?user_id=1", nil)
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for stale If-Match, got %d", w.Code)
+	}
+}
+
+func TestParseEventPatch_AllDay(t *testing.T) {
+	req := httptest.NewRequest("PATCH", "/v1/events/1", bytes.NewBufferString(`{"date": "2026-03-15", "end": "2026-03-17"}`))
+	patch, err := parseEventPatch(req)
+	if err != nil {
+		t.Fatalf("parseEventPatch: %v", err)
+	}
+	if !patch.AllDay.Set || !patch.AllDay.Value {
+		t.Error("Expected date-only start to make the event all-day")
+	}
+	if want := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC); !patch.End.Value.Equal(want) {
+		t.Errorf("Expected exclusive end %v, got %v", want, patch.End.Value)
+	}
+}
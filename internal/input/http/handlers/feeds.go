@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/ical"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+const (
+	// feedPast и feedFuture окно ленты относительно начала текущих суток (UTC); вместе не длиннее интервала ListEvents
+	feedPast   = 90 * 24 * time.Hour
+	feedFuture = 275 * 24 * time.Hour
+)
+
+// FeedHandler подписка на календарь по секретной ссылке (webcal)
+type FeedHandler struct {
+	feeds    port.FeedUsecases
+	usecases port.EventUsecases
+	users    port.UserUsecases
+	logger   *log_worker.Logger
+	now      func() time.Time
+}
+
+func NewFeedHandler(feeds port.FeedUsecases, usecases port.EventUsecases, users port.UserUsecases, logger *log_worker.Logger) *FeedHandler {
+	return &FeedHandler{
+		feeds:    feeds,
+		usecases: usecases,
+		users:    users,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// RotateFeedV1 POST /v1/users/{id}/feed — выпускает ссылку на ленту пользователя; прежняя ссылка перестаёт работать
+func (h *FeedHandler) RotateFeedV1(w http.ResponseWriter, r *http.Request) {
+	userID, ok := feedUser(w, r)
+	if !ok {
+		return
+	}
+	token, plain, err := h.feeds.RotateFeedToken(r.Context(), userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	h.logger.Writef("feed token issued for user %d", userID)
+	writeJSON(w, http.StatusOK, types.FeedTokenResponse{FeedToken: token, Token: plain, Path: "/feeds/" + plain + ".ics"})
+}
+
+// RevokeFeedV1 DELETE /v1/users/{id}/feed — отзывает ссылку на ленту
+func (h *FeedHandler) RevokeFeedV1(w http.ResponseWriter, r *http.Request) {
+	userID, ok := feedUser(w, r)
+	if !ok {
+		return
+	}
+	if err := h.feeds.RevokeFeedToken(r.Context(), userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	h.logger.Writef("feed token revoked for user %d", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Feed GET /feeds/{token}.ics — лента iCalendar без аутентификации: доступ даёт сам токен.
+// Отдаёт события за последние 90 и ближайшие 275 дней; окно сдвигается раз в сутки. ETag и Last-Modified
+// меняются, только когда меняются события или окно, поэтому опрос без изменений получает 304 без тела
+// и без выборки событий.
+func (h *FeedHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	token, err := h.feeds.AuthenticateFeedToken(r.Context(), strings.TrimSuffix(r.PathValue("token"), ".ics"))
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	day := h.now().Truncate(24 * time.Hour)
+	query := domain.EventQuery{UserId: token.UserId, From: day.Add(-feedPast), To: day.Add(feedFuture)}
+	contentHash, modified, err := h.feeds.FeedModified(r.Context(), token, query.From)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	etag := strconv.Quote(contentHash)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	// Клиент может хранить ленту, но перед показом должен переспросить сервер
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	events, err := calendarEvents(r.Context(), h.usecases, query)
+	if err != nil {
+		// Ответ с ошибкой не должен выглядеть версией ленты
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
+		writeUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType)
+	encoder := ical.NewEncoder(w)
+	encoder.Stamp = modified
+	if err := encoder.Encode(events); err != nil {
+		// Заголовки уже отправлены, ответ с ошибкой не вернуть
+		log.Printf("failed to write feed: %v", err)
+	}
+}
+
+// feedUser пользователь из пути; при ошибке ответ уже отправлен
+func feedUser(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(w, "user id must be positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return 0, false
+	}
+	return userID, true
+}
+
+// notModified условный GET (RFC 9110, 13.1): If-None-Match сравнивается слабо и, если передан,
+// If-Modified-Since не проверяется
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+type MockFeeds struct {
+	tokens   map[string]int64
+	feeds    map[int64]domain.FeedToken
+	issued   int
+	modified time.Time
+	// usecases события, по которым считается хеш ленты; nil — лента не меняется
+	usecases *MockUsecases
+	// touched сколько раз менялся хеш ленты
+	touched int
+}
+
+func NewMockFeeds() *MockFeeds {
+	return &MockFeeds{
+		tokens:   make(map[string]int64),
+		feeds:    make(map[int64]domain.FeedToken),
+		modified: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func (m *MockFeeds) RotateFeedToken(ctx context.Context, userID int64) (domain.FeedToken, string, error) {
+	for plain, id := range m.tokens {
+		if id == userID {
+			delete(m.tokens, plain)
+		}
+	}
+	m.issued++
+	plain := fmt.Sprintf("feed_%d", m.issued)
+	m.tokens[plain] = userID
+	token := domain.FeedToken{UserId: userID, CreatedAt: m.modified}
+	m.feeds[userID] = token
+	return token, plain, nil
+}
+
+func (m *MockFeeds) RevokeFeedToken(ctx context.Context, userID int64) error {
+	if _, ok := m.feeds[userID]; !ok {
+		return domain.ErrFeedNotFound
+	}
+	for plain, id := range m.tokens {
+		if id == userID {
+			delete(m.tokens, plain)
+		}
+	}
+	delete(m.feeds, userID)
+	return nil
+}
+
+func (m *MockFeeds) AuthenticateFeedToken(ctx context.Context, token string) (domain.FeedToken, error) {
+	userID, ok := m.tokens[token]
+	if !ok {
+		return domain.FeedToken{}, domain.ErrFeedNotFound
+	}
+	return m.feeds[userID], nil
+}
+
+// FeedModified хеш из окна и версий событий пользователя; время изменения — текущее m.modified,
+// если хеш поменялся
+func (m *MockFeeds) FeedModified(ctx context.Context, token domain.FeedToken, from time.Time) (string, time.Time, error) {
+	feed, ok := m.feeds[token.UserId]
+	if !ok {
+		return "", time.Time{}, domain.ErrFeedNotFound
+	}
+	contentHash := from.Format(time.RFC3339)
+	if m.usecases != nil {
+		for _, e := range m.usecases.events {
+			if e.UserId == token.UserId {
+				contentHash += fmt.Sprintf(":%d.%d", e.EventId, e.Version)
+			}
+		}
+	}
+	if feed.ContentHash != contentHash {
+		feed.ContentHash = contentHash
+		feed.ModifiedAt = m.modified
+		m.feeds[token.UserId] = feed
+		m.touched++
+	}
+	return contentHash, feed.ModifiedAt, nil
+}
+
+func issueFeed(t *testing.T, srv *Server, header string) types.FeedTokenResponse {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/v1/users/1/feed", nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp types.FeedTokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func getFeed(srv *Server, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Feed(t *testing.T) {
+	usecases := NewMockUsecases()
+	feeds := NewMockFeeds()
+	feeds.usecases = usecases
+	srv := NewServer(usecases, NewMockUsers(), &MockReminders{}, &MockAuth{}, nil, feeds, nil, log_worker.NewLogger())
+	now := time.Now().UTC().Truncate(time.Hour)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: now.Add(24 * time.Hour), Description: "Planning"})
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 2, Date: now.Add(24 * time.Hour), Description: "Other user"})
+
+	feed := issueFeed(t, srv, "")
+	if feed.Path != "/feeds/"+feed.Token+".ics" || feed.UserId != 1 {
+		t.Fatalf("Unexpected feed %+v", feed)
+	}
+
+	w := getFeed(srv, feed.Path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "SUMMARY:Planning") || strings.Contains(body, "Other user") {
+		t.Errorf("Unexpected feed:\n%s", body)
+	}
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if etag == "" || lastModified != "Sun, 15 Mar 2026 10:00:00 GMT" {
+		t.Fatalf("Expected ETag and Last-Modified, got %q, %q", etag, lastModified)
+	}
+
+	for _, headers := range []map[string]string{
+		{"If-None-Match": etag},
+		{"If-None-Match": `"other", W/` + etag},
+		{"If-Modified-Since": lastModified},
+	} {
+		w := getFeed(srv, feed.Path, headers)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("%v: expected status 304 without body, got %d", headers, w.Code)
+		}
+	}
+	// Опрос без изменений не выбирает события и не пишет новый хеш
+	usecases.lastQuery = domain.EventQuery{}
+	if w := getFeed(srv, feed.Path, map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || usecases.lastQuery.UserId != 0 {
+		t.Errorf("Expected status 304 without listing events, got %d, %+v", w.Code, usecases.lastQuery)
+	}
+	if feeds.touched != 1 {
+		t.Errorf("Expected feed hash written once, got %d", feeds.touched)
+	}
+	// Несовпавший ETag важнее If-Modified-Since
+	if w := getFeed(srv, feed.Path, map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for stale ETag, got %d", w.Code)
+	}
+
+	// Изменение событий меняет ETag и Last-Modified
+	feeds.modified = time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: now.Add(48 * time.Hour), Description: "Review"})
+	w = getFeed(srv, feed.Path, map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag || w.Header().Get("Last-Modified") != "Mon, 16 Mar 2026 08:00:00 GMT" {
+		t.Errorf("Expected new content, got %d, %q, %q", w.Code, w.Header().Get("ETag"), w.Header().Get("Last-Modified"))
+	}
+
+	// Новая ссылка отзывает прежнюю
+	rotated := issueFeed(t, srv, "")
+	if w := getFeed(srv, feed.Path, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for rotated token, got %d", w.Code)
+	}
+	if w := getFeed(srv, rotated.Path, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for new token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("DELETE", "/v1/users/1/feed", nil)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := getFeed(srv, rotated.Path, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for revoked token, got %d", w.Code)
+	}
+}
+
+func TestServer_FeedWithAuth(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+
+	// Выпуск ссылки требует аутентификации, сама лента — только токена
+	req := httptest.NewRequest("POST", "/v1/users/1/feed", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", w.Code)
+	}
+	feed := issueFeed(t, srv, "Bearer cal_user")
+	if w := getFeed(srv, feed.Path, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := getFeed(srv, "/feeds/feed_unknown.ics", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	// Токен ленты не заменяет API-ключ
+	req = httptest.NewRequest("GET", "/v1/events?from=2026-03-15&to=2026-03-16", nil)
+	req.Header.Set("Authorization", "Bearer "+feed.Token)
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for feed token, got %d", w.Code)
+	}
+}
+
+// TestServer_FeedNotLogged токен ленты — пароль к календарю, в лог запросов он попадать не должен
+func TestServer_FeedNotLogged(t *testing.T) {
+	srv := NewServer(NewMockUsecases(), NewMockUsers(), &MockReminders{}, &MockAuth{}, nil, NewMockFeeds(), nil, log_worker.NewLogger())
+	feed := issueFeed(t, srv, "")
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	if w := getFeed(srv, feed.Path, nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	getFeed(srv, "/feeds/feed_unknown.ics", nil)
+
+	logged := buf.String()
+	if strings.Contains(logged, feed.Token) || strings.Contains(logged, "feed_unknown") {
+		t.Errorf("Feed token leaked into log:\n%s", logged)
+	}
+	if !strings.Contains(logged, "GET /feeds/{token}") {
+		t.Errorf("Expected feed request in log:\n%s", logged)
+	}
+}
@@ -18,12 +18,15 @@ const dateLayout = "2006-01-02"
 
 type Handler struct {
 	usecases port.EventUsecases
-	logger   *log_worker.Logger
+	// users нужны для часового пояса пользователя
+	users  port.UserUsecases
+	logger *log_worker.Logger
 }
 
-func NewHandler(usecases port.EventUsecases, logger *log_worker.Logger) *Handler {
+func NewHandler(usecases port.EventUsecases, users port.UserUsecases, logger *log_worker.Logger) *Handler {
 	return &Handler{
 		usecases: usecases,
+		users:    users,
 		logger:   logger,
 	}
 }
@@ -34,104 +37,129 @@ func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if event.UserId, err = actingUser(r, event.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.fillTimeZone(r, event); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
 	if err := h.usecases.CreateEvent(r.Context(), event); err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResultMessage(w, "event created")
 }
 
 func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
-	event, err := parseBodyUpdate(r)
+	event, scope, err := parseBodyUpdate(r, 0)
 	if err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := h.usecases.UpdateEvent(r.Context(), event); err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+	if event.UserId, err = actingUser(r, event.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	var ok bool
+	if event.Version, ok = ifMatchVersion(w, r, false); !ok {
+		return
+	}
+	if err := h.fillTimeZone(r, &event); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.usecases.UpdateEvent(r.Context(), event, scope); err != nil {
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResultMessage(w, "event updated")
 }
 
 func (h *Handler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
-	eventID, err := parseBodyDelete(r)
+	userID, eventID, scope, occurrence, err := parseBodyDelete(r)
 	if err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := h.usecases.DeleteEvent(r.Context(), eventID); err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	version, ok := ifMatchVersion(w, r, false)
+	if !ok {
+		return
+	}
+	if err := h.usecases.DeleteEvent(r.Context(), userID, eventID, version, scope, occurrence); err != nil {
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResultMessage(w, "event deleted")
 }
 
-func (h *Handler) EventsForDay(w http.ResponseWriter, r *http.Request) {
-	userID, date, err := parseQueryUserDate(r)
+// GetEvent GET /event?event_id=... — одно событие пользователя
+func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseInt(r.URL.Query().Get("event_id"), 10, 64)
+	if err != nil || eventID <= 0 {
+		writeError(w, "event_id is required and must be positive integer", http.StatusBadRequest)
+		return
+	}
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
 	if err != nil {
 		writeError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	events, err := h.usecases.GetEventsForDay(r.Context(), userID, date)
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	event, err := h.usecases.GetEvent(r.Context(), userID, eventID)
 	if err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, err)
+		return
+	}
+	setETag(w, event)
+	writeResult(w, event)
+}
+
+func (h *Handler) EventsForDay(w http.ResponseWriter, r *http.Request) {
+	userID, date, ok := h.parseRangeQuery(w, r)
+	if !ok {
+		return
+	}
+	events, err := h.usecases.GetEventsForDay(r.Context(), userID, date, includeArchived(r))
+	if err != nil {
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResult(w, events)
 }
 
 func (h *Handler) EventsForWeek(w http.ResponseWriter, r *http.Request) {
-	userID, date, err := parseQueryUserDate(r)
-	if err != nil {
-		writeError(w, err.Error(), http.StatusBadRequest)
+	userID, date, ok := h.parseRangeQuery(w, r)
+	if !ok {
 		return
 	}
-	// Неделя: от начала дня date до конца недели (7 дней)
-	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	events, err := h.usecases.GetEventsForWeek(r.Context(), userID, start)
+	// Неделя: 7 календарных дней от начала дня date
+	events, err := h.usecases.GetEventsForWeek(r.Context(), userID, date, includeArchived(r))
 	if err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResult(w, events)
 }
 
 func (h *Handler) EventsForMonth(w http.ResponseWriter, r *http.Request) {
-	userID, date, err := parseQueryUserDate(r)
-	if err != nil {
-		writeError(w, err.Error(), http.StatusBadRequest)
+	userID, date, ok := h.parseRangeQuery(w, r)
+	if !ok {
 		return
 	}
 	// Месяц: первый день месяца
 	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
-	events, err := h.usecases.GetEventsForMonth(r.Context(), userID, start)
+	events, err := h.usecases.GetEventsForMonth(r.Context(), userID, start, includeArchived(r))
 	if err != nil {
-		if isBusinessError(err) {
-			writeError(w, err.Error(), http.StatusServiceUnavailable)
-			return
-		}
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeUsecaseError(w, err)
 		return
 	}
 	writeResult(w, events)
@@ -153,9 +181,65 @@ func writeResultMessage(w http.ResponseWriter, msg string) {
 
 // writeError отправляет JSON {"error": "..."} с заданным статусом
 func writeError(w http.ResponseWriter, errMsg string, status int) {
+	writeErrorCode(w, errMsg, errorCodeForStatus(status), status)
+}
+
+func writeErrorCode(w http.ResponseWriter, errMsg, code string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(types.ErrorResponse{Error: errMsg})
+	_ = json.NewEncoder(w).Encode(types.ErrorResponse{Error: errMsg, Code: code})
+}
+
+// writeUsecaseError отвечает на ошибку usecase: статус по виду domain-ошибки, код из domain.Error
+func writeUsecaseError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, domain.ErrValidation):
+		status = http.StatusBadRequest
+	case errors.Is(err, domain.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, domain.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, domain.ErrUnauthorized):
+		status = http.StatusUnauthorized
+	case errors.Is(err, domain.ErrPrecondition):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrUnprocessable):
+		status = http.StatusUnprocessableEntity
+	}
+
+	code := errorCodeForStatus(status)
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		code = domainErr.Code
+	}
+	writeErrorCode(w, err.Error(), code, status)
+}
+
+// errorCodeForStatus код ошибки по умолчанию для HTTP-статуса
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusPreconditionRequired:
+		return "precondition_required"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	default:
+		return "internal"
+	}
 }
 
 // parseDate проверяет формат YYYY-MM-DD
@@ -170,6 +254,78 @@ func parseDate(s string) (time.Time, error) {
 	return t, nil
 }
 
+// parseEventTimes парсит начало и конец события. Начало в формате YYYY-MM-DD (в том числе старое поле date)
+// означает событие на весь день, и тогда end — последний день события включительно.
+// Иначе start и end в RFC 3339. Пустой end заполняет usecase.
+func parseEventTimes(date, start, end string) (time.Time, time.Time, bool, error) {
+	if start == "" {
+		start = date
+	}
+	if start == "" {
+		return time.Time{}, time.Time{}, false, errors.New("start is required")
+	}
+
+	if from, err := time.Parse(dateLayout, start); err == nil {
+		if end == "" {
+			return from, time.Time{}, true, nil
+		}
+		last, err := time.Parse(dateLayout, end)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, errors.New("invalid end format, use YYYY-MM-DD for all-day events")
+		}
+		return from, last.AddDate(0, 0, 1), true, nil
+	}
+
+	from, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, errors.New("invalid start format, use YYYY-MM-DD or RFC 3339")
+	}
+	if end == "" {
+		return from, time.Time{}, false, nil
+	}
+	to, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, errors.New("invalid end format, use RFC 3339")
+	}
+	return from, to, false, nil
+}
+
+// parseRecurrence парсит необязательное правило повторения RRULE
+func parseRecurrence(s string) (*domain.RecurrenceRule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return domain.ParseRecurrenceRule(s)
+}
+
+// parseReminders парсит смещения напоминаний ("10m", "1h", "1d") и абсолютные времена в RFC 3339.
+// Переданный пустой список остаётся пустым, а не nil: так событие создаётся без напоминания по умолчанию.
+func parseReminders(offsets, times []string) ([]domain.Offset, []time.Time, error) {
+	var parsedOffsets []domain.Offset
+	if offsets != nil {
+		parsedOffsets = make([]domain.Offset, 0, len(offsets))
+	}
+	for _, s := range offsets {
+		o, err := domain.ParseOffset(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		parsedOffsets = append(parsedOffsets, o)
+	}
+	var parsedTimes []time.Time
+	if times != nil {
+		parsedTimes = make([]time.Time, 0, len(times))
+	}
+	for _, s := range times {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, nil, errors.New("invalid reminder time format, use RFC 3339")
+		}
+		parsedTimes = append(parsedTimes, t)
+	}
+	return parsedOffsets, parsedTimes, nil
+}
+
 // parseBodyCreate парсит JSON или form для создания события
 func parseBodyCreate(r *http.Request) (*domain.Event, error) {
 	ct := r.Header.Get("Content-Type")
@@ -178,93 +334,252 @@ func parseBodyCreate(r *http.Request) (*domain.Event, error) {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			return nil, errors.New("invalid JSON body")
 		}
-		date, err := parseDate(req.Date)
+		start, end, allDay, err := parseEventTimes(req.Date, req.Start, req.End)
 		if err != nil {
 			return nil, err
 		}
-		if req.UserID <= 0 {
-			return nil, errors.New("user_id is required and must be positive")
+		offsets, times, err := parseReminders(req.ReminderOffsets, req.ReminderTimes)
+		if err != nil {
+			return nil, err
 		}
-		return &domain.Event{UserId: req.UserID, Date: date, Description: req.Event}, nil
+		recurrence, err := parseRecurrence(req.RRule)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.Event{
+			UserId:          req.UserID,
+			Date:            start,
+			End:             end,
+			AllDay:          allDay,
+			Description:     req.Event,
+			ReminderOffsets: offsets,
+			ReminderTimes:   times,
+			NotifyChannel:   req.NotifyChannel,
+			NotifyTarget:    req.NotifyTarget,
+			Recurrence:      recurrence,
+			TimeZone:        req.TimeZone,
+		}, nil
 	}
 	if err := r.ParseForm(); err != nil {
 		return nil, errors.New("invalid form body")
 	}
-	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
-	if err != nil || userID <= 0 {
-		return nil, errors.New("user_id is required and must be positive integer")
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		return nil, err
 	}
-	date, err := parseDate(r.FormValue("date"))
+	start, end, allDay, err := parseEventTimes(r.FormValue("date"), r.FormValue("start"), r.FormValue("end"))
 	if err != nil {
 		return nil, err
 	}
 	desc := r.FormValue("event")
-	return &domain.Event{UserId: userID, Date: date, Description: desc}, nil
+	offsets, times, err := parseReminders(r.Form["reminder_offsets"], r.Form["reminder_times"])
+	if err != nil {
+		return nil, err
+	}
+	recurrence, err := parseRecurrence(r.FormValue("rrule"))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Event{
+		UserId:          userID,
+		Date:            start,
+		End:             end,
+		AllDay:          allDay,
+		Description:     desc,
+		ReminderOffsets: offsets,
+		ReminderTimes:   times,
+		NotifyChannel:   r.FormValue("notify_channel"),
+		NotifyTarget:    r.FormValue("notify_target"),
+		Recurrence:      recurrence,
+		TimeZone:        r.FormValue("time_zone"),
+	}, nil
 }
 
-// parseBodyUpdate парсит JSON или form для обновления события
-func parseBodyUpdate(r *http.Request) (domain.Event, error) {
+// parseBodyUpdate парсит JSON или form для обновления события. eventID — id из пути запроса;
+// 0 — id передаётся в теле
+func parseBodyUpdate(r *http.Request, eventID int64) (domain.Event, domain.EditScope, error) {
 	ct := r.Header.Get("Content-Type")
 	if strings.Contains(ct, "application/json") {
 		var req types.UpdateEventRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			return domain.Event{}, errors.New("invalid JSON body")
+			return domain.Event{}, "", errors.New("invalid JSON body")
+		}
+		start, end, allDay, err := parseEventTimes(req.Date, req.Start, req.End)
+		if err != nil {
+			return domain.Event{}, "", err
+		}
+		if req.EventID, err = pathEventID(eventID, req.EventID); err != nil {
+			return domain.Event{}, "", err
+		}
+		if req.EventID <= 0 {
+			return domain.Event{}, "", errors.New("event_id is required and must be positive")
 		}
-		date, err := parseDate(req.Date)
+		offsets, times, err := parseReminders(req.ReminderOffsets, req.ReminderTimes)
 		if err != nil {
-			return domain.Event{}, err
+			return domain.Event{}, "", err
 		}
-		if req.EventID <= 0 || req.UserID <= 0 {
-			return domain.Event{}, errors.New("event_id and user_id are required and must be positive")
+		recurrence, err := parseRecurrence(req.RRule)
+		if err != nil {
+			return domain.Event{}, "", err
 		}
-		return domain.Event{EventId: req.EventID, UserId: req.UserID, Date: date, Description: req.Event}, nil
+		scope, originalStart, err := parseScope(req.Scope, req.OriginalStart)
+		if err != nil {
+			return domain.Event{}, "", err
+		}
+		return domain.Event{
+			EventId:         req.EventID,
+			UserId:          req.UserID,
+			Date:            start,
+			End:             end,
+			AllDay:          allDay,
+			Description:     req.Event,
+			ReminderOffsets: offsets,
+			ReminderTimes:   times,
+			NotifyChannel:   req.NotifyChannel,
+			NotifyTarget:    req.NotifyTarget,
+			Recurrence:      recurrence,
+			TimeZone:        req.TimeZone,
+			OriginalStart:   originalStart,
+		}, scope, nil
 	}
 	if err := r.ParseForm(); err != nil {
-		return domain.Event{}, errors.New("invalid form body")
+		return domain.Event{}, "", errors.New("invalid form body")
 	}
-	eventID, err := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
-	if err != nil || eventID <= 0 {
-		return domain.Event{}, errors.New("event_id is required and must be positive integer")
+	if eventID == 0 {
+		id, err := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
+		if err != nil || id <= 0 {
+			return domain.Event{}, "", errors.New("event_id is required and must be positive integer")
+		}
+		eventID = id
+	} else if s := r.FormValue("event_id"); s != "" && s != strconv.FormatInt(eventID, 10) {
+		return domain.Event{}, "", errors.New("event_id in body does not match the path")
 	}
-	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
-	if err != nil || userID <= 0 {
-		return domain.Event{}, errors.New("user_id is required and must be positive integer")
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		return domain.Event{}, "", err
+	}
+	start, end, allDay, err := parseEventTimes(r.FormValue("date"), r.FormValue("start"), r.FormValue("end"))
+	if err != nil {
+		return domain.Event{}, "", err
+	}
+	offsets, times, err := parseReminders(r.Form["reminder_offsets"], r.Form["reminder_times"])
+	if err != nil {
+		return domain.Event{}, "", err
+	}
+	recurrence, err := parseRecurrence(r.FormValue("rrule"))
+	if err != nil {
+		return domain.Event{}, "", err
 	}
-	date, err := parseDate(r.FormValue("date"))
+	scope, originalStart, err := parseScope(r.FormValue("scope"), r.FormValue("original_start"))
 	if err != nil {
-		return domain.Event{}, err
+		return domain.Event{}, "", err
 	}
-	return domain.Event{EventId: eventID, UserId: userID, Date: date, Description: r.FormValue("event")}, nil
+	return domain.Event{
+		EventId:         eventID,
+		UserId:          userID,
+		Date:            start,
+		End:             end,
+		AllDay:          allDay,
+		Description:     r.FormValue("event"),
+		ReminderOffsets: offsets,
+		ReminderTimes:   times,
+		NotifyChannel:   r.FormValue("notify_channel"),
+		NotifyTarget:    r.FormValue("notify_target"),
+		Recurrence:      recurrence,
+		TimeZone:        r.FormValue("time_zone"),
+		OriginalStart:   originalStart,
+	}, scope, nil
 }
 
-// parseBodyDelete парсит JSON или form для удаления события
-func parseBodyDelete(r *http.Request) (int64, error) {
+// pathEventID id события из пути важнее id из тела; расходиться они не должны
+func pathEventID(pathID, bodyID int64) (int64, error) {
+	if pathID == 0 {
+		return bodyID, nil
+	}
+	if bodyID != 0 && bodyID != pathID {
+		return 0, errors.New("event_id in body does not match the path")
+	}
+	return pathID, nil
+}
+
+// parseBodyDelete парсит JSON или form для удаления события: user_id, event_id, scope и исходное начало повторения
+func parseBodyDelete(r *http.Request) (int64, int64, domain.EditScope, time.Time, error) {
 	ct := r.Header.Get("Content-Type")
 	if strings.Contains(ct, "application/json") {
 		var req types.DeleteEventRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			return 0, errors.New("invalid JSON body")
+			return 0, 0, "", time.Time{}, errors.New("invalid JSON body")
 		}
 		if req.EventID <= 0 {
-			return 0, errors.New("event_id is required and must be positive")
+			return 0, 0, "", time.Time{}, errors.New("event_id is required and must be positive")
+		}
+		if req.UserID < 0 {
+			return 0, 0, "", time.Time{}, errors.New("user_id must be positive integer")
 		}
-		return req.EventID, nil
+		scope, originalStart, err := parseScope(req.Scope, req.OriginalStart)
+		if err != nil {
+			return 0, 0, "", time.Time{}, err
+		}
+		return req.UserID, req.EventID, scope, originalStart, nil
 	}
 	if err := r.ParseForm(); err != nil {
-		return 0, errors.New("invalid form body")
+		return 0, 0, "", time.Time{}, errors.New("invalid form body")
 	}
 	eventID, err := strconv.ParseInt(r.FormValue("event_id"), 10, 64)
 	if err != nil || eventID <= 0 {
-		return 0, errors.New("event_id is required and must be positive integer")
+		return 0, 0, "", time.Time{}, errors.New("event_id is required and must be positive integer")
 	}
-	return eventID, nil
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		return 0, 0, "", time.Time{}, err
+	}
+	scope, originalStart, err := parseScope(r.FormValue("scope"), r.FormValue("original_start"))
+	if err != nil {
+		return 0, 0, "", time.Time{}, err
+	}
+	return userID, eventID, scope, originalStart, nil
+}
+
+// parseScope парсит scope изменения серии и исходное начало повторения (RFC 3339 или YYYY-MM-DD
+// для событий на весь день); для this и following оно обязательно
+func parseScope(scopeStr, originalStart string) (domain.EditScope, time.Time, error) {
+	scope, err := domain.ParseEditScope(scopeStr)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if originalStart == "" {
+		if scope != domain.ScopeAll {
+			return "", time.Time{}, errors.New("original_start is required for scope this and following")
+		}
+		return scope, time.Time{}, nil
+	}
+	if t, err := time.Parse(dateLayout, originalStart); err == nil {
+		return scope, t, nil
+	}
+	t, err := time.Parse(time.RFC3339, originalStart)
+	if err != nil {
+		return "", time.Time{}, errors.New("invalid original_start format, use YYYY-MM-DD or RFC 3339")
+	}
+	return scope, t, nil
+}
+
+// parseUserID парсит необязательный user_id: пустая строка — 0, пользователь определится по учётным данным
+func parseUserID(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	userID, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, errors.New("user_id must be positive integer")
+	}
+	return userID, nil
 }
 
 // parseQueryUserDate парсит query user_id и date для GET
 func parseQueryUserDate(r *http.Request) (userID int64, date time.Time, err error) {
-	userID, err = strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
-	if err != nil || userID <= 0 {
-		return 0, time.Time{}, errors.New("user_id is required and must be positive integer")
+	userID, err = parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		return 0, time.Time{}, err
 	}
 	dateStr := r.URL.Query().Get("date")
 	date, err = parseDate(dateStr)
@@ -274,11 +589,57 @@ func parseQueryUserDate(r *http.Request) (userID int64, date time.Time, err erro
 	return userID, date, nil
 }
 
-// isBusinessError ошибки бизнес-логики (событие не найдено и т.д.) — 503
-func isBusinessError(err error) bool {
-	if err == nil {
-		return false
+// parseRangeQuery парсит user_id и date для выборки за период; date — полночь в часовом поясе пользователя.
+// При ошибке пишет ответ и возвращает false.
+func (h *Handler) parseRangeQuery(w http.ResponseWriter, r *http.Request) (int64, time.Time, bool) {
+	userID, date, err := parseQueryUserDate(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return 0, time.Time{}, false
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return 0, time.Time{}, false
+	}
+	loc, err := h.userLocation(r, userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return 0, time.Time{}, false
+	}
+	return userID, time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), true
+}
+
+// includeArchived архивные события попадают в выборку только с include_archived=true
+func includeArchived(r *http.Request) bool {
+	return r.URL.Query().Get("include_archived") == "true"
+}
+
+// userLocation часовой пояс для границ выборки: параметр tz, затем настройки пользователя, иначе UTC
+func (h *Handler) userLocation(r *http.Request, userID int64) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		settings, err := h.users.GetSettings(r.Context(), userID)
+		if err != nil {
+			return nil, err
+		}
+		name = settings.TimeZone
+	}
+	loc, err := domain.LoadLocation(name)
+	if err != nil {
+		return nil, domain.NewValidationError("invalid_time_zone", "%v", err)
+	}
+	return loc, nil
+}
+
+// fillTimeZone для серии без явного часового пояса берёт пояс из настроек пользователя
+func (h *Handler) fillTimeZone(r *http.Request, event *domain.Event) error {
+	if event.Recurrence == nil || event.TimeZone != "" || event.AllDay {
+		return nil
+	}
+	settings, err := h.users.GetSettings(r.Context(), event.UserId)
+	if err != nil {
+		return err
 	}
-	s := err.Error()
-	return s == "event not found" || s == "event already exists"
+	event.TimeZone = settings.TimeZone
+	return nil
 }
@@ -4,18 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
 	"github.com/dontpanicw/calendar/log_worker"
 )
 
 type MockUsecases struct {
 	events map[int64]*domain.Event
 	nextID int64
+	// lastStart и lastEnd период из последнего запроса выборки
+	lastStart time.Time
+	lastEnd   time.Time
+	// lastQuery последний запрос ListEvents
+	lastQuery domain.EventQuery
 }
 
 func NewMockUsecases() *MockUsecases {
@@ -27,43 +35,222 @@ func NewMockUsecases() *MockUsecases {
 
 func (m *MockUsecases) CreateEvent(ctx context.Context, event *domain.Event) error {
 	event.EventId = m.nextID
+	event.Version = 1
 	m.nextID++
 	m.events[event.EventId] = event
 	return nil
 }
 
-func (m *MockUsecases) UpdateEvent(ctx context.Context, event domain.Event) error {
+func (m *MockUsecases) UpdateEvent(ctx context.Context, event domain.Event, scope domain.EditScope) error {
+	e, ok := m.events[event.EventId]
+	if !ok || e.UserId != event.UserId {
+		return domain.ErrEventNotFound
+	}
+	if event.Version != 0 && event.Version != e.Version {
+		return domain.ErrVersionMismatch
+	}
+	event.Version = e.Version + 1
 	m.events[event.EventId] = &event
 	return nil
 }
 
-func (m *MockUsecases) DeleteEvent(ctx context.Context, eventId int64) error {
+func (m *MockUsecases) DeleteEvent(ctx context.Context, userID, eventId, version int64, scope domain.EditScope, occurrence time.Time) error {
+	e, ok := m.events[eventId]
+	if !ok || e.UserId != userID {
+		return fmt.Errorf("failed to delete event: %w", domain.NewNotFoundError("event_not_found", "event with id %d not found", eventId))
+	}
+	if version != 0 && version != e.Version {
+		return domain.ErrVersionMismatch
+	}
+	if scope == domain.ScopeThis {
+		e.Exclude(occurrence)
+		e.Version++
+		return nil
+	}
 	delete(m.events, eventId)
 	return nil
 }
 
-func (m *MockUsecases) GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+func (m *MockUsecases) PatchEvent(ctx context.Context, userID, eventId, version int64, patch domain.EventPatch) (domain.Event, error) {
+	e, ok := m.events[eventId]
+	if !ok || e.UserId != userID {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if version != 0 && version != e.Version {
+		return domain.Event{}, domain.ErrVersionMismatch
+	}
+	patched := patch.Apply(*e)
+	patched.Version++
+	m.events[eventId] = &patched
+	return patched, nil
+}
+
+func (m *MockUsecases) GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error) {
+	e, ok := m.events[eventId]
+	if !ok || e.UserId != userID {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	return *e, nil
+}
+
+func (m *MockUsecases) ArchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error) {
+	return m.setArchived(userID, eventId, version, true)
+}
+
+func (m *MockUsecases) UnarchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error) {
+	return m.setArchived(userID, eventId, version, false)
+}
+
+func (m *MockUsecases) setArchived(userID, eventId, version int64, archived bool) (domain.Event, error) {
+	e, ok := m.events[eventId]
+	if !ok || e.UserId != userID {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if version != 0 && version != e.Version {
+		return domain.Event{}, domain.ErrVersionMismatch
+	}
+	e.IsArchived = archived
+	e.Version++
+	return *e, nil
+}
+
+func (m *MockUsecases) GetEventsForDay(ctx context.Context, userID int64, date time.Time, includeArchived bool) ([]domain.Event, error) {
+	m.lastStart = date
 	var result []domain.Event
 	for _, event := range m.events {
-		if event.UserId == userID {
+		if event.UserId == userID && (includeArchived || !event.IsArchived) {
 			result = append(result, *event)
 		}
 	}
 	return result, nil
 }
 
-func (m *MockUsecases) GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
-	return m.GetEventsForDay(ctx, userID, start)
+func (m *MockUsecases) GetEventsForWeek(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error) {
+	return m.GetEventsForDay(ctx, userID, start, includeArchived)
+}
+
+func (m *MockUsecases) GetEventsForMonth(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error) {
+	return m.GetEventsForDay(ctx, userID, start, includeArchived)
+}
+
+func (m *MockUsecases) ImportEvent(ctx context.Context, event *domain.Event) (domain.ImportStatus, error) {
+	for _, e := range m.events {
+		if e.UserId == event.UserId && e.ExternalUID == event.ExternalUID {
+			event.EventId = e.EventId
+			if event.Version != 0 && event.Version != e.Version {
+				return domain.ImportFailed, domain.ErrVersionMismatch
+			}
+			if e.Description == event.Description {
+				return domain.ImportSkipped, nil
+			}
+			event.Version = e.Version + 1
+			*e = *event
+			return domain.ImportUpdated, nil
+		}
+	}
+	if event.Description == "" {
+		return domain.ImportFailed, domain.NewValidationError("description_required", "event description is required")
+	}
+	return domain.ImportCreated, m.CreateEvent(ctx, event)
+}
+
+func (m *MockUsecases) ReplaceEvent(ctx context.Context, event *domain.Event) error {
+	e, ok := m.events[event.EventId]
+	if !ok || e.UserId != event.UserId {
+		return domain.ErrEventNotFound
+	}
+	if event.Version != 0 && event.Version != e.Version {
+		return domain.ErrVersionMismatch
+	}
+	event.Version = e.Version + 1
+	*e = *event
+	return nil
+}
+
+func (m *MockUsecases) CancelImportedEvent(ctx context.Context, userID int64, uid string) (domain.ImportStatus, int64, error) {
+	for id, e := range m.events {
+		if e.UserId == userID && uid != "" && e.ExternalUID == uid {
+			delete(m.events, id)
+			return domain.ImportDeleted, id, nil
+		}
+	}
+	return domain.ImportSkipped, 0, nil
+}
+
+func (m *MockUsecases) GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error) {
+	for _, e := range m.events {
+		if e.UserId == userID && uid != "" && e.ExternalUID == uid {
+			return *e, nil
+		}
+	}
+	return domain.Event{}, domain.ErrEventNotFound
+}
+
+func (m *MockUsecases) ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error) {
+	m.lastQuery = query
+	m.lastStart, m.lastEnd = query.From, query.To
+	if err := query.CheckCursor(); err != nil {
+		return domain.EventPage{}, err
+	}
+	var events []domain.Event
+	for _, event := range m.events {
+		if event.UserId != query.UserId && !query.AllUsers {
+			continue
+		}
+		// Серии разворачиваются в повторения, как в репозитории
+		if event.Recurrence != nil {
+			events = append(events, event.Occurrences(query.From, query.To)...)
+			continue
+		}
+		events = append(events, *event)
+	}
+	return query.Page(events), nil
+}
+
+func (m *MockUsecases) ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error {
+	if !query.To.After(query.From) {
+		return domain.NewValidationError("invalid_range", "range end must be after start")
+	}
+	var events []domain.Event
+	for _, event := range m.events {
+		if (event.UserId == query.UserId || query.AllUsers) && query.Includes(*event) {
+			events = append(events, *event)
+		}
+	}
+	domain.SortEvents(events)
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type MockUsers struct {
+	settings map[int64]domain.UserSettings
+}
+
+func NewMockUsers() *MockUsers {
+	return &MockUsers{settings: make(map[int64]domain.UserSettings)}
 }
 
-func (m *MockUsecases) GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
-	return m.GetEventsForDay(ctx, userID, start)
+func (m *MockUsers) GetSettings(ctx context.Context, userID int64) (domain.UserSettings, error) {
+	settings, ok := m.settings[userID]
+	if !ok {
+		return domain.UserSettings{UserId: userID}, nil
+	}
+	return settings, nil
+}
+
+func (m *MockUsers) UpdateSettings(ctx context.Context, settings domain.UserSettings) error {
+	m.settings[settings.UserId] = settings
+	return nil
 }
 
 func TestHandler_CreateEvent(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	body := map[string]interface{}{
 		"user_id": 1,
@@ -93,7 +280,7 @@ func TestHandler_CreateEvent(t *testing.T) {
 func TestHandler_CreateEvent_InvalidJSON(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	req := httptest.NewRequest("POST", "/create_event", bytes.NewBufferString("invalid json"))
 	req.Header.Set("Content-Type", "application/json")
@@ -109,7 +296,7 @@ func TestHandler_CreateEvent_InvalidJSON(t *testing.T) {
 func TestHandler_UpdateEvent(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	// Создаем событие
 	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "Original"}
@@ -137,7 +324,7 @@ func TestHandler_UpdateEvent(t *testing.T) {
 func TestHandler_DeleteEvent(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	// Создаем событие
 	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "To delete"}
@@ -145,6 +332,7 @@ func TestHandler_DeleteEvent(t *testing.T) {
 
 	body := map[string]interface{}{
 		"event_id": event.EventId,
+		"user_id":  1,
 	}
 	jsonBody, _ := json.Marshal(body)
 
@@ -166,7 +354,7 @@ func TestHandler_DeleteEvent(t *testing.T) {
 func TestHandler_EventsForDay(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	// Создаем событие
 	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "Test"}
@@ -192,7 +380,7 @@ func TestHandler_EventsForDay(t *testing.T) {
 func TestHandler_EventsForDay_InvalidUserID(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	req := httptest.NewRequest("GET", "/events_for_day?user_id=invalid&date=2026-03-15", nil)
 	w := httptest.NewRecorder()
@@ -207,7 +395,7 @@ func TestHandler_EventsForDay_InvalidUserID(t *testing.T) {
 func TestHandler_EventsForDay_InvalidDate(t *testing.T) {
 	usecases := NewMockUsecases()
 	logger := log_worker.NewLogger()
-	handler := NewHandler(usecases, logger)
+	handler := NewHandler(usecases, NewMockUsers(), logger)
 
 	req := httptest.NewRequest("GET", "/events_for_day?user_id=1&date=invalid", nil)
 	w := httptest.NewRecorder()
@@ -218,3 +406,362 @@ func TestHandler_EventsForDay_InvalidDate(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", w.Code)
 	}
 }
+
+func TestHandler_CreateEvent_WithReminders(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	body := map[string]interface{}{
+		"user_id":          1,
+		"date":             "2026-03-15",
+		"event":            "Test event",
+		"reminder_offsets": []string{"10m", "1d"},
+		"reminder_times":   []string{"2026-03-14T09:00:00Z"},
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateEvent(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	event := usecases.events[1]
+	if len(event.ReminderOffsets) != 2 || event.ReminderOffsets[1].Duration() != 24*time.Hour {
+		t.Errorf("Expected offsets [10m 1d], got %v", event.ReminderOffsets)
+	}
+	if len(event.ReminderTimes) != 1 {
+		t.Errorf("Expected 1 reminder time, got %v", event.ReminderTimes)
+	}
+}
+
+func TestHandler_CreateEvent_InvalidReminder(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	body := map[string]interface{}{
+		"user_id":          1,
+		"date":             "2026-03-15",
+		"event":            "Test event",
+		"reminder_offsets": []string{"soon"},
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateEvent(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandler_CreateEvent_StartEnd(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	body := map[string]interface{}{
+		"user_id": 1,
+		"start":   "2026-03-15T14:00:00+03:00",
+		"end":     "2026-03-15T15:30:00+03:00",
+		"event":   "Meeting",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateEvent(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	event := usecases.events[1]
+	if event.AllDay || event.End.Sub(event.Date) != 90*time.Minute {
+		t.Errorf("Expected 90 minute timed event, got %v - %v (all day %v)", event.Date, event.End, event.AllDay)
+	}
+}
+
+func TestHandler_CreateEvent_AllDayRange(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	body := map[string]interface{}{
+		"user_id": 1,
+		"start":   "2026-03-15",
+		"end":     "2026-03-17",
+		"event":   "Conference",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateEvent(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	event := usecases.events[1]
+	want := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+	if !event.AllDay || !event.End.Equal(want) {
+		t.Errorf("Expected all-day event ending %v, got %v (all day %v)", want, event.End, event.AllDay)
+	}
+}
+
+func TestHandler_CreateEvent_InvalidStart(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	body := map[string]interface{}{
+		"user_id": 1,
+		"start":   "15.03.2026 14:00",
+		"event":   "Meeting",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateEvent(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandler_CreateEvent_RRule(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+
+	for _, tc := range []struct {
+		rrule string
+		code  int
+	}{
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR", http.StatusOK},
+		{"FREQ=SOMETIMES", http.StatusBadRequest},
+	} {
+		body := map[string]interface{}{
+			"user_id": 1,
+			"start":   "2026-03-16T10:00:00Z",
+			"event":   "Standup",
+			"rrule":   tc.rrule,
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler.CreateEvent(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("rrule %q: expected status %d, got %d", tc.rrule, tc.code, w.Code)
+		}
+	}
+	if event := usecases.events[1]; event.Recurrence == nil || len(event.Recurrence.ByDay) != 3 {
+		t.Errorf("Expected weekly recurrence on 3 days, got %+v", event.Recurrence)
+	}
+}
+
+func TestHandler_DeleteEvent_ScopeRequiresOriginalStart(t *testing.T) {
+	usecases := NewMockUsecases()
+	logger := log_worker.NewLogger()
+	handler := NewHandler(usecases, NewMockUsers(), logger)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Series"})
+
+	for _, tc := range []struct {
+		body map[string]interface{}
+		code int
+	}{
+		{map[string]interface{}{"event_id": 1, "scope": "this"}, http.StatusBadRequest},
+		{map[string]interface{}{"event_id": 1, "scope": "sometimes", "original_start": "2026-03-15"}, http.StatusBadRequest},
+		{map[string]interface{}{"event_id": 1, "user_id": 1, "scope": "this", "original_start": "2026-03-15T10:00:00Z"}, http.StatusOK},
+	} {
+		jsonBody, _ := json.Marshal(tc.body)
+		req := httptest.NewRequest("POST", "/delete_event", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		handler.DeleteEvent(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%v: expected status %d, got %d", tc.body, tc.code, w.Code)
+		}
+	}
+}
+
+func TestHandler_EventsForMonth_TimeZone(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	usecases := NewMockUsecases()
+	users := NewMockUsers()
+	users.settings[1] = domain.UserSettings{UserId: 1, TimeZone: "Europe/Moscow"}
+	handler := NewHandler(usecases, users, log_worker.NewLogger())
+
+	// Параметр tz важнее настроек пользователя
+	req := httptest.NewRequest("GET", "/events_for_month?user_id=1&date=2026-03-15&tz=America/New_York", nil)
+	w := httptest.NewRecorder()
+	handler.EventsForMonth(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, newYork); !usecases.lastStart.Equal(want) {
+		t.Errorf("Expected month start %v, got %v", want, usecases.lastStart)
+	}
+
+	req = httptest.NewRequest("GET", "/events_for_day?user_id=1&date=2026-03-15", nil)
+	w = httptest.NewRecorder()
+	handler.EventsForDay(w, req)
+	if got := usecases.lastStart; got.Location().String() != "Europe/Moscow" || got.Hour() != 0 {
+		t.Errorf("Expected midnight in user's time zone, got %v", got)
+	}
+
+	req = httptest.NewRequest("GET", "/events_for_day?user_id=1&date=2026-03-15&tz=Mars/Olympus", nil)
+	w = httptest.NewRecorder()
+	handler.EventsForDay(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown time zone, got %d", w.Code)
+	}
+}
+
+func TestHandler_ErrorStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{domain.ErrEventNotFound, http.StatusNotFound, "event_not_found"},
+		{fmt.Errorf("failed to update event: %w", domain.NewValidationError("invalid_rrule", "invalid rrule")), http.StatusBadRequest, "invalid_rrule"},
+		{domain.NewConflictError("version_mismatch", "event was modified"), http.StatusConflict, "version_mismatch"},
+		{domain.NewForbiddenError("forbidden", "access denied"), http.StatusForbidden, "forbidden"},
+		{errors.New("pq: connection refused"), http.StatusInternalServerError, "internal"},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		writeUsecaseError(w, tc.err)
+
+		if w.Code != tc.status {
+			t.Errorf("%v: expected status %d, got %d", tc.err, tc.status, w.Code)
+		}
+		var response types.ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Code != tc.code {
+			t.Errorf("%v: expected code %q, got %q", tc.err, tc.code, response.Code)
+		}
+	}
+}
+
+func TestHandler_UpdateEvent_NotFound(t *testing.T) {
+	handler := NewHandler(NewMockUsecases(), NewMockUsers(), log_worker.NewLogger())
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"event_id": 42,
+		"user_id":  1,
+		"date":     "2026-03-16",
+		"event":    "Missing",
+	})
+	req := httptest.NewRequest("POST", "/update_event", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.UpdateEvent(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_DeleteEvent_NotFound(t *testing.T) {
+	handler := NewHandler(NewMockUsecases(), NewMockUsers(), log_worker.NewLogger())
+
+	req := httptest.NewRequest("POST", "/delete_event", bytes.NewBufferString(`{"event_id": 42, "user_id": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.DeleteEvent(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	var response types.ErrorResponse
+	_ = json.NewDecoder(w.Body).Decode(&response)
+	if response.Code != "event_not_found" {
+		t.Errorf("Expected code event_not_found, got %q", response.Code)
+	}
+}
+
+func TestServer_DeleteEvent_OtherUser(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newAuthServer(t, usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 2, Date: time.Now(), Description: "Not yours"})
+
+	req := httptest.NewRequest("POST", "/delete_event", bytes.NewBufferString(`{"event_id": 1}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer cal_user")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	if _, ok := usecases.events[1]; !ok {
+		t.Error("Event of another user must not be deleted")
+	}
+}
+
+// TestServer_LegacyIfMatch старые маршруты проверяют If-Match, только если он передан
+func TestServer_LegacyIfMatch(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+	usecases.CreateEvent(context.Background(), &domain.Event{UserId: 1, Date: time.Now(), Description: "Shared"})
+
+	update := map[string]any{"event_id": 1, "user_id": 1, "date": "2026-03-16", "event": "Updated"}
+	remove := map[string]any{"event_id": 1, "user_id": 1}
+	for _, tc := range []struct {
+		path    string
+		ifMatch string
+		code    int
+	}{
+		{"/update_event", "", http.StatusOK},
+		{"/update_event", `"5"`, http.StatusPreconditionFailed},
+		{"/update_event", `"2"`, http.StatusOK},
+		{"/delete_event", `"1"`, http.StatusPreconditionFailed},
+		{"/delete_event", "", http.StatusOK},
+	} {
+		body := update
+		if tc.path == "/delete_event" {
+			body = remove
+		}
+		req := httptest.NewRequest("POST", tc.path, jsonBody(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		if w.Code != tc.code {
+			t.Errorf("%s If-Match %q: expected status %d, got %d: %s", tc.path, tc.ifMatch, tc.code, w.Code, w.Body)
+		}
+	}
+}
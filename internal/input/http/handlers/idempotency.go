@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+// maxIdempotentBody тело запроса с Idempotency-Key читается целиком для хеша
+const maxIdempotentBody = 1 << 20
+
+// idempotent выполняет запрос с заголовком Idempotency-Key один раз: повтор с тем же ключом и телом
+// получает сохранённый ответ, с другим телом — 422. Ответы 5xx не сохраняются, такой запрос можно повторить.
+// Запросы без заголовка выполняются как обычно.
+func idempotent(store port.IdempotencyUsecases, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || store == nil {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			writeErrorCode(w, "request body is too large", "request_too_large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Без аутентификации ключи общие: пользователь из тела входит в хеш запроса
+		var userID int64
+		if principal, ok := principalFrom(r.Context()); ok {
+			userID = principal.UserId
+		}
+		stored, err := store.Begin(r.Context(), userID, key, requestHash(r, body))
+		if err != nil {
+			writeUsecaseError(w, err)
+			return
+		}
+		if stored != nil {
+			replayResponse(w, *stored)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		// Ответ клиенту уже отправлен, сохранить его нужно, даже если клиент отключился
+		ctx := context.WithoutCancel(r.Context())
+		if rec.status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, userID, key); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		err = store.Complete(ctx, domain.IdempotencyRecord{
+			Key:         key,
+			UserId:      userID,
+			StatusCode:  rec.status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Location:    rec.Header().Get("Location"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("failed to save idempotent response: %v", err)
+		}
+	}
+}
+
+// requestHash хеш метода, пути, типа и тела запроса
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.Header.Get("Content-Type")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayResponse отправляет сохранённый ответ; Idempotent-Replayed отличает его от нового
+func replayResponse(w http.ResponseWriter, record domain.IdempotencyRecord) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	if record.Location != "" {
+		w.Header().Set("Location", record.Location)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+// responseRecorder пишет ответ клиенту и одновременно запоминает его
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
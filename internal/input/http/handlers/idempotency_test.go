@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+type MockIdempotency struct {
+	records map[string]domain.IdempotencyRecord
+}
+
+func (m *MockIdempotency) Begin(ctx context.Context, userID int64, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	existing, ok := m.records[key]
+	if !ok {
+		m.records[key] = domain.IdempotencyRecord{Key: key, UserId: userID, RequestHash: requestHash}
+		return nil, nil
+	}
+	if existing.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return &existing, nil
+}
+
+func (m *MockIdempotency) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	record.RequestHash = m.records[record.Key].RequestHash
+	m.records[record.Key] = record
+	return nil
+}
+
+func (m *MockIdempotency) Release(ctx context.Context, userID int64, key string) error {
+	delete(m.records, key)
+	return nil
+}
+
+func TestServer_IdempotentCreate(t *testing.T) {
+	usecases := NewMockUsecases()
+	store := &MockIdempotency{records: map[string]domain.IdempotencyRecord{}}
+	srv := NewServer(usecases, NewMockUsers(), &MockReminders{}, &MockAuth{}, store, NewMockFeeds(), nil, log_worker.NewLogger())
+
+	post := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"user_id": 1, "start": "2026-03-15T10:00:00Z", "event": "Standup"}`
+
+	first := post("/v1/events", "retry-1", body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", first.Code, first.Body)
+	}
+	retry := post("/v1/events", "retry-1", body)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected replayed 201 %s, got %d %s", first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get("Location") != "/v1/events/1" || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected replayed Location and Idempotent-Replayed headers, got %v", retry.Header())
+	}
+	if len(usecases.events) != 1 {
+		t.Errorf("Expected a single event after retry, got %d", len(usecases.events))
+	}
+
+	if w := post("/v1/events", "retry-1", `{"user_id": 1, "start": "2026-03-16T10:00:00Z", "event": "Other"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for key reuse with another body, got %d", w.Code)
+	}
+	// Ключ относится к конкретному запросу: тот же ключ на другом маршруте — другой запрос
+	if w := post("/create_event", "retry-1", body); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for key reuse on another route, got %d", w.Code)
+	}
+
+	// Ошибка валидации тоже сохраняется и повторяется
+	if w := post("/create_event", "retry-2", `{"user_id": 1}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	if w := post("/create_event", "retry-2", `{"user_id": 1}`); w.Code != http.StatusBadRequest || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected replayed 400, got %d", w.Code)
+	}
+
+	// Без заголовка каждый запрос создаёт событие
+	for range 2 {
+		req := httptest.NewRequest("POST", "/create_event", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(usecases.events) != 3 {
+		t.Errorf("Expected 3 events, got %d", len(usecases.events))
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dontpanicw/calendar/internal/adapter/ical"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+)
+
+// maxImportSize наибольший размер загружаемого файла календаря
+const maxImportSize = 10 << 20
+
+// ImportICSV1 POST /v1/import/ics — импорт файла .ics из поля file (multipart/form-data).
+// Событие с UID, импортированным ранее, обновляется, а не создаётся заново; отменённое (STATUS:CANCELLED) — удаляется.
+// Ответ — отчёт по каждому событию; ошибка в одном событии не мешает импорту остальных.
+func (h *Handler) ImportICSV1(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErrorCode(w, "file is too large", "payload_too_large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeError(w, "multipart field file with .ics calendar is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	loc, err := h.userLocation(r, userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+
+	decoder := ical.NewDecoder(file)
+	decoder.Location = loc
+	items, err := decoder.Decode()
+	if err != nil {
+		writeErrorCode(w, err.Error(), "invalid_calendar", http.StatusBadRequest)
+		return
+	}
+
+	report := types.ImportReport{Items: make([]types.ImportItem, 0, len(items))}
+	for _, item := range items {
+		result := types.ImportItem{UID: item.UID}
+		switch {
+		case item.Err != nil:
+			result.Status, result.Error = domain.ImportFailed, item.Err.Error()
+		case item.Cancelled:
+			result.Status, result.EventID, err = h.usecases.CancelImportedEvent(r.Context(), userID, item.UID)
+			if err != nil {
+				result.Error = err.Error()
+			}
+		default:
+			event := item.Event
+			event.UserId = userID
+			result.Status, err = h.usecases.ImportEvent(r.Context(), &event)
+			if err != nil {
+				result.Error = err.Error()
+			}
+			result.EventID = event.EventId
+		}
+		switch result.Status {
+		case domain.ImportCreated:
+			report.Created++
+		case domain.ImportUpdated:
+			report.Updated++
+		case domain.ImportDeleted:
+			report.Deleted++
+		case domain.ImportSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Items = append(report.Items, result)
+	}
+	writeJSON(w, http.StatusOK, report)
+}
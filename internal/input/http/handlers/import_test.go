@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+)
+
+const importCalendar = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\nUID:a@example.com\r\nDTSTART:20260315T100000Z\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:b@example.com\r\nDTSTART;VALUE=DATE:20260316\r\nSUMMARY:Holiday\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:c@example.com\r\nDTSTART:20260317T100000Z\r\nSTATUS:CANCELLED\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:d@example.com\r\nSUMMARY:No start\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func importRequest(t *testing.T, field, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("user_id", "1")
+	fw, err := mw.CreateFormFile(field, "calendar.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write([]byte(content))
+	_ = mw.Close()
+	req := httptest.NewRequest("POST", "/v1/import/ics", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestServer_ImportICSV1(t *testing.T) {
+	usecases := NewMockUsecases()
+	srv := newV1Server(usecases)
+
+	importFile := func() types.ImportReport {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, importRequest(t, "file", importCalendar))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var report types.ImportReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return report
+	}
+
+	report := importFile()
+	if report.Created != 2 || report.Skipped != 1 || report.Failed != 1 || len(report.Items) != 4 {
+		t.Errorf("Unexpected first report: %+v", report)
+	}
+	if item := report.Items[3]; item.UID != "d@example.com" || item.Status != domain.ImportFailed || item.Error == "" {
+		t.Errorf("Expected failed item with error, got %+v", item)
+	}
+	if e := usecases.events[2]; !e.AllDay || e.ExternalUID != "b@example.com" || e.UserId != 1 {
+		t.Errorf("Unexpected imported event: %+v", e)
+	}
+
+	// Повторный импорт того же файла не создаёт дублей
+	report = importFile()
+	if report.Created != 0 || report.Skipped != 3 || len(usecases.events) != 2 {
+		t.Errorf("Unexpected repeated report: %+v, events %d", report, len(usecases.events))
+	}
+
+	// Отменённое в исходном календаре событие удаляется
+	cancelled := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:a@example.com\r\nDTSTART:20260315T100000Z\r\nSTATUS:CANCELLED\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, importRequest(t, "file", cancelled))
+	report = types.ImportReport{}
+	_ = json.NewDecoder(w.Body).Decode(&report)
+	if report.Deleted != 1 || report.Skipped != 0 || report.Items[0].Status != domain.ImportDeleted || report.Items[0].EventID != 1 {
+		t.Errorf("Unexpected report for cancelled event: %+v", report)
+	}
+	if _, ok := usecases.events[1]; ok || len(usecases.events) != 1 {
+		t.Errorf("Expected cancelled event deleted, got %d events", len(usecases.events))
+	}
+
+	for _, tc := range []struct {
+		field, content string
+		code           int
+	}{
+		{"other", importCalendar, http.StatusBadRequest},
+		{"file", "not a calendar", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, importRequest(t, tc.field, tc.content))
+		if w.Code != tc.code {
+			t.Errorf("field %s: expected status %d, got %d", tc.field, tc.code, w.Code)
+		}
+	}
+}
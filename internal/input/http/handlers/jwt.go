@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// jwtLeeway допустимое расхождение часов при проверке exp и nbf
+const jwtLeeway = time.Minute
+
+// JWTConfig настройки проверки JWT. Принимаются только алгоритмы, для которых задан ключ:
+// HMACSecret — HS256, RSAPublicKey — RS256. Пустые Issuer и Audience не проверяются.
+type JWTConfig struct {
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	Issuer       string
+	Audience     string
+}
+
+// JWTVerifier проверяет подпись и claims JWT. Пользователь берётся из sub, права администратора — из scope "admin".
+type JWTVerifier struct {
+	cfg JWTConfig
+	now func() time.Time
+}
+
+func NewJWTVerifier(cfg JWTConfig) *JWTVerifier {
+	return &JWTVerifier{cfg: cfg, now: time.Now}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *float64    `json:"exp"`
+	NotBefore *float64    `json:"nbf"`
+	Scope     string      `json:"scope"`
+}
+
+// jwtAudience aud по RFC 7519 — строка или массив строк
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+func invalidToken(format string, args ...any) error {
+	return domain.NewUnauthorizedError("invalid_token", format, args...)
+}
+
+// Verify проверяет токен и возвращает пользователя, от имени которого он выпущен
+func (v *JWTVerifier) Verify(token string) (domain.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return domain.Principal{}, invalidToken("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return domain.Principal{}, invalidToken("malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return domain.Principal{}, invalidToken("malformed token signature")
+	}
+	if err := v.verifySignature(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+		return domain.Principal{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return domain.Principal{}, invalidToken("malformed token claims")
+	}
+	return v.checkClaims(claims)
+}
+
+// verifySignature алгоритм из заголовка должен совпадать с настроенным ключом,
+// иначе токен HS256 можно было бы подписать публичным RSA-ключом
+func (v *JWTVerifier) verifySignature(alg, signingInput string, signature []byte) error {
+	switch {
+	case alg == "HS256" && v.cfg.HMACSecret != nil:
+		mac := hmac.New(sha256.New, v.cfg.HMACSecret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return invalidToken("invalid token signature")
+		}
+		return nil
+	case alg == "RS256" && v.cfg.RSAPublicKey != nil:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(v.cfg.RSAPublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return invalidToken("invalid token signature")
+		}
+		return nil
+	default:
+		return invalidToken("unsupported token algorithm %q", alg)
+	}
+}
+
+func (v *JWTVerifier) checkClaims(claims jwtClaims) (domain.Principal, error) {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return domain.Principal{}, invalidToken("token has no expiration")
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(jwtLeeway)) {
+		return domain.Principal{}, domain.NewUnauthorizedError("token_expired", "token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(numericDate(*claims.NotBefore)) {
+		return domain.Principal{}, invalidToken("token is not valid yet")
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return domain.Principal{}, invalidToken("unexpected token issuer")
+	}
+	if v.cfg.Audience != "" && !slices.Contains(claims.Audience, v.cfg.Audience) {
+		return domain.Principal{}, invalidToken("unexpected token audience")
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return domain.Principal{}, invalidToken("token subject must be a positive user id")
+	}
+	return domain.Principal{
+		UserId: userID,
+		Admin:  slices.Contains(strings.Fields(claims.Scope), "admin"),
+	}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericDate NumericDate из RFC 7519: секунды от эпохи, возможно дробные
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// ParseRSAPublicKey читает публичный ключ RS256 из PEM (PKIX или PKCS #1)
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not RSA")
+	}
+	return rsaKey, nil
+}
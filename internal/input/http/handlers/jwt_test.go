@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+var jwtTestNow = time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func signJWT(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "42",
+		"iss": "https://auth.example.com",
+		"aud": []string{"calendar", "other"},
+		"exp": jwtTestNow.Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(cfg JWTConfig) *JWTVerifier {
+	cfg.Issuer = "https://auth.example.com"
+	cfg.Audience = "calendar"
+	v := NewJWTVerifier(cfg)
+	v.now = func() time.Time { return jwtTestNow }
+	return v
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	secret := []byte("test-secret")
+	v := newTestVerifier(JWTConfig{HMACSecret: secret})
+
+	claims := validClaims()
+	claims["scope"] = "events admin"
+	principal, err := v.Verify(signJWT(t, "HS256", secret, claims))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if principal.UserId != 42 || !principal.Admin {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	cases := map[string]func(map[string]any){
+		"expired":      func(c map[string]any) { c["exp"] = jwtTestNow.Add(-time.Hour).Unix() },
+		"no exp":       func(c map[string]any) { delete(c, "exp") },
+		"not yet":      func(c map[string]any) { c["nbf"] = jwtTestNow.Add(time.Hour).Unix() },
+		"wrong issuer": func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"wrong aud":    func(c map[string]any) { c["aud"] = "other" },
+		"bad subject":  func(c map[string]any) { c["sub"] = "alice" },
+	}
+	for name, mutate := range cases {
+		claims := validClaims()
+		mutate(claims)
+		if _, err := v.Verify(signJWT(t, "HS256", secret, claims)); !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("%s: expected ErrUnauthorized, got %v", name, err)
+		}
+	}
+
+	if _, err := v.Verify(signJWT(t, "HS256", []byte("other-secret"), validClaims())); err == nil {
+		t.Error("expected error for wrong signature")
+	}
+}
+
+func TestJWTVerifier_RS256(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	public, err := ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("ParseRSAPublicKey: %v", err)
+	}
+	v := newTestVerifier(JWTConfig{RSAPublicKey: public})
+
+	principal, err := v.Verify(signJWT(t, "RS256", private, validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if principal.UserId != 42 || principal.Admin {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	// HS256, подписанный публичным ключом, не должен приниматься, когда настроен только RS256
+	if _, err := v.Verify(signJWT(t, "HS256", der, validClaims())); err == nil {
+		t.Error("expected error for HS256 token when only RS256 is configured")
+	}
+	if _, err := v.Verify(signJWT(t, "none", nil, validClaims())); err == nil {
+		t.Error("expected error for unsigned token")
+	}
+}
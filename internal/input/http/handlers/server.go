@@ -4,6 +4,7 @@ import (
 	"github.com/dontpanicw/calendar/log_worker"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/dontpanicw/calendar/internal/port"
@@ -11,30 +12,75 @@ import (
 
 type Server struct {
 	mux *http.ServeMux
+	// public маршруты без аутентификации: доступ к ним даёт токен в пути
+	public *http.ServeMux
+	// auth nil — аутентификация отключена, пользователь берётся из user_id запроса
+	auth *Authenticator
 }
 
-func NewServer(usecases port.EventUsecases, logger *log_worker.Logger) *Server {
+// idempotency nil — заголовок Idempotency-Key не поддерживается
+func NewServer(usecases port.EventUsecases, users port.UserUsecases, reminders port.ReminderUsecases, keys port.AuthUsecases,
+	idempotency port.IdempotencyUsecases, feeds port.FeedUsecases, auth *Authenticator, logger *log_worker.Logger) *Server {
 	s := &Server{
-		mux: http.NewServeMux(),
+		mux:    http.NewServeMux(),
+		public: http.NewServeMux(),
+		auth:   auth,
 	}
-	h := NewHandler(usecases, logger)
+	h := NewHandler(usecases, users, logger)
+	uh := NewUserHandler(users, logger)
+	ah := NewAdminHandler(reminders, logger)
+	kh := NewAPIKeyHandler(keys, logger)
+	fh := NewFeedHandler(feeds, usecases, users, logger)
 
-	s.mux.HandleFunc("POST /create_event", h.CreateEvent)
+	s.mux.HandleFunc("POST /create_event", idempotent(idempotency, h.CreateEvent))
 	s.mux.HandleFunc("POST /update_event", h.UpdateEvent)
 	s.mux.HandleFunc("POST /delete_event", h.DeleteEvent)
+	s.mux.HandleFunc("GET /event", h.GetEvent)
 	s.mux.HandleFunc("GET /events_for_day", h.EventsForDay)
 	s.mux.HandleFunc("GET /events_for_week", h.EventsForWeek)
 	s.mux.HandleFunc("GET /events_for_month", h.EventsForMonth)
 
+	// REST API; RPC-маршруты выше оставлены для существующих клиентов
+	s.mux.HandleFunc("GET /v1/events", h.ListEventsV1)
+	s.mux.HandleFunc("POST /v1/events", idempotent(idempotency, h.CreateEventV1))
+	s.mux.HandleFunc("GET /v1/events/{id}", h.GetEventV1)
+	s.mux.HandleFunc("PUT /v1/events/{id}", h.UpdateEventV1)
+	s.mux.HandleFunc("PATCH /v1/events/{id}", h.PatchEventV1)
+	s.mux.HandleFunc("DELETE /v1/events/{id}", h.DeleteEventV1)
+	// {id}:archive и {id}:unarchive — шаблон ServeMux не делит сегмент пути, действие разбирает обработчик
+	s.mux.HandleFunc("POST /v1/events/{id}", h.EventActionV1)
+	s.mux.HandleFunc("GET /v1/archive", h.ListArchiveV1)
+	s.mux.HandleFunc("GET /v1/users/{id}/calendar.ics", h.ExportCalendarV1)
+	s.mux.HandleFunc("POST /v1/import/ics", h.ImportICSV1)
+	s.mux.HandleFunc("GET /v1/export/events", h.ExportEventsV1)
+	s.mux.HandleFunc("POST /v1/import/events", h.ImportEventsV1)
+	s.mux.HandleFunc("POST /v1/users/{id}/feed", fh.RotateFeedV1)
+	s.mux.HandleFunc("DELETE /v1/users/{id}/feed", fh.RevokeFeedV1)
+	s.public.HandleFunc("GET /feeds/{token}", fh.Feed)
+	// Календарные приложения ищут CalDAV по /.well-known/caldav (RFC 6764)
+	s.public.Handle("/.well-known/caldav", http.RedirectHandler(davPrefix, http.StatusMovedPermanently))
+	s.mux.Handle(davPrefix, NewCalDAVHandler(usecases, users, logger))
+
+	s.mux.HandleFunc("GET /user_settings", uh.GetSettings)
+	s.mux.HandleFunc("POST /update_user_settings", uh.UpdateSettings)
+	s.mux.HandleFunc("POST /api_keys", kh.CreateAPIKey)
+	s.mux.HandleFunc("GET /admin/reminders/dead", requireAdmin(ah.DeadReminders))
+	s.mux.HandleFunc("POST /admin/reminders/{id}/redrive", requireAdmin(ah.RedriveReminder))
+
 	return s
 }
 
-// loggingMiddleware логирует каждый запрос: метод, URL, время (на stdout)
-func loggingMiddleware(next http.Handler) http.Handler {
+// loggingMiddleware логирует каждый запрос: метод, URL, время (на stdout).
+// url — что записать вместо URL запроса; пусто — сам URL.
+func loggingMiddleware(next http.Handler, url string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.String(), time.Since(start))
+		logged := url
+		if logged == "" {
+			logged = r.URL.String()
+		}
+		log.Printf("%s %s %v", r.Method, logged, time.Since(start))
 	})
 }
 
@@ -53,8 +99,26 @@ func recoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// ServeHTTP реализует http.Handler с middleware: recovery, затем логирование
+// ServeHTTP реализует http.Handler с middleware: логирование, recovery, затем аутентификация
+// (кроме публичных маршрутов)
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler := loggingMiddleware(recoveryMiddleware(s.mux))
+	var handler http.Handler = s.mux
+	logged := ""
+	if _, pattern := s.public.Handler(r); pattern != "" {
+		handler = s.public
+		// Путь публичного маршрута содержит токен доступа, в лог пишется шаблон маршрута
+		logged = publicPath(pattern)
+	} else if s.auth != nil {
+		handler = s.auth.Middleware(handler)
+	}
+	handler = loggingMiddleware(recoveryMiddleware(handler), logged)
 	handler.ServeHTTP(w, r)
 }
+
+// publicPath путь из шаблона маршрута ServeMux без метода: "GET /feeds/{token}" — "/feeds/{token}"
+func publicPath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/input/http/types"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/log_worker"
+)
+
+type UserHandler struct {
+	users  port.UserUsecases
+	logger *log_worker.Logger
+}
+
+func NewUserHandler(users port.UserUsecases, logger *log_worker.Logger) *UserHandler {
+	return &UserHandler{
+		users:  users,
+		logger: logger,
+	}
+}
+
+func (h *UserHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserID(r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if userID, err = actingUser(r, userID); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	settings, err := h.users.GetSettings(r.Context(), userID)
+	if err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeResult(w, settings)
+}
+
+func (h *UserHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := parseBodyUserSettings(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if settings.UserId, err = actingUser(r, settings.UserId); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	if err := h.users.UpdateSettings(r.Context(), settings); err != nil {
+		writeUsecaseError(w, err)
+		return
+	}
+	writeResultMessage(w, "settings updated")
+}
+
+// parseBodyUserSettings парсит JSON или form для изменения настроек пользователя
+func parseBodyUserSettings(r *http.Request) (domain.UserSettings, error) {
+	ct := r.Header.Get("Content-Type")
+	if strings.Contains(ct, "application/json") {
+		var req types.UpdateUserSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return domain.UserSettings{}, errors.New("invalid JSON body")
+		}
+		return domain.UserSettings{
+			UserId:        req.UserID,
+			NotifyChannel: req.NotifyChannel,
+			NotifyTarget:  req.NotifyTarget,
+			TimeZone:      req.TimeZone,
+		}, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return domain.UserSettings{}, errors.New("invalid form body")
+	}
+	userID, err := parseUserID(r.FormValue("user_id"))
+	if err != nil {
+		return domain.UserSettings{}, err
+	}
+	return domain.UserSettings{
+		UserId:        userID,
+		NotifyChannel: r.FormValue("notify_channel"),
+		NotifyTarget:  r.FormValue("notify_target"),
+		TimeZone:      r.FormValue("time_zone"),
+	}, nil
+}
@@ -1,21 +1,58 @@
 package types
 
-// CreateEventRequest запрос на создание события (user_id, date YYYY-MM-DD, event — текст)
+// CreateEventRequest запрос на создание события (user_id, start/end, event — текст).
+// Date — устаревший синоним start.
 type CreateEventRequest struct {
-	UserID      int64  `json:"user_id" form:"user_id"`
-	Date        string `json:"date" form:"date"` // YYYY-MM-DD
-	Event       string `json:"event" form:"event"`
+	UserID          int64    `json:"user_id" form:"user_id"`
+	Date            string   `json:"date" form:"date"`   // YYYY-MM-DD
+	Start           string   `json:"start" form:"start"` // YYYY-MM-DD (весь день) или RFC 3339
+	End             string   `json:"end" form:"end"`     // последний день включительно или RFC 3339
+	Event           string   `json:"event" form:"event"`
+	ReminderOffsets []string `json:"reminder_offsets" form:"reminder_offsets"` // "10m", "1h", "1d"
+	ReminderTimes   []string `json:"reminder_times" form:"reminder_times"`     // RFC 3339
+	NotifyChannel   string   `json:"notify_channel" form:"notify_channel"`     // log, webhook, email
+	NotifyTarget    string   `json:"notify_target" form:"notify_target"`       // URL или email
+	RRule           string   `json:"rrule" form:"rrule"`                       // RFC 5545, например FREQ=WEEKLY;BYDAY=MO
+	TimeZone        string   `json:"time_zone" form:"time_zone"`               // пояс серии, по умолчанию из настроек пользователя
 }
 
 // UpdateEventRequest запрос на обновление события
 type UpdateEventRequest struct {
-	EventID     int64  `json:"event_id" form:"event_id"`
-	UserID      int64  `json:"user_id" form:"user_id"`
-	Date        string `json:"date" form:"date"`
-	Event       string `json:"event" form:"event"`
+	EventID         int64    `json:"event_id" form:"event_id"`
+	UserID          int64    `json:"user_id" form:"user_id"`
+	Date            string   `json:"date" form:"date"`
+	Start           string   `json:"start" form:"start"`
+	End             string   `json:"end" form:"end"`
+	Event           string   `json:"event" form:"event"`
+	ReminderOffsets []string `json:"reminder_offsets" form:"reminder_offsets"`
+	ReminderTimes   []string `json:"reminder_times" form:"reminder_times"`
+	NotifyChannel   string   `json:"notify_channel" form:"notify_channel"`
+	NotifyTarget    string   `json:"notify_target" form:"notify_target"`
+	RRule           string   `json:"rrule" form:"rrule"`
+	TimeZone        string   `json:"time_zone" form:"time_zone"`
+	Scope           string   `json:"scope" form:"scope"`                   // this, following, all (по умолчанию)
+	OriginalStart   string   `json:"original_start" form:"original_start"` // исходное начало повторения для this и following
 }
 
 // DeleteEventRequest запрос на удаление события
 type DeleteEventRequest struct {
-	EventID int64 `json:"event_id" form:"event_id"`
+	EventID       int64  `json:"event_id" form:"event_id"`
+	UserID        int64  `json:"user_id" form:"user_id"`
+	Scope         string `json:"scope" form:"scope"`
+	OriginalStart string `json:"original_start" form:"original_start"`
+}
+
+// UpdateUserSettingsRequest запрос на изменение настроек пользователя
+type UpdateUserSettingsRequest struct {
+	UserID        int64  `json:"user_id" form:"user_id"`
+	NotifyChannel string `json:"notify_channel" form:"notify_channel"` // log, webhook, email
+	NotifyTarget  string `json:"notify_target" form:"notify_target"`   // URL или email
+	TimeZone      string `json:"time_zone" form:"time_zone"`           // IANA, например Europe/Moscow
+}
+
+// CreateAPIKeyRequest запрос на выпуск API-ключа
+type CreateAPIKeyRequest struct {
+	UserID int64  `json:"user_id" form:"user_id"`
+	Name   string `json:"name" form:"name"`
+	Admin  bool   `json:"admin" form:"admin"`
 }
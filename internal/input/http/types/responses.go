@@ -1,16 +1,78 @@
 package types
 
+import "github.com/dontpanicw/calendar/internal/domain"
+
 // APIResponse успешный ответ: {"result": "..."}
 type APIResponse struct {
 	Result string `json:"result"`
 }
 
-// ErrorResponse ответ с ошибкой: {"error": "описание ошибки"}
+// ErrorResponse ответ с ошибкой: {"error": "описание ошибки", "code": "event_not_found"}.
+// Code стабилен и предназначен для обработки на клиенте, Error — для человека.
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
 // EventsResponse ответ со списком событий (result может содержать JSON массива)
 type EventsResponse struct {
 	Result interface{} `json:"result"`
 }
+
+// CreateAPIKeyResponse выпущенный ключ; Key возвращается только один раз
+type CreateAPIKeyResponse struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+// FeedTokenResponse выпущенный токен подписки; Token и Path возвращаются только один раз
+type FeedTokenResponse struct {
+	domain.FeedToken
+	Token string `json:"token"`
+	// Path путь ленты для календаря, например /feeds/feed_....ics; схему webcal:// и хост добавляет клиент
+	Path string `json:"path"`
+}
+
+// EventListResponse список событий в API v1. NextPageToken передаётся в page_token за следующей страницей;
+// пусто — страница последняя.
+type EventListResponse struct {
+	Events        []domain.Event `json:"events"`
+	NextPageToken string         `json:"next_page_token,omitempty"`
+}
+
+// ImportReport итог импорта календаря: сколько событий создано, обновлено, удалено, пропущено и не импортировано,
+// и результат по каждому событию файла
+type ImportReport struct {
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Deleted int          `json:"deleted"`
+	Skipped int          `json:"skipped"`
+	Failed  int          `json:"failed"`
+	Items   []ImportItem `json:"items"`
+}
+
+// ImportItem результат импорта одного события; UID — из файла
+type ImportItem struct {
+	UID     string              `json:"uid"`
+	EventID int64               `json:"event_id,omitempty"`
+	Status  domain.ImportStatus `json:"status"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// BulkImportReport итог загрузки событий из CSV или NDJSON. В Errors — только строки, которые не загружены.
+// Truncated — файл не дочитан: последняя ошибка — строка, на которой чтение прервалось, строки после неё не загружены.
+type BulkImportReport struct {
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Errors    []BulkImportError `json:"errors"`
+}
+
+// BulkImportError строка файла, которую не удалось загрузить; Row — номер строки, в CSV заголовок — строка 1
+type BulkImportError struct {
+	Row     int    `json:"row"`
+	EventID int64  `json:"event_id,omitempty"`
+	Error   string `json:"error"`
+}
@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// Notifier канал доставки напоминаний (вебхук, email, файл)
+type Notifier interface {
+	// Notify доставляет напоминание по адресу target (URL, email); смысл target зависит от канала
+	Notify(ctx context.Context, target string, reminder domain.Reminder) error
+}
@@ -10,9 +10,83 @@ import (
 // EventRepository интерфейс для работы с репозиторием событий
 type EventRepository interface {
 	CreateEvent(ctx context.Context, event *domain.Event) error
+	// UpdateEvent, DeleteEvent и GetEvent работают только с событиями пользователя:
+	// чужое событие — domain.ErrEventNotFound, как и несуществующее.
+	// UpdateEvent и DeleteEvent проверяют версию (event.Version, version), если она не 0: при несовпадении —
+	// domain.ErrVersionMismatch. UpdateEvent увеличивает версию.
 	UpdateEvent(ctx context.Context, event domain.Event) error
-	DeleteEvent(ctx context.Context, eventId int64) error
-	GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
-	GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error)
-	GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error)
+	DeleteEvent(ctx context.Context, userID, eventId, version int64) error
+	// GetEvent возвращает событие (для серии — саму серию, без развёртывания повторений)
+	GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error)
+	// GetEventByExternalUID импортированное событие пользователя по UID из внешнего календаря;
+	// domain.ErrEventNotFound, если такого нет
+	GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error)
+	// ListEvents страница событий, пересекающихся с [query.From, query.To); серии развёрнуты в повторения
+	ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error)
+	// ExportEvents передаёт fn события выборки одним проходом по началу, без развёртывания: серия — одно
+	// событие, если в выборку попадает её повторение. query.Sort, query.Limit и query.After не учитываются.
+	// Ошибка fn прекращает проход и возвращается.
+	ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error
+	// EventsMarker признак изменения всех событий пользователя без их чтения
+	EventsMarker(ctx context.Context, userID int64) (domain.EventsMarker, error)
+}
+
+// ReminderRepository интерфейс для работы с очередью напоминаний
+type ReminderRepository interface {
+	// ReplacePendingReminders заменяет неотправленные напоминания события на новые и проставляет им ReminderId
+	ReplacePendingReminders(ctx context.Context, eventId int64, reminders []domain.Reminder) error
+	DeletePendingReminders(ctx context.Context, eventId int64) error
+	GetPendingReminders(ctx context.Context) ([]domain.Reminder, error)
+	// MarkReminderSent, MarkReminderFailed, MarkReminderRetry и MarkReminderDead меняют только напоминание
+	// в статусе pending; domain.ErrReminderNotFound, если его уже заменили, удалили или перевели в другой статус
+	MarkReminderSent(ctx context.Context, reminderId int64) error
+	// MarkReminderFailed окончательная ошибка без повторов (например, событие уже началось)
+	MarkReminderFailed(ctx context.Context, reminderId int64, reason string) error
+	// MarkReminderRetry оставляет напоминание в очереди до следующей попытки
+	MarkReminderRetry(ctx context.Context, reminderId int64, attempts int, nextAttemptAt time.Time, reason string) error
+	// MarkReminderDead переводит напоминание в dead-letter очередь
+	MarkReminderDead(ctx context.Context, reminderId int64, attempts int, reason string) error
+	GetDeadReminders(ctx context.Context) ([]domain.Reminder, error)
+	// RedriveReminder возвращает напоминание из dead-letter очереди в pending со сброшенным счётчиком попыток
+	RedriveReminder(ctx context.Context, reminderId int64, at time.Time) (domain.Reminder, error)
+}
+
+// UserSettingsRepository интерфейс для работы с настройками пользователей
+type UserSettingsRepository interface {
+	// GetUserSettings возвращает настройки; если пользователь их не задавал — пустые настройки без ошибки
+	GetUserSettings(ctx context.Context, userID int64) (domain.UserSettings, error)
+	SaveUserSettings(ctx context.Context, settings domain.UserSettings) error
+}
+
+// APIKeyRepository интерфейс для работы с API-ключами
+type APIKeyRepository interface {
+	// CreateAPIKey сохраняет ключ и проставляет KeyId и CreatedAt
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	// GetAPIKeyByHash domain.ErrAPIKeyNotFound, если ключа с таким хешем нет
+	GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, error)
+}
+
+// FeedTokenRepository интерфейс для токенов подписки на календарь
+type FeedTokenRepository interface {
+	// SaveFeedToken сохраняет токен пользователя, заменяя прежний, и проставляет CreatedAt и ModifiedAt
+	SaveFeedToken(ctx context.Context, token *domain.FeedToken) error
+	// GetFeedTokenByHash domain.ErrFeedNotFound, если токена с таким хешем нет
+	GetFeedTokenByHash(ctx context.Context, hash string) (domain.FeedToken, error)
+	// DeleteFeedToken domain.ErrFeedNotFound, если у пользователя нет токена
+	DeleteFeedToken(ctx context.Context, userID int64) error
+	// TouchFeedContent запоминает хеш ленты; если он изменился, время изменения становится at.
+	// Возвращает время последнего изменения ленты.
+	TouchFeedContent(ctx context.Context, userID int64, contentHash string, at time.Time) (time.Time, error)
+}
+
+// IdempotencyRepository интерфейс для хранения ответов на запросы с Idempotency-Key
+type IdempotencyRepository interface {
+	// CreateIdempotencyRecord резервирует ключ пользователя. Если по ключу есть запись, срок которой
+	// на record.CreatedAt не истёк, она возвращается вместе с false и не меняется.
+	CreateIdempotencyRecord(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error)
+	// SaveIdempotencyResponse сохраняет ответ в зарезервированную запись
+	SaveIdempotencyResponse(ctx context.Context, record domain.IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, userID int64, key string) error
+	// DeleteExpiredIdempotencyRecords удаляет записи с истёкшим сроком
+	DeleteExpiredIdempotencyRecords(ctx context.Context) error
 }
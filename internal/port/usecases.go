@@ -10,9 +10,87 @@ import (
 // EventUsecases интерфейс use cases для событий
 type EventUsecases interface {
 	CreateEvent(ctx context.Context, event *domain.Event) error
-	UpdateEvent(ctx context.Context, event domain.Event) error
-	DeleteEvent(ctx context.Context, eventId int64) error
-	GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
-	GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error)
-	GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error)
+	// UpdateEvent меняет событие пользователя event.UserId;
+	// для this и following повторение серии задаётся в event.OriginalStart.
+	// UpdateEvent (event.Version), DeleteEvent и PatchEvent (version) при ненулевой версии
+	// меняют событие, только если она совпадает с текущей, иначе — domain.ErrVersionMismatch
+	UpdateEvent(ctx context.Context, event domain.Event, scope domain.EditScope) error
+	// DeleteEvent удаляет событие пользователя userID; occurrence — исходное начало повторения для scope this и following
+	DeleteEvent(ctx context.Context, userID, eventId, version int64, scope domain.EditScope, occurrence time.Time) error
+	// PatchEvent частичное изменение всего события (RFC 7396), возвращает событие после изменения
+	PatchEvent(ctx context.Context, userID, eventId, version int64, patch domain.EventPatch) (domain.Event, error)
+	// GetEvent событие пользователя userID, в том числе архивное; для серии — сама серия с правилом и исключениями
+	GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error)
+	// ArchiveEvent и UnarchiveEvent переносят событие в архив и обратно, возвращают событие после изменения.
+	// version — ожидаемая версия события, 0 — без проверки
+	ArchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error)
+	UnarchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error)
+	// GetEventsForDay, GetEventsForWeek и GetEventsForMonth — ListEvents за календарный период;
+	// архивные события — только с includeArchived
+	GetEventsForDay(ctx context.Context, userID int64, date time.Time, includeArchived bool) ([]domain.Event, error)
+	GetEventsForWeek(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error)
+	GetEventsForMonth(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error)
+	// ImportEvent сохраняет событие из внешнего календаря по event.ExternalUID: создаёт новое,
+	// обновляет импортированное ранее или пропускает, если оно не изменилось. Проставляет event.EventId.
+	// event.Version — ожидаемая версия импортированного ранее события, 0 — без проверки.
+	ImportEvent(ctx context.Context, event *domain.Event) (domain.ImportStatus, error)
+	// ReplaceEvent заменяет событие целиком, вместе с отменёнными и изменёнными повторениями серии,
+	// как ресурс CalDAV. event.Version — ожидаемая версия, 0 — без проверки; после замены — новая версия.
+	ReplaceEvent(ctx context.Context, event *domain.Event) error
+	// CancelImportedEvent удаляет событие пользователя, импортированное с этим UID и отменённое в исходном
+	// календаре: domain.ImportDeleted и id удалённого события; domain.ImportSkipped, если его нет
+	CancelImportedEvent(ctx context.Context, userID int64, uid string) (domain.ImportStatus, int64, error)
+	// GetEventByExternalUID событие пользователя, импортированное с этим UID; domain.ErrEventNotFound, если его нет
+	GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error)
+	// ListEvents страница событий за интервал [query.From, query.To) не длиннее года.
+	// query.Limit 0 — все события; архивные включаются только с query.IncludeArchived
+	ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error)
+	// ExportEvents передаёт fn события за интервал как ListEvents, но все сразу и без развёртывания серий.
+	// Ошибка проверки выборки возвращается до первого вызова fn.
+	ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error
+}
+
+// UserUsecases интерфейс use cases для настроек пользователя
+type UserUsecases interface {
+	GetSettings(ctx context.Context, userID int64) (domain.UserSettings, error)
+	UpdateSettings(ctx context.Context, settings domain.UserSettings) error
+}
+
+// ReminderUsecases интерфейс use cases для администрирования очереди напоминаний
+type ReminderUsecases interface {
+	ListDeadReminders(ctx context.Context) ([]domain.Reminder, error)
+	RedriveReminder(ctx context.Context, reminderId int64) error
+}
+
+// AuthUsecases интерфейс use cases для API-ключей
+type AuthUsecases interface {
+	// AuthenticateAPIKey возвращает владельца ключа; domain.ErrUnauthorized, если ключ неизвестен
+	AuthenticateAPIKey(ctx context.Context, key string) (domain.Principal, error)
+	// CreateAPIKey выпускает ключ и возвращает его в открытом виде — больше его нигде получить нельзя
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, error)
+}
+
+// FeedUsecases интерфейс use cases для подписки на календарь по секретной ссылке
+type FeedUsecases interface {
+	// RotateFeedToken выпускает токен ленты пользователя, отзывая прежний; токен в открытом виде возвращается только здесь
+	RotateFeedToken(ctx context.Context, userID int64) (domain.FeedToken, string, error)
+	RevokeFeedToken(ctx context.Context, userID int64) error
+	// AuthenticateFeedToken domain.ErrFeedNotFound, если токен неизвестен или отозван
+	AuthenticateFeedToken(ctx context.Context, token string) (domain.FeedToken, error)
+	// FeedModified хеш содержимого ленты токена с окном от from и время её последнего изменения.
+	// Хеш считается по признаку изменения событий, без их выборки; в БД пишется, только если он поменялся.
+	FeedModified(ctx context.Context, token domain.FeedToken, from time.Time) (string, time.Time, error)
+}
+
+// IdempotencyUsecases интерфейс use cases для повторов запросов с Idempotency-Key
+type IdempotencyUsecases interface {
+	// Begin начинает запрос с ключом. Возвращает сохранённый ответ, если такой запрос уже выполнен,
+	// или nil — тогда запрос нужно выполнить и вызвать Complete или Release.
+	// domain.ErrIdempotencyKeyReused — ключ использован для другого запроса,
+	// domain.ErrIdempotencyInProgress — запрос с ключом ещё выполняется.
+	Begin(ctx context.Context, userID int64, key, requestHash string) (*domain.IdempotencyRecord, error)
+	// Complete сохраняет ответ для повторов
+	Complete(ctx context.Context, record domain.IdempotencyRecord) error
+	// Release снимает резерв ключа, если ответ сохранять не нужно, например при внутренней ошибке
+	Release(ctx context.Context, userID int64, key string) error
 }
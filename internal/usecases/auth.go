@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.AuthUsecases = (*UsecaseAuth)(nil)
+)
+
+// apiKeyPrefix отличает API-ключ от JWT в заголовке Authorization
+const apiKeyPrefix = "cal_"
+
+var errInvalidAPIKey = domain.NewUnauthorizedError("invalid_api_key", "invalid api key")
+
+type UsecaseAuth struct {
+	repo port.APIKeyRepository
+}
+
+func NewUsecaseAuth(repo port.APIKeyRepository) *UsecaseAuth {
+	return &UsecaseAuth{repo: repo}
+}
+
+func (u *UsecaseAuth) AuthenticateAPIKey(ctx context.Context, key string) (domain.Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return domain.Principal{}, errInvalidAPIKey
+	}
+	stored, err := u.repo.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return domain.Principal{}, errInvalidAPIKey
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	return domain.Principal{UserId: stored.UserId, Admin: stored.Admin}, nil
+}
+
+func (u *UsecaseAuth) CreateAPIKey(ctx context.Context, key *domain.APIKey) (string, error) {
+	if key.UserId <= 0 {
+		return "", errInvalidUserID
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = HashAPIKey(plain)
+	if err := u.repo.CreateAPIKey(ctx, key); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// HashAPIKey хеш ключа для хранения. Ключ случайный и длинный, поэтому соль и медленный хеш не нужны.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
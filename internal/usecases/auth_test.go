@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestUsecaseAuth_CreateAndAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseAuth(repo)
+
+	key := domain.APIKey{UserId: 7, Name: "cli"}
+	plain, err := uc.CreateAPIKey(ctx, &key)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(plain, apiKeyPrefix) || key.KeyId == 0 {
+		t.Fatalf("unexpected key %q (id %d)", plain, key.KeyId)
+	}
+	if key.Hash == plain || strings.Contains(key.Hash, plain) {
+		t.Fatal("plain key must not be stored")
+	}
+
+	principal, err := uc.AuthenticateAPIKey(ctx, plain)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey: %v", err)
+	}
+	if principal.UserId != 7 || principal.Admin {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	for _, bad := range []string{"", "cal_unknown", "not-a-key"} {
+		if _, err := uc.AuthenticateAPIKey(ctx, bad); !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("%q: expected ErrUnauthorized, got %v", bad, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/dontpanicw/calendar/log_worker"
 	"github.com/dontpanicw/calendar/notify_worker"
+	"slices"
 	"time"
 
 	"github.com/dontpanicw/calendar/internal/domain"
@@ -15,6 +16,17 @@ var (
 	_ port.EventUsecases = (*UsecaseEvent)(nil)
 )
 
+var errInvalidUserID = domain.NewValidationError("invalid_user_id", "invalid user id")
+
+const (
+	// maxReminders максимальное число напоминаний у одного события
+	maxReminders = 10
+	// maxRangeDays ограничивает интервал выборки: серии разворачиваются в памяти
+	maxRangeDays = 366
+	// maxPageSize наибольшая страница ListEvents
+	maxPageSize = 1000
+)
+
 type UsecaseEvent struct {
 	repo         port.EventRepository
 	logger       *log_worker.Logger
@@ -30,50 +42,493 @@ func NewUsecaseEvent(repo port.EventRepository, logger *log_worker.Logger, notif
 
 func (u *UsecaseEvent) CreateEvent(ctx context.Context, event *domain.Event) error {
 	if event.UserId <= 0 {
-		return errors.New("invalid user id")
+		return errInvalidUserID
 	}
 	if event.Description == "" {
-		return errors.New("event description is required")
+		return domain.NewValidationError("description_required", "event description is required")
+	}
+	if err := normalizeEventTimes(event); err != nil {
+		return err
+	}
+	if event.ReminderOffsets == nil && event.ReminderTimes == nil {
+		event.ReminderOffsets = []domain.Offset{domain.DefaultReminderOffset}
+	}
+	if err := validateReminders(*event); err != nil {
+		return err
 	}
 	err := u.repo.CreateEvent(ctx, event)
 	if err != nil {
 		return err
 	}
-	u.notifyWorker.SendNotify(event)
+	u.scheduleReminders(ctx, event)
 	return nil
 }
 
-func (u *UsecaseEvent) UpdateEvent(ctx context.Context, event domain.Event) error {
+// UpdateEvent обновляет событие. Для серии scope задаёт, какие повторения меняются;
+// для this и following повторение указывается в event.OriginalStart.
+// Если event.Version не 0, событие меняется, только пока его версия совпадает с ней.
+func (u *UsecaseEvent) UpdateEvent(ctx context.Context, event domain.Event, scope domain.EditScope) error {
 	if event.EventId <= 0 || event.UserId <= 0 {
-		return errors.New("invalid event or user id")
+		return domain.NewValidationError("invalid_id", "invalid event or user id")
+	}
+	// Запрос заменяет событие целиком, поэтому пустое описание — ошибка, а не «не менять»
+	if event.Description == "" {
+		return domain.NewValidationError("description_required", "event description is required")
+	}
+	if err := normalizeEventTimes(&event); err != nil {
+		return err
+	}
+	if err := validateReminders(event); err != nil {
+		return err
+	}
+
+	// Событие ищется только среди событий пользователя, поэтому чужое не изменить и не переписать его владельца
+	current, err := u.repo.GetEvent(ctx, event.UserId, event.EventId)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(current, event.Version); err != nil {
+		return err
+	}
+	if scope == domain.ScopeThis || scope == domain.ScopeFollowing {
+		if err := checkOccurrence(current, event.OriginalStart); err != nil {
+			return err
+		}
 	}
-	return u.repo.UpdateEvent(ctx, event)
+
+	switch {
+	case scope == domain.ScopeThis:
+		current.Override(domain.OccurrenceOverride{
+			OriginalStart: event.OriginalStart,
+			Date:          event.Date,
+			End:           event.End,
+			Description:   event.Description,
+		})
+		return u.saveSeries(ctx, &current)
+
+	case scope == domain.ScopeFollowing && !event.OriginalStart.Equal(current.Date):
+		// Старая серия заканчивается перед повторением, с него начинается новая
+		rest := current.SplitAt(event.OriginalStart)
+		if err := u.saveSeries(ctx, &current); err != nil {
+			return err
+		}
+		if event.Recurrence == nil {
+			event.Recurrence = rest
+		}
+		event.EventId = 0
+		event.OriginalStart = time.Time{}
+		if err := u.repo.CreateEvent(ctx, &event); err != nil {
+			return err
+		}
+		u.scheduleReminders(ctx, &event)
+		return nil
+	}
+
+	// Вся серия: отменённые и изменённые повторения сохраняются
+	if event.Recurrence != nil {
+		event.ExDates, event.Overrides = current.ExDates, current.Overrides
+	}
+	event.OriginalStart = time.Time{}
+	// Архивирует событие cleaning_worker, клиент этот флаг не задаёт
+	event.IsArchived = current.IsArchived
+	// Сохранение проверяет прочитанную версию, поэтому изменение между чтением и записью не потеряется
+	event.Version = current.Version
+	return u.saveSeries(ctx, &event)
 }
 
-func (u *UsecaseEvent) DeleteEvent(ctx context.Context, eventId int64) error {
+// PatchEvent меняет только заданные в патче поля события и возвращает событие после изменения.
+// Патч применяется ко всей серии. version — ожидаемая версия события, 0 — без проверки.
+func (u *UsecaseEvent) PatchEvent(ctx context.Context, userID, eventId, version int64, patch domain.EventPatch) (domain.Event, error) {
+	if userID <= 0 {
+		return domain.Event{}, errInvalidUserID
+	}
 	if eventId <= 0 {
-		return errors.New("invalid event id")
+		return domain.Event{}, domain.NewValidationError("invalid_event_id", "invalid event id")
+	}
+	current, err := u.repo.GetEvent(ctx, userID, eventId)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if err := checkVersion(current, version); err != nil {
+		return domain.Event{}, err
 	}
-	return u.repo.DeleteEvent(ctx, eventId)
+
+	event := patch.Apply(current)
+	if event.Description == "" {
+		return domain.Event{}, domain.NewValidationError("description_required", "event description is required")
+	}
+	if err := normalizeEventTimes(&event); err != nil {
+		return domain.Event{}, err
+	}
+	if err := validateReminders(event); err != nil {
+		return domain.Event{}, err
+	}
+	if err := u.saveSeries(ctx, &event); err != nil {
+		return domain.Event{}, err
+	}
+	return event, nil
 }
 
-func (u *UsecaseEvent) GetEventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+// DeleteEvent удаляет событие. Для серии scope this отменяет одно повторение occurrence,
+// following — повторение и все следующие. version — ожидаемая версия события, 0 — без проверки.
+func (u *UsecaseEvent) DeleteEvent(ctx context.Context, userID, eventId, version int64, scope domain.EditScope, occurrence time.Time) error {
 	if userID <= 0 {
-		return nil, errors.New("invalid user id")
+		return errInvalidUserID
+	}
+	if eventId <= 0 {
+		return domain.NewValidationError("invalid_event_id", "invalid event id")
+	}
+	if scope == domain.ScopeThis || scope == domain.ScopeFollowing {
+		current, err := u.repo.GetEvent(ctx, userID, eventId)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, version); err != nil {
+			return err
+		}
+		if err := checkOccurrence(current, occurrence); err != nil {
+			return err
+		}
+		if scope == domain.ScopeThis {
+			current.Exclude(occurrence)
+			return u.saveSeries(ctx, &current)
+		}
+		if !occurrence.Equal(current.Date) {
+			current.SplitAt(occurrence)
+			return u.saveSeries(ctx, &current)
+		}
+	}
+
+	if err := u.repo.DeleteEvent(ctx, userID, eventId, version); err != nil {
+		return err
+	}
+	if err := u.notifyWorker.CancelNotify(ctx, eventId); err != nil {
+		u.logger.Writef("failed to cancel reminders for event %d: %v", eventId, err)
+	}
+	return nil
+}
+
+func (u *UsecaseEvent) GetEvent(ctx context.Context, userID, eventId int64) (domain.Event, error) {
+	if userID <= 0 {
+		return domain.Event{}, errInvalidUserID
+	}
+	if eventId <= 0 {
+		return domain.Event{}, domain.NewValidationError("invalid_event_id", "invalid event id")
+	}
+	return u.repo.GetEvent(ctx, userID, eventId)
+}
+
+func (u *UsecaseEvent) ArchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error) {
+	return u.setArchived(ctx, userID, eventId, version, true)
+}
+
+func (u *UsecaseEvent) UnarchiveEvent(ctx context.Context, userID, eventId, version int64) (domain.Event, error) {
+	return u.setArchived(ctx, userID, eventId, version, false)
+}
+
+// setArchived архивное событие скрыто из выборок и не напоминает о себе; из архива оно возвращается с напоминаниями
+func (u *UsecaseEvent) setArchived(ctx context.Context, userID, eventId, version int64, archived bool) (domain.Event, error) {
+	if userID <= 0 {
+		return domain.Event{}, errInvalidUserID
+	}
+	if eventId <= 0 {
+		return domain.Event{}, domain.NewValidationError("invalid_event_id", "invalid event id")
+	}
+	event, err := u.repo.GetEvent(ctx, userID, eventId)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if err := checkVersion(event, version); err != nil {
+		return domain.Event{}, err
+	}
+	if event.IsArchived == archived {
+		return event, nil
+	}
+
+	event.IsArchived = archived
+	if !archived {
+		if err := u.saveSeries(ctx, &event); err != nil {
+			return domain.Event{}, err
+		}
+		return event, nil
+	}
+	if err := u.repo.UpdateEvent(ctx, event); err != nil {
+		return domain.Event{}, err
+	}
+	event.Version++
+	if err := u.notifyWorker.CancelNotify(ctx, eventId); err != nil {
+		u.logger.Writef("failed to cancel reminders for event %d: %v", eventId, err)
+	}
+	return event, nil
+}
+
+// ImportEvent создаёт событие через CreateEvent или заменяет импортированное ранее с тем же UID.
+// Архив и канал доставки, которых в файле нет, у существующего события сохраняются.
+func (u *UsecaseEvent) ImportEvent(ctx context.Context, event *domain.Event) (domain.ImportStatus, error) {
+	if event.UserId <= 0 {
+		return domain.ImportFailed, errInvalidUserID
+	}
+	if event.ExternalUID == "" {
+		return domain.ImportFailed, domain.NewValidationError("uid_required", "imported event must have UID")
+	}
+	current, err := u.repo.GetEventByExternalUID(ctx, event.UserId, event.ExternalUID)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		// Напоминания импортируются как есть: событие без VALARM не получает напоминание по умолчанию
+		if event.ReminderOffsets == nil {
+			event.ReminderOffsets = []domain.Offset{}
+		}
+		if err := u.CreateEvent(ctx, event); err != nil {
+			return domain.ImportFailed, err
+		}
+		return domain.ImportCreated, nil
+	}
+	if err != nil {
+		return domain.ImportFailed, err
+	}
+	changed, err := u.replaceEvent(ctx, current, event)
+	if err != nil {
+		return domain.ImportFailed, err
+	}
+	if !changed {
+		return domain.ImportSkipped, nil
+	}
+	return domain.ImportUpdated, nil
+}
+
+func (u *UsecaseEvent) ReplaceEvent(ctx context.Context, event *domain.Event) error {
+	if event.EventId <= 0 || event.UserId <= 0 {
+		return domain.NewValidationError("invalid_id", "invalid event or user id")
+	}
+	current, err := u.repo.GetEvent(ctx, event.UserId, event.EventId)
+	if err != nil {
+		return err
 	}
-	return u.repo.GetEventsForDay(ctx, userID, date)
+	_, err = u.replaceEvent(ctx, current, event)
+	return err
 }
 
-func (u *UsecaseEvent) GetEventsForWeek(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+// replaceEvent заменяет current событием из внешнего календаря целиком, вместе с исключениями серии.
+// event.Version — ожидаемая версия, 0 — без проверки. false — событие не изменилось и не сохранялось.
+func (u *UsecaseEvent) replaceEvent(ctx context.Context, current domain.Event, event *domain.Event) (bool, error) {
+	if err := checkVersion(current, event.Version); err != nil {
+		return false, err
+	}
+	if event.Description == "" {
+		return false, domain.NewValidationError("description_required", "event description is required")
+	}
+	if err := normalizeEventTimes(event); err != nil {
+		return false, err
+	}
+	if err := validateReminders(*event); err != nil {
+		return false, err
+	}
+	event.EventId = current.EventId
+	event.ExternalUID = current.ExternalUID
+	event.IsArchived = current.IsArchived
+	if event.Recurrence == nil {
+		event.ExDates, event.Overrides = nil, nil
+	}
+	if event.NotifyChannel == "" {
+		event.NotifyChannel, event.NotifyTarget = current.NotifyChannel, current.NotifyTarget
+	}
+	if sameImportedEvent(current, *event) {
+		*event = current
+		return false, nil
+	}
+	// Сохранение проверяет прочитанную версию, поэтому изменение между чтением и записью не потеряется
+	event.Version = current.Version
+	return true, u.saveSeries(ctx, event)
+}
+
+func (u *UsecaseEvent) CancelImportedEvent(ctx context.Context, userID int64, uid string) (domain.ImportStatus, int64, error) {
 	if userID <= 0 {
-		return nil, errors.New("invalid user id")
+		return domain.ImportFailed, 0, errInvalidUserID
+	}
+	if uid == "" {
+		return domain.ImportFailed, 0, domain.NewValidationError("uid_required", "imported event must have UID")
+	}
+	current, err := u.repo.GetEventByExternalUID(ctx, userID, uid)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return domain.ImportSkipped, 0, nil
+	}
+	if err != nil {
+		return domain.ImportFailed, 0, err
+	}
+	if err := u.DeleteEvent(ctx, userID, current.EventId, current.Version, domain.ScopeAll, time.Time{}); err != nil {
+		return domain.ImportFailed, current.EventId, err
 	}
-	return u.repo.GetEventsForWeek(ctx, userID, start)
+	return domain.ImportDeleted, current.EventId, nil
 }
 
-func (u *UsecaseEvent) GetEventsForMonth(ctx context.Context, userID int64, start time.Time) ([]domain.Event, error) {
+func (u *UsecaseEvent) GetEventByExternalUID(ctx context.Context, userID int64, uid string) (domain.Event, error) {
 	if userID <= 0 {
-		return nil, errors.New("invalid user id")
+		return domain.Event{}, errInvalidUserID
+	}
+	if uid == "" {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	return u.repo.GetEventByExternalUID(ctx, userID, uid)
+}
+
+// sameImportedEvent сравнивает поля, которые приходят из файла календаря
+func sameImportedEvent(a, b domain.Event) bool {
+	sameRule := (a.Recurrence == nil) == (b.Recurrence == nil) &&
+		(a.Recurrence == nil || a.Recurrence.String() == b.Recurrence.String())
+	return sameRule && a.Date.Equal(b.Date) && a.End.Equal(b.End) && a.AllDay == b.AllDay &&
+		a.Description == b.Description && a.TimeZone == b.TimeZone &&
+		a.NotifyChannel == b.NotifyChannel && a.NotifyTarget == b.NotifyTarget &&
+		slices.Equal(a.ReminderOffsets, b.ReminderOffsets) &&
+		slices.EqualFunc(a.ReminderTimes, b.ReminderTimes, time.Time.Equal) &&
+		slices.EqualFunc(a.ExDates, b.ExDates, time.Time.Equal) &&
+		slices.EqualFunc(a.Overrides, b.Overrides, func(x, y domain.OccurrenceOverride) bool {
+			return x.OriginalStart.Equal(y.OriginalStart) && x.Date.Equal(y.Date) && x.End.Equal(y.End) && x.Description == y.Description
+		})
+}
+
+// checkOccurrence проверяет, что occurrence — повторение серии event
+func checkOccurrence(event domain.Event, occurrence time.Time) error {
+	if event.Recurrence == nil {
+		return domain.NewValidationError("not_recurring", "scope this and following require a recurring event")
+	}
+	if !event.IsOccurrence(occurrence) {
+		return domain.ErrOccurrenceNotFound
+	}
+	return nil
+}
+
+// checkVersion сверяет версию события с ожидаемой клиентом; 0 — без проверки
+func checkVersion(event domain.Event, version int64) error {
+	if version != 0 && version != event.Version {
+		return domain.ErrVersionMismatch
+	}
+	return nil
+}
+
+// saveSeries сохраняет событие, если его версия не изменилась с чтения, и пересчитывает напоминания.
+// После сохранения event.Version — новая версия.
+func (u *UsecaseEvent) saveSeries(ctx context.Context, event *domain.Event) error {
+	if err := u.repo.UpdateEvent(ctx, *event); err != nil {
+		return err
+	}
+	event.Version++
+	u.scheduleReminders(ctx, event)
+	return nil
+}
+
+// normalizeEventTimes заполняет конец события по умолчанию и проверяет время и правило повторения
+func normalizeEventTimes(event *domain.Event) error {
+	if event.Date.IsZero() {
+		return domain.NewValidationError("start_required", "event start is required")
 	}
-	return u.repo.GetEventsForMonth(ctx, userID, start)
+	if event.AllDay {
+		// События на весь день хранятся плавающими датами; неполный последний день округляется вверх
+		if event.End.IsZero() {
+			event.Date = domain.FloatingDate(event.Date)
+			event.End = event.Date.AddDate(0, 0, 1)
+		} else {
+			event.Date, event.End = domain.FloatingRange(event.Date, event.End)
+		}
+	}
+	if event.End.IsZero() {
+		event.End = event.Date.Add(domain.DefaultEventDuration)
+	}
+	if !event.End.After(event.Date) {
+		return domain.NewValidationError("invalid_time_range", "event end must be after start")
+	}
+	if event.Recurrence != nil {
+		if err := event.Recurrence.Validate(); err != nil {
+			return domain.NewValidationError("invalid_rrule", "%v", err)
+		}
+	}
+	if _, err := domain.LoadLocation(event.TimeZone); err != nil {
+		return domain.NewValidationError("invalid_time_zone", "%v", err)
+	}
+	return nil
+}
+
+func validateReminders(event domain.Event) error {
+	if len(event.ReminderOffsets)+len(event.ReminderTimes) > maxReminders {
+		return domain.NewValidationError("too_many_reminders", "too many reminders, maximum is %d", maxReminders)
+	}
+	for _, o := range event.ReminderOffsets {
+		if o < 0 {
+			return domain.NewValidationError("invalid_reminder", "reminder offset must not be negative")
+		}
+	}
+	if !domain.IsValidChannel(event.NotifyChannel) {
+		return domain.NewValidationError("unknown_channel", "unknown notify channel")
+	}
+	return domain.ValidateNotifyTarget(event.NotifyChannel, event.NotifyTarget)
+}
+
+// scheduleReminders ставит напоминания события в очередь; ошибка не откатывает само событие
+func (u *UsecaseEvent) scheduleReminders(ctx context.Context, event *domain.Event) {
+	if err := u.notifyWorker.SendNotify(ctx, event); err != nil {
+		u.logger.Writef("failed to schedule reminders for event %d: %v", event.EventId, err)
+	}
+}
+
+// GetEventsForDay, GetEventsForWeek и GetEventsForMonth — выборки ListEvents за календарный период целиком
+func (u *UsecaseEvent) GetEventsForDay(ctx context.Context, userID int64, date time.Time, includeArchived bool) ([]domain.Event, error) {
+	from, to := domain.DayRange(date)
+	return u.eventsInRange(ctx, userID, from, to, includeArchived)
+}
+
+func (u *UsecaseEvent) GetEventsForWeek(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error) {
+	from, to := domain.WeekRange(start)
+	return u.eventsInRange(ctx, userID, from, to, includeArchived)
+}
+
+func (u *UsecaseEvent) GetEventsForMonth(ctx context.Context, userID int64, start time.Time, includeArchived bool) ([]domain.Event, error) {
+	from, to := domain.MonthRange(start)
+	return u.eventsInRange(ctx, userID, from, to, includeArchived)
+}
+
+func (u *UsecaseEvent) eventsInRange(ctx context.Context, userID int64, from, to time.Time, includeArchived bool) ([]domain.Event, error) {
+	page, err := u.ListEvents(ctx, domain.EventQuery{UserId: userID, From: from, To: to, IncludeArchived: includeArchived})
+	if err != nil {
+		return nil, err
+	}
+	return page.Events, nil
+}
+
+func (u *UsecaseEvent) ListEvents(ctx context.Context, query domain.EventQuery) (domain.EventPage, error) {
+	if err := validateQuery(&query); err != nil {
+		return domain.EventPage{}, err
+	}
+	if query.Limit < 0 || query.Limit > maxPageSize {
+		return domain.EventPage{}, domain.NewValidationError("invalid_limit", "limit must be between 1 and %d", maxPageSize)
+	}
+	if err := query.CheckCursor(); err != nil {
+		return domain.EventPage{}, err
+	}
+	return u.repo.ListEvents(ctx, query)
+}
+
+func (u *UsecaseEvent) ExportEvents(ctx context.Context, query domain.EventQuery, fn func(domain.Event) error) error {
+	if err := validateQuery(&query); err != nil {
+		return err
+	}
+	return u.repo.ExportEvents(ctx, query, fn)
+}
+
+// validateQuery проверяет пользователя, интервал и порядок выборки; пустой порядок — SortAsc
+func validateQuery(query *domain.EventQuery) error {
+	if query.UserId <= 0 && !query.AllUsers {
+		return errInvalidUserID
+	}
+	if !query.To.After(query.From) {
+		return domain.NewValidationError("invalid_range", "range end must be after start")
+	}
+	if query.To.After(query.From.AddDate(0, 0, maxRangeDays)) {
+		return domain.NewValidationError("range_too_long", "range must not be longer than %d days", maxRangeDays)
+	}
+	switch query.Sort {
+	case "":
+		query.Sort = domain.SortAsc
+	case domain.SortAsc, domain.SortDesc:
+	default:
+		return domain.NewValidationError("invalid_sort", "sort must be asc or desc")
+	}
+	return nil
 }
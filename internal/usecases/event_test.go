@@ -2,6 +2,8 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,7 +17,7 @@ func TestUsecaseEvent_CreateEvent(t *testing.T) {
 	ctx := context.Background()
 	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
 	uc := NewUsecaseEvent(repo, logger, notifyWorker)
 	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 
@@ -27,9 +29,10 @@ func TestUsecaseEvent_CreateEvent(t *testing.T) {
 
 func TestUsecaseEvent_CreateEvent_InvalidUserID(t *testing.T) {
 	ctx := context.Background()
+	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
-	uc := NewUsecaseEvent(cache.NewCacheMap(), logger, notifyWorker)
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
 	err := uc.CreateEvent(ctx, &domain.Event{UserId: 0, Date: time.Now(), Description: "X"})
 	if err == nil {
 		t.Fatal("expected error for invalid user id")
@@ -41,9 +44,10 @@ func TestUsecaseEvent_CreateEvent_InvalidUserID(t *testing.T) {
 
 func TestUsecaseEvent_CreateEvent_EmptyDescription(t *testing.T) {
 	ctx := context.Background()
+	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
-	uc := NewUsecaseEvent(cache.NewCacheMap(), logger, notifyWorker)
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
 	err := uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: time.Now(), Description: ""})
 	if err == nil {
 		t.Fatal("expected error for empty description")
@@ -54,13 +58,13 @@ func TestUsecaseEvent_GetEventsForDay(t *testing.T) {
 	ctx := context.Background()
 	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
 	uc := NewUsecaseEvent(repo, logger, notifyWorker)
 	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: date, Description: "A"})
 	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: date, Description: "B"})
 
-	events, err := uc.GetEventsForDay(ctx, 1, date)
+	events, err := uc.GetEventsForDay(ctx, 1, date, false)
 	if err != nil {
 		t.Fatalf("GetEventsForDay: %v", err)
 	}
@@ -71,10 +75,11 @@ func TestUsecaseEvent_GetEventsForDay(t *testing.T) {
 
 func TestUsecaseEvent_GetEventsForDay_InvalidUserID(t *testing.T) {
 	ctx := context.Background()
+	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
-	uc := NewUsecaseEvent(cache.NewCacheMap(), logger, notifyWorker)
-	_, err := uc.GetEventsForDay(ctx, 0, time.Now())
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+	_, err := uc.GetEventsForDay(ctx, 0, time.Now(), false)
 	if err == nil {
 		t.Fatal("expected error for invalid user id")
 	}
@@ -84,12 +89,12 @@ func TestUsecaseEvent_DeleteEvent(t *testing.T) {
 	ctx := context.Background()
 	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
 	uc := NewUsecaseEvent(repo, logger, notifyWorker)
 	event := &domain.Event{UserId: 1, Date: time.Now(), Description: "X"}
 	_ = uc.CreateEvent(ctx, event)
 
-	err := uc.DeleteEvent(ctx, event.EventId)
+	err := uc.DeleteEvent(ctx, event.UserId, event.EventId, 0, domain.ScopeAll, time.Time{})
 	if err != nil {
 		t.Fatalf("DeleteEvent: %v", err)
 	}
@@ -97,10 +102,11 @@ func TestUsecaseEvent_DeleteEvent(t *testing.T) {
 
 func TestUsecaseEvent_DeleteEvent_NotFound(t *testing.T) {
 	ctx := context.Background()
+	repo := cache.NewCacheMap()
 	logger := log_worker.NewLogger()
-	notifyWorker := notify_worker.NewNotifyWorker()
-	uc := NewUsecaseEvent(cache.NewCacheMap(), logger, notifyWorker)
-	err := uc.DeleteEvent(ctx, 999)
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+	err := uc.DeleteEvent(ctx, 1, 999, 0, domain.ScopeAll, time.Time{})
 	if err == nil {
 		t.Fatal("expected error for non-existent event")
 	}
@@ -108,3 +114,643 @@ func TestUsecaseEvent_DeleteEvent_NotFound(t *testing.T) {
 		t.Errorf("expected 'event not found', got %q", err.Error())
 	}
 }
+
+func TestUsecaseEvent_CreateEvent_TooManyReminders(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logger := log_worker.NewLogger()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+
+	offsets := make([]domain.Offset, maxReminders+1)
+	for i := range offsets {
+		offsets[i] = domain.Offset(time.Duration(i+1) * time.Minute)
+	}
+	err := uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: time.Now(), Description: "X", ReminderOffsets: offsets})
+	if err == nil {
+		t.Fatal("expected error for too many reminders")
+	}
+}
+
+func TestUsecaseEvent_UpdateEvent_ReschedulesReminders(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logger := log_worker.NewLogger()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+
+	start := time.Now().Add(48 * time.Hour)
+	event := &domain.Event{UserId: 1, Date: start, Description: "X", ReminderOffsets: []domain.Offset{domain.Offset(time.Hour)}}
+	_ = uc.CreateEvent(ctx, event)
+
+	event.ReminderOffsets = []domain.Offset{domain.Offset(time.Hour), domain.Offset(24 * time.Hour)}
+	if err := uc.UpdateEvent(ctx, *event, domain.ScopeAll); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+
+	pending, _ := repo.GetPendingReminders(ctx)
+	if len(pending) != 2 {
+		t.Errorf("expected 2 pending reminders after update, got %d", len(pending))
+	}
+}
+
+func TestUsecaseEvent_CreateEvent_DefaultReminder(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	event := &domain.Event{UserId: 1, Date: start, Description: "No reminder fields"}
+	if err := uc.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	pending, _ := repo.GetPendingReminders(ctx)
+	if len(pending) != 1 || !pending[0].RemindAt.Equal(start.Add(-time.Hour)) {
+		t.Fatalf("expected one reminder an hour before the event, got %+v", pending)
+	}
+
+	// Пустой список — событие без напоминаний
+	silent := &domain.Event{UserId: 1, Date: start, Description: "Silent", ReminderOffsets: []domain.Offset{}}
+	if err := uc.CreateEvent(ctx, silent); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if pending, _ := repo.GetPendingReminders(ctx); len(pending) != 1 {
+		t.Errorf("expected no reminders for empty list, got %d pending", len(pending))
+	}
+}
+
+func TestUsecaseEvent_CreateEvent_DefaultEnd(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logger := log_worker.NewLogger()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+
+	timed := &domain.Event{UserId: 1, Date: start, Description: "Call"}
+	if err := uc.CreateEvent(ctx, timed); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if !timed.End.Equal(start.Add(domain.DefaultEventDuration)) {
+		t.Errorf("expected default duration, got end %v", timed.End)
+	}
+
+	allDay := &domain.Event{UserId: 1, Date: start.Truncate(24 * time.Hour), AllDay: true, Description: "Holiday"}
+	if err := uc.CreateEvent(ctx, allDay); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if !allDay.End.Equal(allDay.Date.AddDate(0, 0, 1)) {
+		t.Errorf("expected all-day event to end next day, got %v", allDay.End)
+	}
+}
+
+func TestUsecaseEvent_CreateEvent_EndBeforeStart(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logger := log_worker.NewLogger()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+	start := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+
+	err := uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start, End: start, Description: "X"})
+	if err == nil || err.Error() != "event end must be after start" {
+		t.Errorf("expected 'event end must be after start', got %v", err)
+	}
+}
+
+func TestUsecaseEvent_CreateEvent_AllDayFloating(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logger := log_worker.NewLogger()
+	notifyWorker := notify_worker.NewNotifyWorker(repo, repo, repo, nil)
+	uc := NewUsecaseEvent(repo, logger, notifyWorker)
+	msk := time.FixedZone("MSK", 3*60*60)
+
+	event := &domain.Event{
+		UserId:      1,
+		Date:        time.Date(2024, 1, 15, 0, 0, 0, 0, msk),
+		End:         time.Date(2024, 1, 16, 12, 0, 0, 0, msk),
+		AllDay:      true,
+		Description: "Trip",
+	}
+	if err := uc.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if !event.Date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) || !event.End.Equal(time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected floating dates 2024-01-15..2024-01-17, got %v - %v", event.Date, event.End)
+	}
+}
+
+func newRecurringUsecase(t *testing.T) (*UsecaseEvent, *cache.CacheMap, *domain.Event) {
+	t.Helper()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY;COUNT=5")
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	event := &domain.Event{UserId: 1, Date: start, End: start.Add(time.Hour), Recurrence: rule, Description: "Standup"}
+	if err := uc.CreateEvent(context.Background(), event); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	return uc, repo, event
+}
+
+func TestUsecaseEvent_DeleteEvent_ThisOccurrence(t *testing.T) {
+	ctx := context.Background()
+	uc, _, event := newRecurringUsecase(t)
+	week := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	if err := uc.DeleteEvent(ctx, event.UserId, event.EventId, 0, domain.ScopeThis, event.Date.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	events, _ := uc.GetEventsForWeek(ctx, 1, week, false)
+	if len(events) != 4 {
+		t.Errorf("expected 4 occurrences left, got %d", len(events))
+	}
+
+	err := uc.DeleteEvent(ctx, event.UserId, event.EventId, 0, domain.ScopeThis, event.Date.Add(time.Minute))
+	if !errors.Is(err, domain.ErrOccurrenceNotFound) {
+		t.Errorf("expected ErrOccurrenceNotFound, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_UpdateEvent_ThisOccurrence(t *testing.T) {
+	ctx := context.Background()
+	uc, _, event := newRecurringUsecase(t)
+	original := event.Date.AddDate(0, 0, 2)
+
+	moved := *event
+	moved.Date = original.Add(3 * time.Hour)
+	moved.End = time.Time{}
+	moved.Description = "Late standup"
+	moved.OriginalStart = original
+	if err := uc.UpdateEvent(ctx, moved, domain.ScopeThis); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+
+	events, _ := uc.GetEventsForDay(ctx, 1, original, false)
+	if len(events) != 1 || !events[0].Date.Equal(moved.Date) || events[0].Description != "Late standup" {
+		t.Fatalf("expected moved occurrence, got %+v", events)
+	}
+	events, _ = uc.GetEventsForWeek(ctx, 1, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), false)
+	if len(events) != 5 {
+		t.Errorf("expected 5 occurrences, got %d", len(events))
+	}
+}
+
+func TestUsecaseEvent_UpdateEvent_ThisAndFollowing(t *testing.T) {
+	ctx := context.Background()
+	uc, repo, event := newRecurringUsecase(t)
+	split := event.Date.AddDate(0, 0, 3)
+
+	changed := *event
+	changed.Date = split.Add(-time.Hour)
+	changed.End = split
+	changed.Description = "Earlier standup"
+	changed.Recurrence = nil
+	changed.OriginalStart = split
+	if err := uc.UpdateEvent(ctx, changed, domain.ScopeFollowing); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+
+	series, _ := repo.GetEvent(ctx, event.UserId, event.EventId)
+	if series.Recurrence.Count != 3 {
+		t.Errorf("expected original series to keep 3 occurrences, got %d", series.Recurrence.Count)
+	}
+	events, _ := uc.GetEventsForWeek(ctx, 1, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), false)
+	if len(events) != 5 {
+		t.Fatalf("expected 5 occurrences across both series, got %d", len(events))
+	}
+	last := events[4]
+	if last.SeriesId == event.EventId || last.Description != "Earlier standup" || last.Date.Hour() != 9 {
+		t.Errorf("expected occurrence of the new series, got %+v", last)
+	}
+}
+
+func TestUsecaseEvent_ValidationErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	err := uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: time.Now()})
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) || !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if domainErr.Code != "description_required" {
+		t.Errorf("expected code description_required, got %q", domainErr.Code)
+	}
+
+	err = uc.DeleteEvent(ctx, 1, 999, 0, domain.ScopeAll, time.Time{})
+	if !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_OtherUsersEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	event := &domain.Event{UserId: 1, Date: time.Now().Add(time.Hour), Description: "Mine"}
+	_ = uc.CreateEvent(ctx, event)
+
+	stolen := *event
+	stolen.UserId = 2
+	stolen.Description = "Stolen"
+	if err := uc.UpdateEvent(ctx, stolen, domain.ScopeAll); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("UpdateEvent: expected ErrEventNotFound, got %v", err)
+	}
+	if err := uc.DeleteEvent(ctx, 2, event.EventId, 0, domain.ScopeAll, time.Time{}); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("DeleteEvent: expected ErrEventNotFound, got %v", err)
+	}
+
+	current, err := repo.GetEvent(ctx, 1, event.EventId)
+	if err != nil {
+		t.Fatalf("GetEvent: %v", err)
+	}
+	if current.UserId != 1 || current.Description != "Mine" {
+		t.Errorf("event of user 1 changed: %+v", current)
+	}
+}
+
+func TestUsecaseEvent_ListEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	daily, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start, Description: "Standup", Recurrence: daily})
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start.Add(time.Hour), Description: "Review"})
+	past := &domain.Event{UserId: 1, Date: start.Add(2 * time.Hour), Description: "Archived review"}
+	_ = uc.CreateEvent(ctx, past)
+	stored, _ := repo.GetEvent(ctx, 1, past.EventId)
+	stored.IsArchived = true
+	_ = repo.UpdateEvent(ctx, stored)
+
+	query := domain.EventQuery{UserId: 1, From: start, To: start.AddDate(0, 0, 3), Limit: 2}
+	var got []string
+	for {
+		page, err := uc.ListEvents(ctx, query)
+		if err != nil {
+			t.Fatalf("ListEvents: %v", err)
+		}
+		for _, e := range page.Events {
+			got = append(got, e.Date.Format("02 15:04")+" "+e.Description)
+		}
+		if page.Next == nil {
+			break
+		}
+		query.After = page.Next
+	}
+	want := []string{"15 10:00 Standup", "15 11:00 Review", "16 10:00 Standup", "17 10:00 Standup"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("pages: expected %v, got %v", want, got)
+	}
+
+	page, _ := uc.ListEvents(ctx, domain.EventQuery{UserId: 1, From: start, To: start.AddDate(0, 0, 1),
+		IncludeArchived: true, Text: "REVIEW", Sort: domain.SortDesc})
+	if len(page.Events) != 2 || page.Events[0].Description != "Archived review" || page.Next != nil {
+		t.Errorf("expected both reviews, latest first, got %+v", page.Events)
+	}
+
+	// День, неделя и месяц — ListEvents за период
+	events, err := uc.GetEventsForDay(ctx, 1, start, false)
+	if err != nil || len(events) != 2 {
+		t.Errorf("GetEventsForDay: expected 2 events without archived, got %d (%v)", len(events), err)
+	}
+	events, _ = uc.GetEventsForDay(ctx, 1, start, true)
+	if len(events) != 3 {
+		t.Errorf("GetEventsForDay: expected 3 events with archived, got %d", len(events))
+	}
+
+	// Выборка по всем пользователям
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 2, Date: start, Description: "Other user"})
+	page, err = uc.ListEvents(ctx, domain.EventQuery{AllUsers: true, From: start, To: start.Add(90 * time.Minute)})
+	if err != nil || len(page.Events) != 3 {
+		t.Errorf("AllUsers: expected 3 events, got %+v (%v)", page.Events, err)
+	}
+
+	for _, bad := range []domain.EventQuery{
+		{From: start, To: start.Add(time.Hour)},
+		{UserId: 1, From: start, To: start},
+		{UserId: 1, From: start, To: start.AddDate(2, 0, 0)},
+		{UserId: 1, From: start, To: start.Add(time.Hour), Sort: "random"},
+		{UserId: 1, From: start, To: start.Add(time.Hour), Limit: maxPageSize + 1},
+	} {
+		if _, err := uc.ListEvents(ctx, bad); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("%+v: expected validation error, got %v", bad, err)
+		}
+	}
+}
+
+func TestUsecaseEvent_ExportEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	daily, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start.AddDate(0, 0, -10), Description: "Standup", Recurrence: daily})
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start.Add(time.Hour), Description: "Review"})
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 1, Date: start.AddDate(0, 0, 5), Description: "Later"})
+	_ = uc.CreateEvent(ctx, &domain.Event{UserId: 2, Date: start, Description: "Other user"})
+
+	// Серия, начавшаяся до интервала, выгружается один раз, сама по себе
+	var got []string
+	err := uc.ExportEvents(ctx, domain.EventQuery{UserId: 1, From: start, To: start.AddDate(0, 0, 3)}, func(e domain.Event) error {
+		got = append(got, e.Description)
+		if e.Recurrence != nil && e.SeriesId != 0 {
+			t.Errorf("expected series itself, got occurrence %+v", e)
+		}
+		return nil
+	})
+	if err != nil || strings.Join(got, ", ") != "Standup, Review" {
+		t.Errorf("expected Standup, Review, got %v (%v)", got, err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = uc.ExportEvents(ctx, domain.EventQuery{AllUsers: true, From: start, To: start.AddDate(0, 0, 3)}, func(domain.Event) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected export to stop on first error, got %d calls (%v)", calls, err)
+	}
+
+	err = uc.ExportEvents(ctx, domain.EventQuery{UserId: 1, From: start, To: start}, func(domain.Event) error {
+		t.Error("unexpected event for invalid query")
+		return nil
+	})
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_PatchEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	event := &domain.Event{UserId: 1, Date: start, End: start.Add(30 * time.Minute), Description: "Original"}
+	_ = uc.CreateEvent(ctx, event)
+	stored, _ := repo.GetEvent(ctx, 1, event.EventId)
+	stored.IsArchived = true
+	_ = repo.UpdateEvent(ctx, stored)
+
+	patched, err := uc.PatchEvent(ctx, 1, event.EventId, 0, domain.EventPatch{
+		Description: domain.Patched[string]{Set: true, Value: "Renamed"},
+	})
+	if err != nil {
+		t.Fatalf("PatchEvent: %v", err)
+	}
+	if patched.Description != "Renamed" || !patched.Date.Equal(start) || !patched.End.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("expected only description changed, got %+v", patched)
+	}
+	if !patched.IsArchived {
+		t.Error("patch must not reset is_archived")
+	}
+
+	_, err = uc.PatchEvent(ctx, 1, event.EventId, 0, domain.EventPatch{Description: domain.Patched[string]{Set: true}})
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error for removed description, got %v", err)
+	}
+	if _, err := uc.PatchEvent(ctx, 2, event.EventId, 0, domain.EventPatch{}); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for another user, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_UpdateEvent_KeepsArchived(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	event := &domain.Event{UserId: 1, Date: time.Now().Add(-48 * time.Hour), Description: "Past"}
+	_ = uc.CreateEvent(ctx, event)
+	stored, _ := repo.GetEvent(ctx, 1, event.EventId)
+	stored.IsArchived = true
+	_ = repo.UpdateEvent(ctx, stored)
+
+	update := domain.Event{EventId: event.EventId, UserId: 1, Date: event.Date, Description: "Past, renamed"}
+	if err := uc.UpdateEvent(ctx, update, domain.ScopeAll); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	stored, _ = repo.GetEvent(ctx, 1, event.EventId)
+	if !stored.IsArchived {
+		t.Error("update must not reset is_archived")
+	}
+
+	update.Description = ""
+	if err := uc.UpdateEvent(ctx, update, domain.ScopeAll); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error for empty description, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_VersionMismatch(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	event := &domain.Event{UserId: 1, Date: time.Now().Add(time.Hour), Description: "Shared"}
+	_ = uc.CreateEvent(ctx, event)
+
+	// Два клиента прочитали версию 1, первый сохранил изменения
+	first := domain.Event{EventId: event.EventId, UserId: 1, Version: 1, Date: event.Date, Description: "First"}
+	if err := uc.UpdateEvent(ctx, first, domain.ScopeAll); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	second := first
+	second.Description = "Second"
+	if err := uc.UpdateEvent(ctx, second, domain.ScopeAll); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch, got %v", err)
+	}
+
+	patched, err := uc.PatchEvent(ctx, 1, event.EventId, 2, domain.EventPatch{
+		Description: domain.Patched[string]{Set: true, Value: "Patched"},
+	})
+	if err != nil {
+		t.Fatalf("PatchEvent: %v", err)
+	}
+	if patched.Version != 3 {
+		t.Errorf("expected version 3 after patch, got %d", patched.Version)
+	}
+	if err := uc.DeleteEvent(ctx, 1, event.EventId, 2, domain.ScopeAll, time.Time{}); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch on delete, got %v", err)
+	}
+	stored, _ := repo.GetEvent(ctx, 1, event.EventId)
+	if stored.Description != "Patched" {
+		t.Errorf("expected the first writer's changes to survive, got %q", stored.Description)
+	}
+}
+
+func TestUsecaseEvent_ArchiveEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Now().Add(24 * time.Hour)
+	event := &domain.Event{UserId: 1, Date: start, Description: "Trip", ReminderOffsets: []domain.Offset{domain.Offset(time.Hour)}}
+	_ = uc.CreateEvent(ctx, event)
+
+	archived, err := uc.ArchiveEvent(ctx, 1, event.EventId, 0)
+	if err != nil {
+		t.Fatalf("ArchiveEvent: %v", err)
+	}
+	if !archived.IsArchived || archived.Version != 2 {
+		t.Errorf("expected archived event with version 2, got %+v", archived)
+	}
+	if reminders, _ := repo.GetPendingReminders(ctx); len(reminders) != 0 {
+		t.Errorf("expected reminders cancelled, got %d", len(reminders))
+	}
+	if events, _ := uc.GetEventsForDay(ctx, 1, start, false); len(events) != 0 {
+		t.Errorf("expected archived event hidden, got %d events", len(events))
+	}
+
+	if _, err := uc.UnarchiveEvent(ctx, 1, event.EventId, 1); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch, got %v", err)
+	}
+	restored, err := uc.UnarchiveEvent(ctx, 1, event.EventId, 2)
+	if err != nil || restored.IsArchived {
+		t.Fatalf("UnarchiveEvent: %+v, %v", restored, err)
+	}
+	if reminders, _ := repo.GetPendingReminders(ctx); len(reminders) != 1 {
+		t.Errorf("expected reminders rescheduled, got %d", len(reminders))
+	}
+	if _, err := uc.ArchiveEvent(ctx, 2, event.EventId, 0); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for another user, got %v", err)
+	}
+}
+
+func TestUsecaseEvent_ImportEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	imported := func(description string) *domain.Event {
+		return &domain.Event{UserId: 1, Date: start, End: start.Add(time.Hour), Description: description, ExternalUID: "a@example.com"}
+	}
+
+	event := imported("Meeting")
+	if status, err := uc.ImportEvent(ctx, event); err != nil || status != domain.ImportCreated {
+		t.Fatalf("first import: %v, %v", status, err)
+	}
+	id := event.EventId
+	if _, err := uc.ArchiveEvent(ctx, 1, id, 0); err != nil {
+		t.Fatalf("ArchiveEvent: %v", err)
+	}
+
+	again := imported("Meeting")
+	if status, err := uc.ImportEvent(ctx, again); err != nil || status != domain.ImportSkipped || again.EventId != id {
+		t.Errorf("repeated import: %v, %v, id %d", status, err, again.EventId)
+	}
+
+	changed := imported("Meeting moved")
+	if status, err := uc.ImportEvent(ctx, changed); err != nil || status != domain.ImportUpdated || changed.EventId != id {
+		t.Fatalf("changed import: %v, %v, id %d", status, err, changed.EventId)
+	}
+	got, _ := uc.GetEvent(ctx, 1, id)
+	if got.Description != "Meeting moved" || !got.IsArchived || got.ExternalUID != "a@example.com" {
+		t.Errorf("unexpected event after import: %+v", got)
+	}
+	if byUID, err := uc.GetEventByExternalUID(ctx, 1, "a@example.com"); err != nil || byUID.EventId != id {
+		t.Errorf("GetEventByExternalUID: %+v, %v", byUID, err)
+	}
+	if _, err := uc.GetEventByExternalUID(ctx, 2, "b@example.com"); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for unknown UID, got %v", err)
+	}
+
+	// UID другого пользователя не совпадает
+	other := imported("Meeting")
+	other.UserId = 2
+	if status, _ := uc.ImportEvent(ctx, other); status != domain.ImportCreated || other.EventId == id {
+		t.Errorf("expected a new event for another user, got %v, id %d", status, other.EventId)
+	}
+
+	noUID := imported("Meeting")
+	noUID.ExternalUID = ""
+	if status, err := uc.ImportEvent(ctx, noUID); status != domain.ImportFailed || !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error without UID, got %v, %v", status, err)
+	}
+
+	// PUT не стирает UID импортированного события
+	current, _ := uc.GetEvent(ctx, 1, id)
+	current.ExternalUID = ""
+	if err := uc.UpdateEvent(ctx, current, domain.ScopeAll); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	if got, _ := uc.GetEvent(ctx, 1, id); got.ExternalUID != "a@example.com" {
+		t.Errorf("expected external UID kept, got %q", got.ExternalUID)
+	}
+}
+
+// TestUsecaseEvent_ReplaceEvent замена серии целиком: исключения, которых нет в новой версии, снимаются
+func TestUsecaseEvent_ReplaceEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	start := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	daily, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	series := &domain.Event{UserId: 1, Date: start, Description: "Standup", Recurrence: daily}
+	_ = uc.CreateEvent(ctx, series)
+	if err := uc.DeleteEvent(ctx, 1, series.EventId, 0, domain.ScopeThis, start.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	moved := domain.Event{EventId: series.EventId, UserId: 1, OriginalStart: start.AddDate(0, 0, 2),
+		Date: start.AddDate(0, 0, 2).Add(time.Hour), Description: "Standup"}
+	if err := uc.UpdateEvent(ctx, moved, domain.ScopeThis); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	current, _ := uc.GetEvent(ctx, 1, series.EventId)
+
+	replacement := &domain.Event{EventId: series.EventId, UserId: 1, Version: current.Version - 1, Date: start,
+		Description: "Standup", Recurrence: daily, ExDates: []time.Time{start.AddDate(0, 0, 3)}}
+	if err := uc.ReplaceEvent(ctx, replacement); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Fatalf("expected version mismatch, got %v", err)
+	}
+	replacement.Version = current.Version
+	if err := uc.ReplaceEvent(ctx, replacement); err != nil {
+		t.Fatalf("ReplaceEvent: %v", err)
+	}
+	got, _ := uc.GetEvent(ctx, 1, series.EventId)
+	if len(got.ExDates) != 1 || !got.ExDates[0].Equal(start.AddDate(0, 0, 3)) || len(got.Overrides) != 0 || got.Version != current.Version+1 {
+		t.Errorf("expected only the new exception, got exdates %v, overrides %v, version %d", got.ExDates, got.Overrides, got.Version)
+	}
+
+	// Импорт тоже проверяет ожидаемую версию
+	imported := &domain.Event{UserId: 1, Date: start, Description: "Imported", ExternalUID: "a@example.com"}
+	_, _ = uc.ImportEvent(ctx, imported)
+	stale := &domain.Event{UserId: 1, Version: imported.Version + 1, Date: start, Description: "Changed", ExternalUID: "a@example.com"}
+	if status, err := uc.ImportEvent(ctx, stale); status != domain.ImportFailed || !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("expected version mismatch on import, got %v, %v", status, err)
+	}
+}
+
+func TestUsecaseEvent_CancelImportedEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseEvent(repo, log_worker.NewLogger(), notify_worker.NewNotifyWorker(repo, repo, repo, nil))
+
+	event := &domain.Event{UserId: 1, Date: time.Now().Add(24 * time.Hour), Description: "Meeting", ExternalUID: "a@example.com"}
+	if _, err := uc.ImportEvent(ctx, event); err != nil {
+		t.Fatalf("ImportEvent: %v", err)
+	}
+
+	// Чужой UID не трогается
+	if status, id, err := uc.CancelImportedEvent(ctx, 2, "a@example.com"); status != domain.ImportSkipped || id != 0 || err != nil {
+		t.Errorf("expected skipped for another user, got %v, %d, %v", status, id, err)
+	}
+	status, id, err := uc.CancelImportedEvent(ctx, 1, "a@example.com")
+	if status != domain.ImportDeleted || id != event.EventId || err != nil {
+		t.Fatalf("expected deleted event %d, got %v, %d, %v", event.EventId, status, id, err)
+	}
+	if _, err := uc.GetEvent(ctx, 1, event.EventId); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected event deleted, got %v", err)
+	}
+	if status, _, err := uc.CancelImportedEvent(ctx, 1, "a@example.com"); status != domain.ImportSkipped || err != nil {
+		t.Errorf("expected skipped for repeated cancel, got %v, %v", status, err)
+	}
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.FeedUsecases = (*UsecaseFeed)(nil)
+)
+
+// feedTokenPrefix отличает токен ленты от API-ключа: как ключ для API токен не принимается,
+// поэтому ссылка на ленту даёт только чтение календаря
+const feedTokenPrefix = "feed_"
+
+type UsecaseFeed struct {
+	repo   port.FeedTokenRepository
+	events port.EventRepository
+	now    func() time.Time
+}
+
+func NewUsecaseFeed(repo port.FeedTokenRepository, events port.EventRepository) *UsecaseFeed {
+	return &UsecaseFeed{
+		repo:   repo,
+		events: events,
+		now:    time.Now,
+	}
+}
+
+func (u *UsecaseFeed) RotateFeedToken(ctx context.Context, userID int64) (domain.FeedToken, string, error) {
+	if userID <= 0 {
+		return domain.FeedToken{}, "", errInvalidUserID
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return domain.FeedToken{}, "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	plain := feedTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	token := domain.FeedToken{UserId: userID, Hash: HashAPIKey(plain)}
+	if err := u.repo.SaveFeedToken(ctx, &token); err != nil {
+		return domain.FeedToken{}, "", err
+	}
+	return token, plain, nil
+}
+
+func (u *UsecaseFeed) RevokeFeedToken(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return errInvalidUserID
+	}
+	return u.repo.DeleteFeedToken(ctx, userID)
+}
+
+func (u *UsecaseFeed) AuthenticateFeedToken(ctx context.Context, token string) (domain.FeedToken, error) {
+	if !strings.HasPrefix(token, feedTokenPrefix) {
+		return domain.FeedToken{}, domain.ErrFeedNotFound
+	}
+	return u.repo.GetFeedTokenByHash(ctx, HashAPIKey(token))
+}
+
+// FeedModified время в Last-Modified с точностью до секунды, как в заголовках HTTP,
+// иначе If-Modified-Since никогда не совпадёт
+func (u *UsecaseFeed) FeedModified(ctx context.Context, token domain.FeedToken, from time.Time) (string, time.Time, error) {
+	marker, err := u.events.EventsMarker(ctx, token.UserId)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d:%d:%d:%d", from.Unix(), marker.Count, marker.MaxEventId, marker.VersionSum))
+	contentHash := hex.EncodeToString(sum[:])
+	if contentHash == token.ContentHash {
+		// Опрос без изменений ничего не пишет
+		return contentHash, token.ModifiedAt, nil
+	}
+	modified, err := u.repo.TouchFeedContent(ctx, token.UserId, contentHash, u.now().Truncate(time.Second))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return contentHash, modified, nil
+}
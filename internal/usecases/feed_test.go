@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestUsecaseFeed_RotateAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	uc := NewUsecaseFeed(repo, repo)
+
+	token, plain, err := uc.RotateFeedToken(ctx, 1)
+	if err != nil {
+		t.Fatalf("RotateFeedToken: %v", err)
+	}
+	if !strings.HasPrefix(plain, feedTokenPrefix) || token.Hash != HashAPIKey(plain) || token.Hash == plain {
+		t.Fatalf("unexpected token %q with hash %q", plain, token.Hash)
+	}
+	if got, err := uc.AuthenticateFeedToken(ctx, plain); err != nil || got.UserId != 1 {
+		t.Fatalf("expected token of user 1, got %+v, %v", got, err)
+	}
+
+	_, rotated, err := uc.RotateFeedToken(ctx, 1)
+	if err != nil || rotated == plain {
+		t.Fatalf("expected new token, got %q, %v", rotated, err)
+	}
+	if _, err := uc.AuthenticateFeedToken(ctx, plain); !errors.Is(err, domain.ErrFeedNotFound) {
+		t.Errorf("rotated token must not work, got %v", err)
+	}
+	// API-ключ не открывает ленту, даже если хеш совпал бы
+	if _, err := uc.AuthenticateFeedToken(ctx, "cal_"+strings.TrimPrefix(rotated, feedTokenPrefix)); !errors.Is(err, domain.ErrFeedNotFound) {
+		t.Errorf("expected ErrFeedNotFound for api key, got %v", err)
+	}
+
+	if err := uc.RevokeFeedToken(ctx, 1); err != nil {
+		t.Fatalf("RevokeFeedToken: %v", err)
+	}
+	if _, err := uc.AuthenticateFeedToken(ctx, rotated); !errors.Is(err, domain.ErrFeedNotFound) {
+		t.Errorf("revoked token must not work, got %v", err)
+	}
+	if err := uc.RevokeFeedToken(ctx, 1); !errors.Is(err, domain.ErrFeedNotFound) {
+		t.Errorf("expected ErrFeedNotFound, got %v", err)
+	}
+	if _, _, err := uc.RotateFeedToken(ctx, 0); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+// touchCounter считает записи хеша ленты
+type touchCounter struct {
+	*cache.CacheMap
+	touched int
+}
+
+func (c *touchCounter) TouchFeedContent(ctx context.Context, userID int64, contentHash string, at time.Time) (time.Time, error) {
+	c.touched++
+	return c.CacheMap.TouchFeedContent(ctx, userID, contentHash, at)
+}
+
+func TestUsecaseFeed_Modified(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 10, 0, 0, 500, time.UTC)
+	repo := &touchCounter{CacheMap: cache.NewCacheMap()}
+	uc := NewUsecaseFeed(repo, repo)
+	uc.now = func() time.Time { return now }
+	_, plain, _ := uc.RotateFeedToken(ctx, 1)
+	from := now.Truncate(24 * time.Hour)
+	event := &domain.Event{UserId: 1, Date: now.Add(time.Hour), Description: "Planning"}
+	_ = repo.CreateEvent(ctx, event)
+
+	poll := func(from time.Time) (string, time.Time) {
+		t.Helper()
+		token, err := uc.AuthenticateFeedToken(ctx, plain)
+		if err != nil {
+			t.Fatalf("AuthenticateFeedToken: %v", err)
+		}
+		hash, modified, err := uc.FeedModified(ctx, token, from)
+		if err != nil {
+			t.Fatalf("FeedModified: %v", err)
+		}
+		return hash, modified
+	}
+
+	firstHash, first := poll(from)
+	if !first.Equal(now.Truncate(time.Second)) || repo.touched != 1 {
+		t.Fatalf("expected %v after one write, got %v after %d", now.Truncate(time.Second), first, repo.touched)
+	}
+	now = now.Add(time.Hour)
+	if hash, got := poll(from); hash != firstHash || !got.Equal(first) || repo.touched != 1 {
+		t.Errorf("unchanged feed must keep hash and time %v without writes, got %v after %d writes", first, got, repo.touched)
+	}
+
+	// Изменение события или сдвиг окна меняют хеш
+	event.Description = "Review"
+	_ = repo.UpdateEvent(ctx, *event)
+	hash, got := poll(from)
+	if hash == firstHash || !got.Equal(now.Truncate(time.Second)) {
+		t.Errorf("changed feed must be modified at %v, got %v", now.Truncate(time.Second), got)
+	}
+	dayHash, _ := poll(from.Add(24 * time.Hour))
+	if dayHash == hash {
+		t.Error("expected new hash for the next day window")
+	}
+	_ = repo.DeleteEvent(ctx, 1, event.EventId, 0)
+	if next, _ := poll(from.Add(24 * time.Hour)); next == dayHash {
+		t.Error("expected new hash after delete")
+	}
+}
@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.IdempotencyUsecases = (*UsecaseIdempotency)(nil)
+)
+
+const (
+	// DefaultIdempotencyTTL сколько хранится ответ на запрос с Idempotency-Key
+	DefaultIdempotencyTTL = 24 * time.Hour
+	maxIdempotencyKeyLen  = 255
+	// idempotencyLockTimeout после него незавершённый запрос считается брошенным (например, сервис перезапустился),
+	// и ключ можно занять снова
+	idempotencyLockTimeout = time.Minute
+)
+
+type UsecaseIdempotency struct {
+	repo port.IdempotencyRepository
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewUsecaseIdempotency(repo port.IdempotencyRepository, ttl time.Duration) *UsecaseIdempotency {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &UsecaseIdempotency{
+		repo: repo,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+func (u *UsecaseIdempotency) Begin(ctx context.Context, userID int64, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return nil, domain.NewValidationError("invalid_idempotency_key", "Idempotency-Key must be 1 to %d characters long", maxIdempotencyKeyLen)
+	}
+	now := u.now()
+	record := domain.IdempotencyRecord{
+		Key:         key,
+		UserId:      userID,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(u.ttl),
+	}
+
+	existing, created, err := u.repo.CreateIdempotencyRecord(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if !created && !existing.Completed() && existing.CreatedAt.Add(idempotencyLockTimeout).Before(now) {
+		if err := u.repo.DeleteIdempotencyRecord(ctx, userID, key); err != nil {
+			return nil, err
+		}
+		if existing, created, err = u.repo.CreateIdempotencyRecord(ctx, record); err != nil {
+			return nil, err
+		}
+	}
+	if created {
+		return nil, nil
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if !existing.Completed() {
+		return nil, domain.ErrIdempotencyInProgress
+	}
+	return &existing, nil
+}
+
+func (u *UsecaseIdempotency) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	return u.repo.SaveIdempotencyResponse(ctx, record)
+}
+
+func (u *UsecaseIdempotency) Release(ctx context.Context, userID int64, key string) error {
+	return u.repo.DeleteIdempotencyRecord(ctx, userID, key)
+}
@@ -0,0 +1,74 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestUsecaseIdempotency_Replay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	uc := NewUsecaseIdempotency(cache.NewCacheMap(), time.Hour)
+	uc.now = func() time.Time { return now }
+
+	stored, err := uc.Begin(ctx, 1, "key-1", "hash-a")
+	if err != nil || stored != nil {
+		t.Fatalf("first request must be executed, got %v, %v", stored, err)
+	}
+	// Пока первый запрос выполняется, повтор не выполняется второй раз
+	if _, err := uc.Begin(ctx, 1, "key-1", "hash-a"); !errors.Is(err, domain.ErrIdempotencyInProgress) {
+		t.Errorf("expected ErrIdempotencyInProgress, got %v", err)
+	}
+
+	response := domain.IdempotencyRecord{Key: "key-1", UserId: 1, StatusCode: 201, Body: []byte(`{"event_id":1}`)}
+	if err := uc.Complete(ctx, response); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	stored, err = uc.Begin(ctx, 1, "key-1", "hash-a")
+	if err != nil || stored == nil || stored.StatusCode != 201 || string(stored.Body) != `{"event_id":1}` {
+		t.Fatalf("expected stored response, got %+v, %v", stored, err)
+	}
+
+	if _, err := uc.Begin(ctx, 1, "key-1", "hash-b"); !errors.Is(err, domain.ErrIdempotencyKeyReused) || !errors.Is(err, domain.ErrUnprocessable) {
+		t.Errorf("expected ErrIdempotencyKeyReused, got %v", err)
+	}
+	// Ключи разных пользователей независимы
+	if stored, err := uc.Begin(ctx, 2, "key-1", "hash-b"); err != nil || stored != nil {
+		t.Errorf("another user's key must be free, got %v, %v", stored, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if stored, err := uc.Begin(ctx, 1, "key-1", "hash-b"); err != nil || stored != nil {
+		t.Errorf("expired key must be free, got %v, %v", stored, err)
+	}
+}
+
+func TestUsecaseIdempotency_ReleaseAndAbandoned(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	uc := NewUsecaseIdempotency(cache.NewCacheMap(), time.Hour)
+	uc.now = func() time.Time { return now }
+
+	_, _ = uc.Begin(ctx, 1, "key", "hash")
+	if err := uc.Release(ctx, 1, "key"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if stored, err := uc.Begin(ctx, 1, "key", "hash"); err != nil || stored != nil {
+		t.Fatalf("released key must be free, got %v, %v", stored, err)
+	}
+
+	// Запрос не завершился (например, сервис упал) — через idempotencyLockTimeout ключ снова доступен
+	now = now.Add(2 * idempotencyLockTimeout)
+	if stored, err := uc.Begin(ctx, 1, "key", "hash"); err != nil || stored != nil {
+		t.Errorf("abandoned key must be free, got %v, %v", stored, err)
+	}
+
+	if _, err := uc.Begin(ctx, 1, "", "hash"); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected validation error for empty key, got %v", err)
+	}
+}
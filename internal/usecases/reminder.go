@@ -0,0 +1,36 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+	"github.com/dontpanicw/calendar/notify_worker"
+)
+
+var (
+	_ port.ReminderUsecases = (*UsecaseReminder)(nil)
+)
+
+type UsecaseReminder struct {
+	repo         port.ReminderRepository
+	notifyWorker *notify_worker.NotifyWorker
+}
+
+func NewUsecaseReminder(repo port.ReminderRepository, notifyWorker *notify_worker.NotifyWorker) *UsecaseReminder {
+	return &UsecaseReminder{
+		repo:         repo,
+		notifyWorker: notifyWorker,
+	}
+}
+
+func (u *UsecaseReminder) ListDeadReminders(ctx context.Context) ([]domain.Reminder, error) {
+	return u.repo.GetDeadReminders(ctx)
+}
+
+func (u *UsecaseReminder) RedriveReminder(ctx context.Context, reminderId int64) error {
+	if reminderId <= 0 {
+		return domain.NewValidationError("invalid_reminder_id", "invalid reminder id")
+	}
+	return u.notifyWorker.Redrive(ctx, reminderId)
+}
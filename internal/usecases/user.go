@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+var (
+	_ port.UserUsecases = (*UsecaseUser)(nil)
+)
+
+type UsecaseUser struct {
+	repo port.UserSettingsRepository
+}
+
+func NewUsecaseUser(repo port.UserSettingsRepository) *UsecaseUser {
+	return &UsecaseUser{repo: repo}
+}
+
+func (u *UsecaseUser) GetSettings(ctx context.Context, userID int64) (domain.UserSettings, error) {
+	if userID <= 0 {
+		return domain.UserSettings{}, errInvalidUserID
+	}
+	return u.repo.GetUserSettings(ctx, userID)
+}
+
+func (u *UsecaseUser) UpdateSettings(ctx context.Context, settings domain.UserSettings) error {
+	if settings.UserId <= 0 {
+		return errInvalidUserID
+	}
+	if !domain.IsValidChannel(settings.NotifyChannel) {
+		return domain.NewValidationError("unknown_channel", "unknown notify channel")
+	}
+	if err := domain.ValidateNotifyTarget(settings.NotifyChannel, settings.NotifyTarget); err != nil {
+		return err
+	}
+	if _, err := domain.LoadLocation(settings.TimeZone); err != nil {
+		return domain.NewValidationError("invalid_time_zone", "%v", err)
+	}
+	return u.repo.SaveUserSettings(ctx, settings)
+}
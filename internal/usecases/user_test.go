@@ -0,0 +1,53 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+func TestUsecaseUser_UpdateSettings_TimeZone(t *testing.T) {
+	ctx := context.Background()
+	uc := NewUsecaseUser(cache.NewCacheMap())
+
+	if err := uc.UpdateSettings(ctx, domain.UserSettings{UserId: 1, TimeZone: "Nowhere/City"}); err == nil {
+		t.Fatal("expected error for unknown time zone")
+	}
+	if err := uc.UpdateSettings(ctx, domain.UserSettings{UserId: 1, TimeZone: "UTC"}); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	settings, _ := uc.GetSettings(ctx, 1)
+	if settings.TimeZone != "UTC" {
+		t.Errorf("expected time zone UTC, got %q", settings.TimeZone)
+	}
+}
+
+func TestUsecaseUser_UpdateSettings_WebhookTarget(t *testing.T) {
+	ctx := context.Background()
+	uc := NewUsecaseUser(cache.NewCacheMap())
+
+	for _, target := range []string{
+		"http://example.com/hook",
+		"https://127.0.0.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://10.1.2.3:8443/hook",
+		"https://[::1]/hook",
+		"https://localhost/hook",
+		"/hook",
+	} {
+		err := uc.UpdateSettings(ctx, domain.UserSettings{UserId: 1, NotifyChannel: domain.ChannelWebhook, NotifyTarget: target})
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("%s: expected validation error, got %v", target, err)
+		}
+	}
+	if err := uc.UpdateSettings(ctx, domain.UserSettings{UserId: 1, NotifyChannel: domain.ChannelWebhook, NotifyTarget: "https://example.com/hook"}); err != nil {
+		t.Errorf("UpdateSettings: %v", err)
+	}
+	// Адрес почты вебхуком не проверяется
+	if err := uc.UpdateSettings(ctx, domain.UserSettings{UserId: 1, NotifyChannel: domain.ChannelEmail, NotifyTarget: "me@localhost"}); err != nil {
+		t.Errorf("UpdateSettings: %v", err)
+	}
+}
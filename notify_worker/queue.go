@@ -0,0 +1,42 @@
+package notify_worker
+
+import (
+	"github.com/dontpanicw/calendar/internal/domain"
+)
+
+// reminder элемент очереди напоминаний
+type reminder struct {
+	rem   domain.Reminder
+	index int
+}
+
+// reminderQueue min-heap напоминаний по времени срабатывания (container/heap)
+type reminderQueue []*reminder
+
+func (q reminderQueue) Len() int { return len(q) }
+
+func (q reminderQueue) Less(i, j int) bool {
+	return q[i].rem.FireAt().Before(q[j].rem.FireAt())
+}
+
+func (q reminderQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *reminderQueue) Push(x any) {
+	r := x.(*reminder)
+	r.index = len(*q)
+	*q = append(*q, r)
+}
+
+func (q *reminderQueue) Pop() any {
+	old := *q
+	n := len(old)
+	r := old[n-1]
+	old[n-1] = nil
+	r.index = -1
+	*q = old[:n-1]
+	return r
+}
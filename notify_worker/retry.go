@@ -0,0 +1,32 @@
+package notify_worker
+
+import "time"
+
+// RetryPolicy политика повторной доставки напоминаний
+type RetryPolicy struct {
+	// MaxAttempts после стольких неудачных попыток напоминание уходит в dead-letter очередь
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    time.Hour,
+}
+
+// Backoff задержка перед следующей попыткой после attempts неудач:
+// экспонента BaseDelay*2^(attempts-1), ограниченная MaxDelay, со случайной половиной (equal jitter).
+// random возвращает число в [0, 1).
+func (p RetryPolicy) Backoff(attempts int, random func() float64) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(random()*float64(delay-half))
+}
@@ -0,0 +1,31 @@
+package notify_worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	zero := func() float64 { return 0 }
+	almostOne := func() float64 { return 0.999999 }
+
+	tests := []struct {
+		attempts int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{5, 5 * time.Second, 10 * time.Second},
+		{50, 5 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff(tt.attempts, zero); got != tt.min {
+			t.Errorf("Backoff(%d) with zero jitter = %v, want %v", tt.attempts, got, tt.min)
+		}
+		if got := p.Backoff(tt.attempts, almostOne); got < tt.min || got > tt.max {
+			t.Errorf("Backoff(%d) with max jitter = %v, want within [%v, %v]", tt.attempts, got, tt.min, tt.max)
+		}
+	}
+}
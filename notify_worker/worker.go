@@ -1,62 +1,451 @@
 package notify_worker
 
 import (
+	"container/heap"
 	"context"
-	"github.com/dontpanicw/calendar/internal/domain"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
 )
 
-//Фоновый воркер через канал:
-//при создании события с напоминанием — кладём задачу в канал, воркер должен следить за временем и слать напоминания
+//Фоновый воркер уведомлений:
+//при создании события с напоминаниями — сохраняем их в БД и ставим в очередь (min-heap по времени срабатывания),
+//воркер держит один таймер на ближайшее напоминание и отправляет его в нужный момент.
+//При старте неотправленные напоминания поднимаются из БД, поэтому переживают рестарт.
+//Доставка идёт через port.Notifier: канал берётся из события, иначе из настроек пользователя.
+//Неудачная доставка повторяется с экспоненциальной задержкой, после RetryPolicy.MaxAttempts попыток
+//напоминание уходит в dead-letter очередь (status = dead), откуда его можно вернуть через Redrive.
+//У серии в очереди только напоминания ближайшего повторения: когда они отправлены или ушли в dead-letter,
+//воркер читает серию и ставит напоминания следующего повторения.
+//Отправка идёт в пуле из deliveryWorkers горутин с таймаутом на попытку, чтобы медленный канал
+//не задерживал цикл таймера и остальные напоминания.
+
+const (
+	// deliveryWorkers сколько напоминаний доставляется одновременно
+	deliveryWorkers = 8
+	// deliveryTimeout ограничение одной попытки доставки
+	deliveryTimeout = 30 * time.Second
+)
 
 type NotifyWorker struct {
-	eventChan chan domain.Event
+	repo port.ReminderRepository
+	// events нужны, чтобы после повторения серии поставить напоминания следующего
+	events    port.EventRepository
+	settings  port.UserSettingsRepository
+	notifiers map[string]port.Notifier
+
+	// scheduleMu упорядочивает изменения напоминаний одновременно в БД и в памяти
+	scheduleMu sync.Mutex
+
+	mu      sync.Mutex
+	queue   reminderQueue
+	byID    map[int64]*reminder
+	byEvent map[int64][]*reminder
+	// inflight число напоминаний события, которые сейчас доставляются
+	inflight map[int64]int
+	// wake сигнализирует циклу, что ближайшее напоминание могло измениться
+	wake chan struct{}
+
+	clock  Clock
+	notify func(ctx context.Context, r domain.Reminder) error
+	retry  RetryPolicy
+	// random источник случайности для jitter, в [0, 1)
+	random func() float64
+
+	// slots ограничивает число одновременных доставок
+	slots      chan struct{}
+	deliveries sync.WaitGroup
 }
 
-func NewNotifyWorker() *NotifyWorker {
-	return &NotifyWorker{
-		eventChan: make(chan domain.Event, 100),
+// NewNotifyWorker notifiers — каналы доставки по именам domain.Channel*
+func NewNotifyWorker(repo port.ReminderRepository, events port.EventRepository, settings port.UserSettingsRepository,
+	notifiers map[string]port.Notifier) *NotifyWorker {
+	w := &NotifyWorker{
+		repo:      repo,
+		events:    events,
+		settings:  settings,
+		notifiers: notifiers,
+		byID:      make(map[int64]*reminder),
+		byEvent:   make(map[int64][]*reminder),
+		inflight:  make(map[int64]int),
+		wake:      make(chan struct{}, 1),
+		clock:     realClock{},
+		retry:     DefaultRetryPolicy,
+		random:    rand.Float64,
+		slots:     make(chan struct{}, deliveryWorkers),
 	}
+	w.notify = w.dispatch
+	return w
 }
 
 func (w *NotifyWorker) Start(ctx context.Context) {
+	if err := w.loadPending(ctx); err != nil {
+		log.Printf("failed to load pending reminders: %v", err)
+	}
+
+	timer := w.clock.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
+
 	for {
+		w.resetTimer(timer)
 		select {
-		case event := <-w.eventChan:
-			err := w.schedule(event)
-			if err != nil {
-				log.Printf("failed to schedule event: %v", err)
-			}
+		case <-w.wake:
+		case <-timer.C():
+			w.fireDue(ctx)
 		case <-ctx.Done():
+			w.deliveries.Wait()
 			log.Println("notify worker stopped")
 			return
 		}
 	}
 }
 
-func (w *NotifyWorker) SendNotify(event *domain.Event) {
+// SendNotify сохраняет и планирует напоминания для события, заменяя ранее запланированные
+func (w *NotifyWorker) SendNotify(ctx context.Context, event *domain.Event) error {
+	reminders := w.remindersFor(*event, w.clock.Now())
+
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	if err := w.repo.ReplacePendingReminders(ctx, event.EventId, reminders); err != nil {
+		return fmt.Errorf("failed to save reminders: %w", err)
+	}
+	w.replace(event.EventId, reminders)
+	return nil
+}
+
+// CancelNotify отменяет неотправленные напоминания события
+func (w *NotifyWorker) CancelNotify(ctx context.Context, eventId int64) error {
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	if err := w.repo.DeletePendingReminders(ctx, eventId); err != nil {
+		return fmt.Errorf("failed to delete reminders: %w", err)
+	}
+	w.replace(eventId, nil)
+	return nil
+}
+
+// Redrive возвращает напоминание из dead-letter очереди и ставит его на отправку сейчас
+func (w *NotifyWorker) Redrive(ctx context.Context, reminderId int64) error {
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	rem, err := w.repo.RedriveReminder(ctx, reminderId, w.clock.Now())
+	if err != nil {
+		return err
+	}
+	w.requeue(rem)
+	return nil
+}
+
+// remindersFor рассчитывает напоминания по смещениям и абсолютным временам события, начинающегося после after.
+// Напоминания, время которых уже прошло, не ставятся — иначе каждое обновление события слало бы их заново.
+// Для серии напоминания ставятся на ближайшее повторение, у которого они ещё впереди.
+func (w *NotifyWorker) remindersFor(event domain.Event, after time.Time) []domain.Reminder {
+	now := w.clock.Now()
+	// Повторение, начавшееся раньше now, напоминаний уже не получит
+	if after.Before(now) {
+		after = now
+	}
+	for {
+		occurrence, ok := event.NextOccurrence(after)
+		if !ok {
+			// Событие уже началось или серия закончилась — напоминать не о чем
+			return nil
+		}
+		var reminders []domain.Reminder
+		for _, at := range occurrence.RemindAt() {
+			if at.Before(now) {
+				continue
+			}
+			reminders = append(reminders, domain.Reminder{
+				EventId:     occurrence.EventId,
+				UserId:      occurrence.UserId,
+				RemindAt:    at,
+				Status:      domain.ReminderPending,
+				Channel:     occurrence.NotifyChannel,
+				Target:      occurrence.NotifyTarget,
+				EventDate:   occurrence.Date,
+				Description: occurrence.Description,
+			})
+		}
+		// Без смещений у следующих повторений напоминаний тоже не будет: абсолютные времена уже прошли
+		if len(reminders) > 0 || event.Recurrence == nil || len(event.ReminderOffsets) == 0 {
+			return reminders
+		}
+		after = occurrence.Date
+	}
+}
+
+// scheduleNext ставит напоминания следующего повторения серии после того, как последнее напоминание
+// повторения rem отправлено или ушло в dead-letter очередь
+func (w *NotifyWorker) scheduleNext(ctx context.Context, rem domain.Reminder) {
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	w.mu.Lock()
+	pending := len(w.byEvent[rem.EventId]) + w.inflight[rem.EventId]
+	w.mu.Unlock()
+	if pending > 0 {
+		// Остались напоминания этого повторения или событие уже перепланировали
+		return
+	}
+
+	event, err := w.events.GetEvent(ctx, rem.UserId, rem.EventId)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to load event %d for next reminders: %v", rem.EventId, err)
+		return
+	}
+	if event.Recurrence == nil || event.IsArchived {
+		return
+	}
+	reminders := w.remindersFor(event, rem.EventDate)
+	if len(reminders) == 0 {
+		return
+	}
+	if err := w.repo.ReplacePendingReminders(ctx, event.EventId, reminders); err != nil {
+		log.Printf("failed to save next reminders for event %d: %v", event.EventId, err)
+		return
+	}
+	w.replace(event.EventId, reminders)
+}
+
+// loadPending поднимает из БД неотправленные напоминания
+func (w *NotifyWorker) loadPending(ctx context.Context) error {
+	reminders, err := w.repo.GetPendingReminders(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := w.clock.Now()
+	var missed []domain.Reminder
+	w.mu.Lock()
+	for _, rem := range reminders {
+		if _, ok := w.byID[rem.ReminderId]; ok {
+			continue
+		}
+		if !rem.EventDate.After(now) {
+			// Пока сервис лежал, событие уже началось
+			if err := w.repo.MarkReminderFailed(ctx, rem.ReminderId, "event already started"); err != nil && !errors.Is(err, domain.ErrReminderNotFound) {
+				log.Printf("failed to mark reminder %d failed: %v", rem.ReminderId, err)
+			}
+			missed = append(missed, rem)
+			continue
+		}
+		w.pushLocked(rem)
+	}
+	w.mu.Unlock()
+
+	// Серия, повторение которой пропущено, продолжает напоминать со следующего
+	for _, rem := range missed {
+		w.scheduleNext(ctx, rem)
+	}
+	w.signal()
+	return nil
+}
+
+// replace заменяет напоминания события в очереди
+func (w *NotifyWorker) replace(eventId int64, reminders []domain.Reminder) {
+	w.mu.Lock()
+	for _, r := range w.byEvent[eventId] {
+		if r.index >= 0 {
+			heap.Remove(&w.queue, r.index)
+		}
+		delete(w.byID, r.rem.ReminderId)
+	}
+	delete(w.byEvent, eventId)
+	for _, rem := range reminders {
+		w.pushLocked(rem)
+	}
+	w.mu.Unlock()
+
+	w.signal()
+}
+
+// requeue ставит в очередь одно напоминание, заменяя его предыдущую версию
+func (w *NotifyWorker) requeue(rem domain.Reminder) {
+	w.mu.Lock()
+	if r, ok := w.byID[rem.ReminderId]; ok {
+		if r.index >= 0 {
+			heap.Remove(&w.queue, r.index)
+		}
+		w.removeLocked(r)
+	}
+	w.pushLocked(rem)
+	w.mu.Unlock()
+
+	w.signal()
+}
+
+func (w *NotifyWorker) pushLocked(rem domain.Reminder) {
+	r := &reminder{rem: rem}
+	heap.Push(&w.queue, r)
+	w.byID[rem.ReminderId] = r
+	w.byEvent[rem.EventId] = append(w.byEvent[rem.EventId], r)
+}
+
+func (w *NotifyWorker) removeLocked(r *reminder) {
+	delete(w.byID, r.rem.ReminderId)
+	siblings := w.byEvent[r.rem.EventId]
+	for i, s := range siblings {
+		if s == r {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(w.byEvent, r.rem.EventId)
+	} else {
+		w.byEvent[r.rem.EventId] = siblings
+	}
+}
+
+// signal будит цикл воркера без блокировки
+func (w *NotifyWorker) signal() {
 	select {
-	case w.eventChan <- *event:
+	case w.wake <- struct{}{}:
 	default:
-		log.Printf("Chan is full, not sending event")
 	}
+}
+
+// resetTimer переводит таймер на ближайшее напоминание или останавливает его, если очередь пуста
+func (w *NotifyWorker) resetTimer(timer Timer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
+	timer.Stop()
+	if w.queue.Len() == 0 {
+		return
+	}
+	timer.Reset(w.queue[0].rem.FireAt().Sub(w.clock.Now()))
 }
 
-func (w *NotifyWorker) schedule(event domain.Event) error {
-	//if time.Now().After(event.Date.Add(-60 * time.Minute)) {
-	//	//send notify
-	//	log.Printf("The event %d will start in %v minutes.", event.EventId, event.Date)
-	//	return nil
-	//}
-	//remindAt := event.Date.Add(-60 * time.Minute)
-	//delay := time.Until(remindAt)
-	//
-	//// Создаем таймер на одно уведомление
-	//time.AfterFunc(delay, func() {
-	//	// send notify
-	//	log.Printf("The event %d will start in %v minutes.", event.EventId, event.Date)
-	//})
+// fireDue передаёт в пул доставки все напоминания, время которых наступило.
+// Цикл ждёт только свободный слот пула, сама доставка идёт в отдельной горутине.
+func (w *NotifyWorker) fireDue(ctx context.Context) {
+	now := w.clock.Now()
 
-	return nil
+	var due []domain.Reminder
+	w.mu.Lock()
+	for w.queue.Len() > 0 && !w.queue[0].rem.FireAt().After(now) {
+		r := heap.Pop(&w.queue).(*reminder)
+		w.removeLocked(r)
+		w.inflight[r.rem.EventId]++
+		due = append(due, r.rem)
+	}
+	w.mu.Unlock()
+
+	for i, rem := range due {
+		select {
+		case w.slots <- struct{}{}:
+		case <-ctx.Done():
+			// Неотправленные напоминания остаются pending в БД и поднимутся при старте
+			w.mu.Lock()
+			for _, rem := range due[i:] {
+				w.doneLocked(rem.EventId)
+			}
+			w.mu.Unlock()
+			return
+		}
+		w.deliveries.Add(1)
+		go func() {
+			defer w.deliveries.Done()
+			defer func() { <-w.slots }()
+			w.deliver(ctx, rem)
+		}()
+	}
+}
+
+// deliver отправляет напоминание и фиксирует результат в БД
+func (w *NotifyWorker) deliver(ctx context.Context, rem domain.Reminder) {
+	attemptCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	err := w.notify(attemptCtx, rem)
+	cancel()
+	if err == nil {
+		// ErrReminderNotFound: пока шла отправка, событие обновили или удалили
+		if err := w.repo.MarkReminderSent(ctx, rem.ReminderId); err != nil && !errors.Is(err, domain.ErrReminderNotFound) {
+			log.Printf("failed to mark reminder %d sent: %v", rem.ReminderId, err)
+		}
+	} else {
+		log.Printf("failed to send reminder %d (attempt %d): %v", rem.ReminderId, rem.Attempts+1, err)
+		w.handleFailure(ctx, rem, err)
+	}
+
+	w.mu.Lock()
+	w.doneLocked(rem.EventId)
+	w.mu.Unlock()
+	// Повторная попытка остаётся в очереди, и scheduleNext ничего не ставит
+	w.scheduleNext(ctx, rem)
+}
+
+// doneLocked снимает отметку о доставке напоминания события
+func (w *NotifyWorker) doneLocked(eventId int64) {
+	if w.inflight[eventId]--; w.inflight[eventId] <= 0 {
+		delete(w.inflight, eventId)
+	}
+}
+
+// handleFailure планирует повторную попытку или переводит напоминание в dead-letter очередь
+func (w *NotifyWorker) handleFailure(ctx context.Context, rem domain.Reminder, deliveryErr error) {
+	w.scheduleMu.Lock()
+	defer w.scheduleMu.Unlock()
+
+	rem.Attempts++
+	rem.LastError = deliveryErr.Error()
+
+	if rem.Attempts >= w.retry.MaxAttempts {
+		if err := w.repo.MarkReminderDead(ctx, rem.ReminderId, rem.Attempts, rem.LastError); err != nil && !errors.Is(err, domain.ErrReminderNotFound) {
+			log.Printf("failed to mark reminder %d dead: %v", rem.ReminderId, err)
+		}
+		return
+	}
+
+	rem.NextAttemptAt = w.clock.Now().Add(w.retry.Backoff(rem.Attempts, w.random))
+	err := w.repo.MarkReminderRetry(ctx, rem.ReminderId, rem.Attempts, rem.NextAttemptAt, rem.LastError)
+	if errors.Is(err, domain.ErrReminderNotFound) {
+		// Пока шла отправка, событие обновили или удалили
+		return
+	}
+	if err != nil {
+		log.Printf("failed to schedule retry for reminder %d: %v", rem.ReminderId, err)
+		return
+	}
+	w.requeue(rem)
+}
+
+// dispatch выбирает канал доставки: настройки напоминания, затем настройки пользователя, затем log
+func (w *NotifyWorker) dispatch(ctx context.Context, rem domain.Reminder) error {
+	channel, target := rem.Channel, rem.Target
+	if channel == "" || target == "" {
+		settings, err := w.settings.GetUserSettings(ctx, rem.UserId)
+		if err != nil {
+			return err
+		}
+		if channel == "" {
+			channel = settings.NotifyChannel
+		}
+		if target == "" && channel == settings.NotifyChannel {
+			target = settings.NotifyTarget
+		}
+	}
+	if channel == "" {
+		channel = domain.ChannelLog
+	}
+
+	n, ok := w.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("notification channel %q is not configured", channel)
+	}
+	return n.Notify(ctx, target, rem)
 }
@@ -0,0 +1,569 @@
+package notify_worker
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dontpanicw/calendar/internal/adapter/repository/cache"
+	"github.com/dontpanicw/calendar/internal/domain"
+	"github.com/dontpanicw/calendar/internal/port"
+)
+
+// fakeClock ручные часы: время двигается только через Advance
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	t.arm(d)
+	return t
+}
+
+// Advance сдвигает время и срабатывает все наступившие таймеры
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		t.fireIfDue()
+	}
+}
+
+// waitArmed ждёт, пока какой-либо таймер будет взведён на момент at
+func (c *fakeClock) waitArmed(t *testing.T, at time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, timer := range c.timers {
+			if timer.active && timer.deadline.Equal(at) {
+				c.mu.Unlock()
+				return
+			}
+		}
+		c.mu.Unlock()
+		runtime.Gosched()
+	}
+	t.Fatalf("no timer armed at %v", at)
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	ch       chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.arm(d)
+	return wasActive
+}
+
+// arm вызывается под clock.mu
+func (t *fakeTimer) arm(d time.Duration) {
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	t.fireIfDue()
+}
+
+// fireIfDue вызывается под clock.mu
+func (t *fakeTimer) fireIfDue() {
+	if !t.active || t.deadline.After(t.clock.now) {
+		return
+	}
+	t.active = false
+	select {
+	case t.ch <- t.clock.now:
+	default:
+	}
+}
+
+var hourBefore = []domain.Offset{domain.Offset(time.Hour)}
+
+func newTestWorker(now time.Time) (*NotifyWorker, *fakeClock, chan domain.Reminder) {
+	return newTestWorkerWithRepo(cache.NewCacheMap(), newFakeClock(now))
+}
+
+func newTestWorkerWithRepo(repo *cache.CacheMap, clock *fakeClock) (*NotifyWorker, *fakeClock, chan domain.Reminder) {
+	fired := make(chan domain.Reminder, 10)
+	w := NewNotifyWorker(repo, repo, repo, nil)
+	w.clock = clock
+	w.notify = func(ctx context.Context, r domain.Reminder) error {
+		fired <- r
+		return nil
+	}
+	return w, clock, fired
+}
+
+// fireDue запускает доставку и дожидается её, чтобы проверять результат синхронно
+func fireDue(w *NotifyWorker, ctx context.Context) {
+	w.fireDue(ctx)
+	w.deliveries.Wait()
+}
+
+func firedIDs(fired chan domain.Reminder) []int64 {
+	var ids []int64
+	for {
+		select {
+		case e := <-fired:
+			ids = append(ids, e.EventId)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestNotifyWorker_FiresInTimeOrder(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, Date: now.Add(5 * time.Hour), ReminderOffsets: hourBefore})
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 2, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore})
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 3, Date: now.Add(3 * time.Hour), ReminderOffsets: hourBefore})
+
+	clock.Advance(30 * time.Minute)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 0 {
+		t.Fatalf("expected nothing fired yet, got %v", ids)
+	}
+
+	// Наступившие одновременно напоминания доставляются параллельно, порядок внутри пачки не важен
+	clock.Advance(90 * time.Minute)
+	fireDue(w, ctx)
+	ids := firedIDs(fired)
+	slices.Sort(ids)
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Fatalf("expected events [2 3], got %v", ids)
+	}
+
+	clock.Advance(2 * time.Hour)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected event [1], got %v", ids)
+	}
+}
+
+func TestNotifyWorker_Reschedule(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	event := &domain.Event{EventId: 1, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore}
+	_ = w.SendNotify(ctx, event)
+	event.Date = now.Add(10 * time.Hour)
+	_ = w.SendNotify(ctx, event)
+
+	if w.queue.Len() != 1 {
+		t.Fatalf("expected 1 reminder in queue, got %d", w.queue.Len())
+	}
+
+	clock.Advance(2 * time.Hour)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 0 {
+		t.Fatalf("expected rescheduled reminder not to fire, got %v", ids)
+	}
+
+	clock.Advance(7 * time.Hour)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected event [1], got %v", ids)
+	}
+}
+
+func TestNotifyWorker_Cancel(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore})
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 2, Date: now.Add(3 * time.Hour), ReminderOffsets: hourBefore})
+	_ = w.CancelNotify(ctx, 1)
+
+	clock.Advance(3 * time.Hour)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 || ids[0] != 2 {
+		t.Fatalf("expected event [2], got %v", ids)
+	}
+}
+
+func TestNotifyWorker_PastReminders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, _, _ := newTestWorker(now)
+
+	// Событие уже прошло — напоминание не ставится
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, Date: now.Add(-time.Hour), ReminderOffsets: hourBefore})
+	// Время напоминания уже прошло — его не ставим, остаётся только будущее
+	offsets := []domain.Offset{domain.Offset(time.Hour), domain.Offset(10 * time.Minute)}
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 2, Date: now.Add(30 * time.Minute), ReminderOffsets: offsets})
+
+	if w.queue.Len() != 1 || !w.queue[0].rem.RemindAt.Equal(now.Add(20*time.Minute)) {
+		t.Fatalf("expected single reminder at +20m, got %d reminders", w.queue.Len())
+	}
+}
+
+func TestNotifyWorker_MultipleReminders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	start := now.Add(48 * time.Hour)
+	event := &domain.Event{
+		EventId:         1,
+		Date:            start,
+		ReminderOffsets: []domain.Offset{domain.Offset(24 * time.Hour), domain.Offset(10 * time.Minute)},
+		ReminderTimes:   []time.Time{now.Add(time.Hour)},
+	}
+	_ = w.SendNotify(ctx, event)
+	if w.queue.Len() != 3 {
+		t.Fatalf("expected 3 reminders, got %d", w.queue.Len())
+	}
+
+	clock.Advance(time.Hour)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 {
+		t.Fatalf("expected absolute reminder fired, got %v", ids)
+	}
+
+	// Обновление события пересчитывает оставшиеся напоминания
+	event.ReminderOffsets = []domain.Offset{domain.Offset(time.Hour)}
+	event.ReminderTimes = nil
+	_ = w.SendNotify(ctx, event)
+	if w.queue.Len() != 1 || !w.queue[0].rem.RemindAt.Equal(start.Add(-time.Hour)) {
+		t.Fatalf("expected single reminder an hour before start, got %d reminders", w.queue.Len())
+	}
+}
+
+func TestNotifyWorker_Start(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(done)
+	}()
+
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 7, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore})
+	clock.waitArmed(t, now.Add(time.Hour))
+	clock.Advance(time.Hour)
+
+	select {
+	case e := <-fired:
+		if e.EventId != 7 {
+			t.Errorf("expected event 7, got %d", e.EventId)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reminder was not fired")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestNotifyWorker_LoadPendingAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	repo := cache.NewCacheMap()
+	clock := newFakeClock(now)
+
+	first, _, _ := newTestWorkerWithRepo(repo, clock)
+	_ = first.SendNotify(ctx, &domain.Event{EventId: 1, UserId: 1, Date: now.Add(3 * time.Hour), ReminderOffsets: hourBefore})
+	_ = first.SendNotify(ctx, &domain.Event{EventId: 2, UserId: 1, Date: now.Add(50 * time.Minute), ReminderOffsets: []domain.Offset{domain.Offset(10 * time.Minute)}})
+
+	// Рестарт: новый воркер с тем же хранилищем, за это время событие 2 уже началось
+	clock.Advance(time.Hour)
+	second, _, fired := newTestWorkerWithRepo(repo, clock)
+	if err := second.loadPending(ctx); err != nil {
+		t.Fatalf("loadPending: %v", err)
+	}
+	if second.queue.Len() != 1 {
+		t.Fatalf("expected 1 reminder restored, got %d", second.queue.Len())
+	}
+
+	clock.Advance(time.Hour)
+	fireDue(second, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected event [1], got %v", ids)
+	}
+
+	pending, _ := repo.GetPendingReminders(ctx)
+	if len(pending) != 0 {
+		t.Errorf("expected no pending reminders, got %v", pending)
+	}
+}
+
+func TestNotifyWorker_RetryThenDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	repo := cache.NewCacheMap()
+	w, clock, _ := newTestWorkerWithRepo(repo, newFakeClock(now))
+	w.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	w.random = func() float64 { return 0 }
+	calls := 0
+	w.notify = func(ctx context.Context, r domain.Reminder) error {
+		calls++
+		return errors.New("smtp is down")
+	}
+
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, UserId: 1, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore})
+
+	clock.Advance(time.Hour)
+	fireDue(w, ctx)
+	pending, _ := repo.GetPendingReminders(ctx)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "smtp is down" {
+		t.Fatalf("expected reminder pending retry after first failure, got %+v", pending)
+	}
+	// Задержка с нулевым jitter — половина от BaseDelay
+	if want := now.Add(time.Hour + 30*time.Second); !pending[0].NextAttemptAt.Equal(want) {
+		t.Fatalf("expected next attempt at %v, got %v", want, pending[0].NextAttemptAt)
+	}
+
+	clock.Advance(30 * time.Second)
+	fireDue(w, ctx)
+	clock.Advance(time.Minute)
+	fireDue(w, ctx)
+
+	if calls != 3 {
+		t.Fatalf("expected 3 delivery attempts, got %d", calls)
+	}
+	dead, _ := repo.GetDeadReminders(ctx)
+	if len(dead) != 1 || dead[0].Attempts != 3 {
+		t.Fatalf("expected reminder in dead-letter queue after 3 attempts, got %+v", dead)
+	}
+	if w.queue.Len() != 0 {
+		t.Errorf("expected empty in-memory queue, got %d", w.queue.Len())
+	}
+}
+
+func TestNotifyWorker_Redrive(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	repo := cache.NewCacheMap()
+	w, clock, fired := newTestWorkerWithRepo(repo, newFakeClock(now))
+
+	reminders := []domain.Reminder{{UserId: 1, RemindAt: now}}
+	_ = repo.ReplacePendingReminders(ctx, 1, reminders)
+	_ = repo.MarkReminderDead(ctx, reminders[0].ReminderId, 5, "boom")
+
+	if err := w.Redrive(ctx, reminders[0].ReminderId); err != nil {
+		t.Fatalf("Redrive: %v", err)
+	}
+	if err := w.Redrive(ctx, reminders[0].ReminderId); !errors.Is(err, domain.ErrReminderNotFound) {
+		t.Errorf("expected ErrReminderNotFound for second redrive, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 1 {
+		t.Fatalf("expected redriven reminder to fire, got %v", ids)
+	}
+	dead, _ := repo.GetDeadReminders(ctx)
+	if len(dead) != 0 {
+		t.Errorf("expected empty dead-letter queue, got %v", dead)
+	}
+}
+
+type recordingNotifier struct {
+	targets []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, target string, r domain.Reminder) error {
+	n.targets = append(n.targets, target)
+	return nil
+}
+
+func TestNotifyWorker_DispatchChannel(t *testing.T) {
+	ctx := context.Background()
+	repo := cache.NewCacheMap()
+	logN, webhookN, emailN := &recordingNotifier{}, &recordingNotifier{}, &recordingNotifier{}
+	w := NewNotifyWorker(repo, repo, repo, map[string]port.Notifier{
+		domain.ChannelLog:     logN,
+		domain.ChannelWebhook: webhookN,
+		domain.ChannelEmail:   emailN,
+	})
+	_ = repo.SaveUserSettings(ctx, domain.UserSettings{UserId: 2, NotifyChannel: domain.ChannelEmail, NotifyTarget: "user@example.com"})
+
+	// Без настроек — канал log
+	_ = w.dispatch(ctx, domain.Reminder{UserId: 1})
+	// Настройки пользователя
+	_ = w.dispatch(ctx, domain.Reminder{UserId: 2})
+	// Настройки напоминания важнее настроек пользователя
+	_ = w.dispatch(ctx, domain.Reminder{UserId: 2, Channel: domain.ChannelWebhook, Target: "http://hook"})
+
+	if len(logN.targets) != 1 {
+		t.Errorf("expected 1 log notification, got %v", logN.targets)
+	}
+	if len(emailN.targets) != 1 || emailN.targets[0] != "user@example.com" {
+		t.Errorf("expected email to user@example.com, got %v", emailN.targets)
+	}
+	if len(webhookN.targets) != 1 || webhookN.targets[0] != "http://hook" {
+		t.Errorf("expected webhook to http://hook, got %v", webhookN.targets)
+	}
+
+	if err := NewNotifyWorker(repo, repo, repo, nil).dispatch(ctx, domain.Reminder{UserId: 1}); err == nil {
+		t.Error("expected error for unconfigured channel")
+	}
+}
+
+func TestNotifyWorker_RecurringEventNextOccurrence(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, _, _ := newTestWorker(now)
+
+	// Серия началась неделю назад — напоминание ставится на ближайшее повторение
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, Date: now.AddDate(0, 0, -7).Add(2 * time.Hour), Recurrence: rule, ReminderOffsets: hourBefore})
+
+	if w.queue.Len() != 1 || !w.queue[0].rem.RemindAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected single reminder at +1h, got %d reminders", w.queue.Len())
+	}
+	if !w.queue[0].rem.EventDate.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("expected reminder for today's occurrence, got %v", w.queue[0].rem.EventDate)
+	}
+}
+
+func TestNotifyWorker_RecurringEventEveryOccurrence(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	repo := cache.NewCacheMap()
+	w, clock, fired := newTestWorkerWithRepo(repo, newFakeClock(now))
+
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY;COUNT=3")
+	event := &domain.Event{UserId: 1, Date: now.Add(2 * time.Hour), Description: "Standup", Recurrence: rule, ReminderOffsets: hourBefore}
+	_ = repo.CreateEvent(ctx, event)
+	_ = w.SendNotify(ctx, event)
+
+	// После напоминания о повторении в очередь встаёт напоминание о следующем
+	clock.Advance(time.Hour)
+	for day := range 3 {
+		fireDue(w, ctx)
+		select {
+		case r := <-fired:
+			if want := event.Date.AddDate(0, 0, day); !r.EventDate.Equal(want) {
+				t.Errorf("day %d: expected reminder for %v, got %v", day, want, r.EventDate)
+			}
+		default:
+			t.Fatalf("day %d: reminder was not fired", day)
+		}
+		clock.Advance(24 * time.Hour)
+	}
+	fireDue(w, ctx)
+	if ids := firedIDs(fired); len(ids) != 0 {
+		t.Errorf("expected no reminders after the last occurrence, got %v", ids)
+	}
+	if pending, _ := repo.GetPendingReminders(ctx); len(pending) != 0 {
+		t.Errorf("expected no pending reminders after the series ended, got %+v", pending)
+	}
+}
+
+func TestNotifyWorker_RecurringEventAfterDeadLetterAndRestart(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	repo := cache.NewCacheMap()
+	clock := newFakeClock(now)
+	w, _, _ := newTestWorkerWithRepo(repo, clock)
+	w.retry = RetryPolicy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	w.notify = func(ctx context.Context, r domain.Reminder) error { return errors.New("smtp is down") }
+
+	rule, _ := domain.ParseRecurrenceRule("FREQ=DAILY")
+	event := &domain.Event{UserId: 1, Date: now.Add(2 * time.Hour), Description: "Standup", Recurrence: rule, ReminderOffsets: hourBefore}
+	_ = repo.CreateEvent(ctx, event)
+	_ = w.SendNotify(ctx, event)
+
+	// Напоминание ушло в dead-letter — серия всё равно напоминает о следующем повторении
+	clock.Advance(time.Hour)
+	fireDue(w, ctx)
+	pending, _ := repo.GetPendingReminders(ctx)
+	if len(pending) != 1 || !pending[0].EventDate.Equal(event.Date.AddDate(0, 0, 1)) {
+		t.Fatalf("expected reminder for tomorrow's occurrence, got %+v", pending)
+	}
+
+	// Сервис лежал, пока шло это повторение: после рестарта ставится следующее
+	clock.Advance(26 * time.Hour)
+	second, _, _ := newTestWorkerWithRepo(repo, clock)
+	if err := second.loadPending(ctx); err != nil {
+		t.Fatalf("loadPending: %v", err)
+	}
+	if second.queue.Len() != 1 || !second.queue[0].rem.EventDate.Equal(event.Date.AddDate(0, 0, 2)) {
+		t.Fatalf("expected reminder for the occurrence after restart, got %d reminders", second.queue.Len())
+	}
+}
+
+func TestNotifyWorker_SlowDeliveryDoesNotBlock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)
+	w, clock, fired := newTestWorker(now)
+
+	release := make(chan struct{})
+	w.notify = func(ctx context.Context, r domain.Reminder) error {
+		if r.EventId == 1 {
+			// Канал завис на первом напоминании
+			<-release
+		}
+		fired <- r
+		return nil
+	}
+
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 1, Date: now.Add(2 * time.Hour), ReminderOffsets: hourBefore})
+	_ = w.SendNotify(ctx, &domain.Event{EventId: 2, Date: now.Add(3 * time.Hour), ReminderOffsets: hourBefore})
+
+	clock.Advance(time.Hour)
+	w.fireDue(ctx)
+	clock.Advance(time.Hour)
+	w.fireDue(ctx)
+
+	select {
+	case r := <-fired:
+		if r.EventId != 2 {
+			t.Fatalf("expected event 2 delivered first, got %d", r.EventId)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second reminder is blocked by the slow delivery")
+	}
+
+	close(release)
+	w.deliveries.Wait()
+	if ids := firedIDs(fired); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("expected event [1] after release, got %v", ids)
+	}
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE reminders (
+                           reminder_id  BIGSERIAL PRIMARY KEY,
+                           event_id     BIGINT NOT NULL REFERENCES events (event_id) ON DELETE CASCADE,
+                           user_id      BIGINT NOT NULL,
+                           remind_at    TIMESTAMPTZ NOT NULL,
+                           status       TEXT NOT NULL DEFAULT 'pending',
+                           last_error   TEXT NOT NULL DEFAULT '',
+                           created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                           updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX reminders_pending_idx ON reminders (remind_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE reminders;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- reminders: {"offsets": ["10m", "1h", "1d"], "times": ["2026-03-15T09:00:00Z"]}
+ALTER TABLE events ADD COLUMN reminders JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN reminders;
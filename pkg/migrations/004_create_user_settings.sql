@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE user_settings (
+                               user_id         BIGINT PRIMARY KEY,
+                               notify_channel  TEXT NOT NULL DEFAULT '',
+                               notify_target   TEXT NOT NULL DEFAULT '',
+                               updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE reminders ADD COLUMN channel TEXT NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN target TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE reminders DROP COLUMN target;
+ALTER TABLE reminders DROP COLUMN channel;
+DROP TABLE user_settings;
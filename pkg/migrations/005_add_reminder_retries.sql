@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE reminders ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+CREATE INDEX reminders_dead_idx ON reminders (updated_at) WHERE status = 'dead';
+
+-- +goose Down
+DROP INDEX reminders_dead_idx;
+ALTER TABLE reminders DROP COLUMN next_attempt_at;
+ALTER TABLE reminders DROP COLUMN attempts;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN end_date TIMESTAMPTZ;
+ALTER TABLE events ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- До этой миграции события задавались только датой — считаем их событиями на весь день
+UPDATE events SET end_date = date + INTERVAL '1 day', all_day = TRUE;
+
+ALTER TABLE events ALTER COLUMN end_date SET NOT NULL;
+CREATE INDEX events_user_range_idx ON events (user_id, date, end_date);
+
+-- +goose Down
+DROP INDEX events_user_range_idx;
+ALTER TABLE events DROP COLUMN all_day;
+ALTER TABLE events DROP COLUMN end_date;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN rrule TEXT NOT NULL DEFAULT '';
+-- Начало повторения, к которому относится напоминание; NULL — начало самого события
+ALTER TABLE reminders ADD COLUMN event_date TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE reminders DROP COLUMN event_date;
+ALTER TABLE events DROP COLUMN rrule;
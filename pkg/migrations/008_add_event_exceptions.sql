@@ -0,0 +1,6 @@
+-- +goose Up
+-- Исключения серии: отменённые повторения (exdates) и изменённые повторения (overrides)
+ALTER TABLE events ADD COLUMN exceptions JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN exceptions;
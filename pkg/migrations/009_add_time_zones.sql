@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE user_settings ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';
+-- Часовой пояс, в котором разворачиваются повторения серии
+ALTER TABLE events ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN time_zone;
+ALTER TABLE user_settings DROP COLUMN time_zone;
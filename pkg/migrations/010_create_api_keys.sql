@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE api_keys (
+                          key_id      BIGSERIAL PRIMARY KEY,
+                          user_id     BIGINT NOT NULL,
+                          name        TEXT NOT NULL DEFAULT '',
+                          -- SHA-256 ключа в hex, сам ключ не хранится
+                          key_hash    TEXT NOT NULL UNIQUE,
+                          admin       BOOLEAN NOT NULL DEFAULT false,
+                          created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX api_keys_user_idx ON api_keys (user_id);
+
+-- +goose Down
+DROP TABLE api_keys;
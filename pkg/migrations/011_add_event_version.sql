@@ -0,0 +1,6 @@
+-- +goose Up
+-- Версия события для оптимистичной блокировки: растёт при каждом изменении, отдаётся клиенту как ETag
+ALTER TABLE events ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN version;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Ответы на запросы с Idempotency-Key; status_code = 0 — запрос ещё выполняется
+CREATE TABLE idempotency_keys (
+                                  user_id       BIGINT NOT NULL,
+                                  idem_key      TEXT NOT NULL,
+                                  request_hash  TEXT NOT NULL,
+                                  status_code   INTEGER NOT NULL DEFAULT 0,
+                                  content_type  TEXT NOT NULL DEFAULT '',
+                                  location      TEXT NOT NULL DEFAULT '',
+                                  body          BYTEA,
+                                  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  expires_at    TIMESTAMPTZ NOT NULL,
+                                  PRIMARY KEY (user_id, idem_key)
+);
+
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
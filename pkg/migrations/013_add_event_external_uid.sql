@@ -0,0 +1,8 @@
+-- +goose Up
+-- UID события во внешнем календаре (.ics): повторный импорт того же файла обновляет события, а не дублирует их
+ALTER TABLE events ADD COLUMN external_uid TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX events_user_external_uid_idx ON events (user_id, external_uid) WHERE external_uid <> '';
+
+-- +goose Down
+DROP INDEX events_user_external_uid_idx;
+ALTER TABLE events DROP COLUMN external_uid;
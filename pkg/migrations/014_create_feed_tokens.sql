@@ -0,0 +1,13 @@
+-- +goose Up
+-- Токены подписки на календарь: по одному на пользователя, хранится только SHA-256 токена
+CREATE TABLE feed_tokens (
+                             user_id       BIGINT PRIMARY KEY,
+                             token_hash    TEXT NOT NULL UNIQUE,
+                             -- хеш содержимого последней отданной ленты и время его изменения — для ETag и Last-Modified
+                             content_hash  TEXT NOT NULL DEFAULT '',
+                             modified_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                             created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE feed_tokens;